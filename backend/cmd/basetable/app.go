@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -25,11 +26,17 @@ import (
 	"github.com/basetable/basetable/backend/internal/payment/storage/gorm"
 
 	proxyapi "github.com/basetable/basetable/backend/internal/proxy/api/controller"
+	proxydto "github.com/basetable/basetable/backend/internal/proxy/application/dto"
 	proxyapp "github.com/basetable/basetable/backend/internal/proxy/application/repository"
 	proxyservice "github.com/basetable/basetable/backend/internal/proxy/application/service"
+	proxycatalog "github.com/basetable/basetable/backend/internal/proxy/catalog"
 	proxyclient "github.com/basetable/basetable/backend/internal/proxy/client"
+	proxybilling "github.com/basetable/basetable/backend/internal/proxy/gateway/billing"
+	"github.com/basetable/basetable/backend/internal/proxy/keyring"
+	proxysigner "github.com/basetable/basetable/backend/internal/proxy/signer"
 	proxygmodel "github.com/basetable/basetable/backend/internal/proxy/storage/gorm/model"
 	proxygrepo "github.com/basetable/basetable/backend/internal/proxy/storage/gorm/repository"
+	proxytokenizer "github.com/basetable/basetable/backend/internal/proxy/tokenizer"
 
 	libraryapi "github.com/basetable/basetable/backend/internal/library/api"
 	libraryapp "github.com/basetable/basetable/backend/internal/library/application"
@@ -56,16 +63,29 @@ func Run(ctx context.Context) {
 	httpServer := setupHTTPServer(logger)
 	paymentGateway := setupPaymentGateway(logger)
 	eventBus := setupEventBus(ctx, logger)
+	credentialVault := setupCredentialVault(logger)
 
 	// Build application layer
 	repositories := setupRepositories(db)
-	services := setupServices(repositories, paymentGateway, eventBus)
+	services := setupServices(repositories, paymentGateway, eventBus, credentialVault, logger)
 	controllers := setupControllers(services, logger)
 
 	// Wire everything together
 	setupEventSubscriptions(eventBus, services)
 	setupRoutes(httpServer, controllers)
 
+	// Seal any credentials not yet under the current master key
+	if _, err := services.Provider.RotateCredentialKeys(ctx); err != nil {
+		logger.Errorf("Failed to rotate provider credentials: %v", err)
+	}
+
+	// Reconcile providers to the seed catalog, if one is configured
+	seedCatalog(ctx, services, logger)
+
+	// Start background workers
+	go services.HealthChecker.Run(ctx)
+	go services.SettlementWorker.Run(ctx)
+
 	// Start the server
 	startHTTPServer(ctx, httpServer, logger)
 }
@@ -107,6 +127,11 @@ func runMigrations(db *gormsdk.DB, logger log.Logger) {
 		&proxygmodel.ProviderModel{},
 		&proxygmodel.ModelModel{},
 		&proxygmodel.EndpointModel{},
+		&proxygmodel.KeyModel{},
+		&proxygmodel.TemplateVersionModel{},
+		&proxygmodel.RouteModel{},
+		&proxygmodel.UsageRecordModel{},
+		&proxygmodel.UsageRollupModel{},
 		&librarymodel.AgentModel{},
 	}
 
@@ -115,6 +140,14 @@ func runMigrations(db *gormsdk.DB, logger log.Logger) {
 			logger.Fatalf("Failed to migrate database: %v", err)
 		}
 	}
+
+	if err := gmodel.MigrateMicrocredits(db); err != nil {
+		logger.Fatalf("Failed to migrate credits to micro-credits: %v", err)
+	}
+
+	if err := proxygmodel.MigrateMicrocreditCosts(db); err != nil {
+		logger.Fatalf("Failed to migrate usage costs to micro-credits: %v", err)
+	}
 }
 
 func setupHTTPServer(logger log.Logger) *httpserver.Server {
@@ -140,6 +173,22 @@ func setupEventBus(ctx context.Context, logger log.Logger) eventbus.EventBus {
 	return ebImpl.NewInMemoryBus(ctx, ebImpl.InMemoryBusConfig{Logger: logger})
 }
 
+func setupCredentialVault(logger log.Logger) proxyservice.CredentialVault {
+	masterKeys, err := keyring.ParseMasterKeys(os.Getenv("CREDENTIAL_MASTER_KEYS"))
+	if err != nil {
+		logger.Fatalf("Failed to parse credential master keys: %v", err)
+	}
+
+	keyRing, err := keyring.NewKeyRing(keyring.Config{
+		MasterKeys:   masterKeys,
+		CurrentKeyID: os.Getenv("CREDENTIAL_MASTER_KEY_ID"),
+	})
+	if err != nil {
+		logger.Fatalf("Failed to create credential key ring: %v", err)
+	}
+	return keyRing
+}
+
 type Repositories struct {
 	Payment            paymentapp.PaymentRepository
 	Ledger             repository.LedgerRepository
@@ -147,6 +196,9 @@ type Repositories struct {
 	Reservation        repository.ReservationRepository
 	BillingUnitOfWork  unitofwork.UnitOfWork[repository.RepositoryProvider]
 	Provider           proxyapp.ProviderRepository
+	Route              proxyapp.RouteRepository
+	Usage              proxyapp.UsageRepository
+	TemplateVersion    proxyapp.TemplateVersionRepository
 	ProviderUnitOfWork unitofwork.UnitOfWork[proxyapp.RepositoryProvider]
 	Agent              libraryapp.AgentRepository
 }
@@ -159,6 +211,9 @@ func setupRepositories(db *gormsdk.DB) *Repositories {
 		Reservation:        grepo.NewReservationRepository(db),
 		BillingUnitOfWork:  guow.NewUnitOfWork(db, grepo.NewRepositoryProvider),
 		Provider:           proxygrepo.NewProviderRepository(db),
+		Route:              proxygrepo.NewRouteRepository(db),
+		Usage:              proxygrepo.NewUsageRepository(db),
+		TemplateVersion:    proxygrepo.NewTemplateVersionRepository(db),
 		ProviderUnitOfWork: guow.NewUnitOfWork(db, proxygrepo.NewRepositoryProvider),
 		Agent:              librarymodel.NewAgentRepository(db),
 	}
@@ -170,14 +225,23 @@ type Services struct {
 	Billing  service.BillingService
 	Ledger   service.LedgerService
 	Provider proxyservice.ProviderService
+	Route    proxyservice.RouteService
 	Proxy    proxyservice.ProxyService
+	Usage    proxyservice.UsageService
+	Catalog  proxyservice.CatalogService
+	Template proxyservice.TemplateVersionService
 	Library  libraryapp.LibraryService
+
+	HealthChecker    proxyservice.HealthChecker
+	SettlementWorker service.SettlementWorker
 }
 
 func setupServices(
 	repo *Repositories,
 	paymentGateway paymentapp.PaymentGateway,
 	eventBus eventbus.EventBus,
+	credentialVault proxyservice.CredentialVault,
+	logger log.Logger,
 ) *Services {
 	paymentService := paymentapp.NewPaymentService(
 		paymentGateway,
@@ -186,14 +250,86 @@ func setupServices(
 	)
 
 	accountService := service.NewAccountService(repo.Account)
-	billingService := service.NewBillingService(repo.BillingUnitOfWork)
+	billingService := service.NewBillingService(repo.Account, repo.BillingUnitOfWork)
 	ledgerService := service.NewLedgerService(repo.Ledger)
 
 	// Create HTTP client for proxy requests
 	proxyClient := proxyclient.NewDefaultHTTPProxyClient()
+	signers := proxysigner.NewFactory(proxysigner.Config{})
 
-	providerService := proxyservice.NewProviderService(repo.Provider, repo.ProviderUnitOfWork)
-	proxyService := proxyservice.NewProxyService(providerService, proxyClient)
+	providerCache := proxyservice.NewProviderCache(proxyservice.ProviderCacheConfig{
+		Size: intFromEnv("PROVIDER_CACHE_SIZE", logger),
+		TTL:  durationFromEnv("PROVIDER_CACHE_TTL", logger),
+	})
+	keyTracker := proxyservice.NewKeyTracker(proxyservice.KeyTrackerConfig{
+		Cooldown: durationFromEnv("PROVIDER_KEY_COOLDOWN", logger),
+	})
+
+	providerService := proxyservice.NewProviderService(
+		repo.Provider,
+		repo.ProviderUnitOfWork,
+		credentialVault,
+		signers,
+		proxyClient,
+		providerCache,
+		keyTracker,
+	)
+	routeService := proxyservice.NewRouteService(
+		repo.Route,
+		repo.Provider,
+		providerService,
+		repo.ProviderUnitOfWork,
+	)
+	proxyService := proxyservice.NewProxyService(
+		providerService,
+		routeService,
+		proxyClient,
+		proxybilling.NewGateway(billingService),
+		credentialVault,
+		signers,
+		providerCache,
+		keyTracker,
+		proxytokenizer.NewRegistry(),
+		proxyservice.ContextConfig{
+			SummaryModel:     os.Getenv("CONTEXT_SUMMARY_MODEL"),
+			SummaryMaxTokens: intFromEnv("CONTEXT_SUMMARY_MAX_TOKENS", logger),
+		},
+		repo.Usage,
+		logger,
+	)
+	usageService := proxyservice.NewUsageService(repo.Usage)
+	catalogService := proxyservice.NewCatalogService(
+		providerService,
+		repo.Provider,
+		repo.ProviderUnitOfWork,
+		credentialVault,
+		providerCache,
+	)
+	templateVersionService := proxyservice.NewTemplateVersionService(
+		repo.Provider,
+		repo.TemplateVersion,
+		repo.ProviderUnitOfWork,
+		providerCache,
+	)
+
+	healthChecker := proxyservice.NewHealthChecker(
+		providerService,
+		proxyClient,
+		repo.ProviderUnitOfWork,
+		credentialVault,
+		signers,
+		providerCache,
+		proxyservice.HealthCheckerConfig{
+			Interval: durationFromEnv("HEALTH_CHECK_INTERVAL", logger),
+			Timeout:  durationFromEnv("HEALTH_CHECK_TIMEOUT", logger),
+			Logger:   logger,
+		},
+	)
+
+	settlementWorker := service.NewSettlementWorker(billingService, service.SettlementWorkerConfig{
+		Interval: durationFromEnv("CREDIT_SETTLEMENT_INTERVAL", logger),
+		Logger:   logger,
+	})
 
 	libraryService := libraryapp.NewLibraryService(repo.Agent)
 
@@ -203,32 +339,117 @@ func setupServices(
 		Billing:  billingService,
 		Ledger:   ledgerService,
 		Provider: providerService,
+		Route:    routeService,
 		Proxy:    proxyService,
+		Usage:    usageService,
+		Catalog:  catalogService,
+		Template: templateVersionService,
 		Library:  libraryService,
+
+		HealthChecker:    healthChecker,
+		SettlementWorker: settlementWorker,
 	}
 }
 
+// seedCatalog imports the catalog at CATALOG_SEED_PATH, a file or a
+// directory of them. Unlisted providers are only removed when
+// CATALOG_SEED_PRUNE is true.
+func seedCatalog(ctx context.Context, services *Services, logger log.Logger) {
+	path := os.Getenv("CATALOG_SEED_PATH")
+	if path == "" {
+		return
+	}
+
+	cat, err := proxycatalog.Load(path, os.Getenv)
+	if err != nil {
+		logger.Errorf("Failed to load provider catalog: %v", err)
+		return
+	}
+
+	result, err := services.Catalog.ImportCatalog(ctx, proxydto.ImportCatalogRequest{
+		Catalog: cat,
+		Prune:   os.Getenv("CATALOG_SEED_PRUNE") == "true",
+	})
+	if err != nil {
+		logger.Errorf("Failed to import provider catalog: %v", err)
+		return
+	}
+
+	logger.Infof("Imported provider catalog: %d created, %d updated, %d unchanged, %d removed",
+		len(result.Created), len(result.Updated), len(result.Unchanged), len(result.Removed))
+}
+
+// durationFromEnv parses an optional duration setting, leaving it zero (and
+// so defaulted by the consumer) when unset or invalid.
+func durationFromEnv(key string, logger log.Logger) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Warnf("Ignoring invalid %s %q: %v", key, value, err)
+		return 0
+	}
+	return d
+}
+
+// intFromEnv parses an optional integer setting, leaving it zero when unset
+// or invalid.
+func intFromEnv(key string, logger log.Logger) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Warnf("Ignoring invalid %s %q: %v", key, value, err)
+		return 0
+	}
+	return n
+}
+
 type Controllers struct {
-	Payment  paymentapi.PaymentController
-	Account  controller.AccountController
-	Provider proxyapi.ProviderController
-	Proxy    proxyapi.ProxyController
-	Library  libraryapi.LibraryController
+	Payment   paymentapi.PaymentController
+	Account   controller.AccountController
+	Provider  proxyapi.ProviderController
+	Route     proxyapi.RouteController
+	Proxy     proxyapi.ProxyController
+	OpenAI    proxyapi.OpenAIController
+	Anthropic proxyapi.AnthropicController
+	Usage     proxyapi.UsageController
+	Catalog   proxyapi.CatalogController
+	Template  proxyapi.TemplateVersionController
+	Library   libraryapi.LibraryController
 }
 
 func setupControllers(services *Services, logger log.Logger) *Controllers {
 	paymentController := paymentapi.NewPaymentController(services.Payment, logger)
 	accountController := controller.NewAccountController(services.Account, logger)
 	providerController := proxyapi.NewProviderController(services.Provider)
+	routeController := proxyapi.NewRouteController(services.Route)
 	proxyController := proxyapi.NewProxyController(services.Proxy)
+	openAIController := proxyapi.NewOpenAIController(services.Proxy)
+	anthropicController := proxyapi.NewAnthropicController(services.Proxy)
+	usageController := proxyapi.NewUsageController(services.Usage)
+	catalogController := proxyapi.NewCatalogController(services.Catalog)
+	templateVersionController := proxyapi.NewTemplateVersionController(services.Template)
 	libraryController := libraryapi.NewLibraryController(services.Library, logger)
 
 	return &Controllers{
-		Payment:  paymentController,
-		Account:  accountController,
-		Provider: providerController,
-		Proxy:    proxyController,
-		Library:  libraryController,
+		Payment:   paymentController,
+		Account:   accountController,
+		Provider:  providerController,
+		Route:     routeController,
+		Proxy:     proxyController,
+		OpenAI:    openAIController,
+		Anthropic: anthropicController,
+		Usage:     usageController,
+		Catalog:   catalogController,
+		Template:  templateVersionController,
+		Library:   libraryController,
 	}
 }
 
@@ -273,8 +494,20 @@ func setupRoutes(httpServer *httpserver.Server, controllers *Controllers) {
 		// Proxy routes
 		router.Route("/proxy", func(router httpserver.Router) {
 			router.Post("/request", controllers.Proxy.ProxyRequest)
+			router.Post("/tokenize", controllers.Proxy.Tokenize)
 		})
 
+		// OpenAI-compatible routes
+		router.Route("/chat", func(router httpserver.Router) {
+			router.Post("/completions", controllers.OpenAI.ChatCompletions)
+		})
+
+		// Anthropic-compatible routes
+		router.Post("/messages", controllers.Anthropic.Messages)
+
+		// Usage analytics
+		router.Get("/usage", controllers.Usage.GetUsage)
+
 		// Library routes
 		router.Route("/library", func(router httpserver.Router) {
 			router.Post("/agents", controllers.Library.AddAgent)
@@ -289,19 +522,54 @@ func setupRoutes(httpServer *httpserver.Server, controllers *Controllers) {
 	router.Route("/v1/providers", func(router httpserver.Router) {
 		router.Post("/", controllers.Provider.CreateProvider)
 		router.Get("/", controllers.Provider.ListProviders)
+		router.Get("/catalog", controllers.Catalog.ExportCatalog)
+		router.Post("/catalog", controllers.Catalog.ImportCatalog)
+		router.Post("/credentials/rotate", controllers.Provider.RotateCredentialKeys)
 		router.Get("/{providerID}", controllers.Provider.GetProvider)
 		router.Delete("/{providerID}", controllers.Provider.RemoveProvider)
+		router.Patch("/{providerID}", controllers.Provider.UpdateProvider)
+		router.Post("/{providerID}/activate", controllers.Provider.ActivateProvider)
+		router.Post("/{providerID}/deactivate", controllers.Provider.DeactivateProvider)
 		router.Patch("/{providerID}/template", controllers.Provider.UpdateProviderTemplate)
+		router.Post("/{providerID}/template/preview", controllers.Provider.PreviewTemplate)
+		router.Put("/{providerID}/health-probe", controllers.Provider.UpdateHealthProbe)
+
+		// Template versions
+		router.Get("/{providerID}/template/versions", controllers.Template.ListTemplateVersions)
+		router.Get("/{providerID}/template/versions/{version}", controllers.Template.GetTemplateVersion)
+		router.Get("/{providerID}/template/diff", controllers.Template.DiffTemplateVersions)
+		router.Post("/{providerID}/template/rollback", controllers.Template.RollbackTemplate)
+		router.Put("/{providerID}/template/staged", controllers.Template.StageTemplate)
+		router.Post("/{providerID}/template/staged/promote", controllers.Template.PromoteStagedTemplate)
+		router.Delete("/{providerID}/template/staged", controllers.Template.DiscardStagedTemplate)
 
 		// Model management
 		router.Post("/{providerID}/models", controllers.Provider.AddModels)
+		router.Patch("/{providerID}/models/{modelID}", controllers.Provider.UpdateModel)
 		router.Delete("/{providerID}/models/{modelID}", controllers.Provider.RemoveModel)
 
 		// Endpoint management
 		router.Post("/{providerID}/endpoints", controllers.Provider.AddEndpoints)
+		router.Patch("/{providerID}/endpoints/{endpointURL}", controllers.Provider.UpdateEndpoint)
 		router.Delete("/{providerID}/endpoints/{endpointURL}", controllers.Provider.RemoveEndpoint)
 		router.Post("/{providerID}/endpoints/activate", controllers.Provider.ActivateEndpoint)
 		router.Post("/{providerID}/endpoints/deactivate", controllers.Provider.DeactivateEndpoint)
+
+		// Credential pool management
+		router.Get("/{providerID}/keys", controllers.Provider.ListKeys)
+		router.Post("/{providerID}/keys", controllers.Provider.AddKey)
+		router.Patch("/{providerID}/keys/{keyID}", controllers.Provider.UpdateKey)
+		router.Post("/{providerID}/keys/{keyID}/retire", controllers.Provider.RetireKey)
+		router.Post("/{providerID}/keys/{keyID}/reinstate", controllers.Provider.ReinstateKey)
+	})
+
+	// Model routing policies
+	router.Route("/v1/routes", func(router httpserver.Router) {
+		router.Post("/", controllers.Route.CreateRoute)
+		router.Get("/", controllers.Route.ListRoutes)
+		router.Get("/{routeID}", controllers.Route.GetRoute)
+		router.Put("/{routeID}/targets", controllers.Route.UpdateRouteTargets)
+		router.Delete("/{routeID}", controllers.Route.RemoveRoute)
 	})
 
 	// Webhook routes (Stripe, Auth0, etc.)
@@ -319,6 +587,8 @@ func setupRoutes(httpServer *httpserver.Server, controllers *Controllers) {
 	// Admin API endpoints
 	router.Route("/admin/api/v1", func(router httpserver.Router) {
 		router.Use(auth0.Middleware())
+
+		router.Get("/usage", controllers.Usage.GetAllUsage)
 	})
 }
 
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/basetable/basetable/backend/internal/billing/application/dto"
@@ -12,19 +13,25 @@ import (
 )
 
 type CreateAccountRequest struct {
-	UserID string `json:"user_id"`
+	UserID         string `json:"user_id"`
+	RoundingPolicy string `json:"rounding_policy,omitempty"`
 }
 
 type CreateAccountResponse struct {
 	AccountID string `json:"account_id"`
 }
 
+// GetAccountResponse reports the balance in whole credits and, exactly, in
+// micro-credits (1 credit = 1,000,000 micro-credits).
 type GetAccountResponse struct {
-	ID        string `json:"id"`
-	UserID    string `json:"user_id"`
-	Balance   int64  `json:"balance"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID                    string `json:"id"`
+	UserID                string `json:"user_id"`
+	Balance               int64  `json:"balance"`
+	BalanceMicrocredits   int64  `json:"balance_microcredits"`
+	RoundingPolicy        string `json:"rounding_policy"`
+	UnsettledMicrocredits int64  `json:"unsettled_microcredits"`
+	CreatedAt             string `json:"created_at"`
+	UpdatedAt             string `json:"updated_at"`
 }
 
 type AccountController interface {
@@ -53,11 +60,14 @@ func (c *accountController) GetAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	hutil.WriteJSONResponse(w, r, GetAccountResponse{
-		ID:        accountDTO.ID,
-		UserID:    accountDTO.UserID,
-		Balance:   accountDTO.Balance,
-		CreatedAt: accountDTO.CreatedAt,
-		UpdatedAt: accountDTO.UpdatedAt,
+		ID:                    accountDTO.ID,
+		UserID:                accountDTO.UserID,
+		Balance:               accountDTO.BalanceCredits,
+		BalanceMicrocredits:   accountDTO.Balance,
+		RoundingPolicy:        accountDTO.RoundingPolicy,
+		UnsettledMicrocredits: accountDTO.Unsettled,
+		CreatedAt:             accountDTO.CreatedAt,
+		UpdatedAt:             accountDTO.UpdatedAt,
 	})
 }
 
@@ -68,7 +78,14 @@ func (c *accountController) CreateAccount(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	accountDTO, err := c.accountService.CreateAccount(r.Context(), dto.CreateAccountRequest{UserID: req.UserID})
+	accountDTO, err := c.accountService.CreateAccount(r.Context(), dto.CreateAccountRequest{
+		UserID:         req.UserID,
+		RoundingPolicy: req.RoundingPolicy,
+	})
+	if errors.Is(err, service.ErrInvalidRequest) {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
 	if err != nil {
 		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
 		return
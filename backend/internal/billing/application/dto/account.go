@@ -1,9 +1,15 @@
 package dto
 
+// Account amounts are in micro-credits (1 credit = 1,000,000 micro-credits).
 type Account struct {
-	ID        string
-	UserID    string
-	Balance   int64
+	ID      string
+	UserID  string
+	Balance int64
+	// BalanceCredits is the balance in whole credits, rounded down.
+	BalanceCredits int64
+	RoundingPolicy string
+	// Unsettled is the usage awaiting settlement under accumulated rounding.
+	Unsettled int64
 	CreatedAt string
 	UpdatedAt string
 }
@@ -18,6 +24,8 @@ type ListAccountsResponse struct {
 
 type CreateAccountRequest struct {
 	UserID string
+	// RoundingPolicy is PER_REQUEST or ACCUMULATED, which is the default.
+	RoundingPolicy string
 }
 
 type CreateAccountResponse struct {
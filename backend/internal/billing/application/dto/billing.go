@@ -1,5 +1,6 @@
 package dto
 
+// Amounts are in micro-credits (1 credit = $0.01 = 1,000,000 micro-credits).
 type ReserveCreditRequest struct {
 	AccountID string
 	Amount    int64
@@ -26,3 +27,8 @@ type ReleaseReservationRequest struct {
 type ReleaseReservationResponse struct {
 	LedgerEntryID string
 }
+
+type SettleAccountsResponse struct {
+	Settled        int
+	LedgerEntryIDs []string
+}
@@ -1,6 +1,6 @@
 package dto
 
-// LedgerEntry represents a single ledger entry
+// LedgerEntry represents a single ledger entry. Amount is in micro-credits.
 type LedgerEntry struct {
 	ID        string
 	AccountID string
@@ -10,6 +10,8 @@ type AccountRepository interface {
 	Save(ctx context.Context, creditAccount *account.Account) error
 	GetByID(ctx context.Context, id string) (*account.Account, error)
 	GetByUserID(ctx context.Context, userID string) (*account.Account, error)
+	// GetUnsettled returns the accounts with usage awaiting settlement.
+	GetUnsettled(ctx context.Context) ([]*account.Account, error)
 
 	// Read-for-update with locks
 	GetByIDForUpdate(ctx context.Context, userID string) (*account.Account, error)
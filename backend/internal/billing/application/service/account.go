@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/basetable/basetable/backend/internal/billing/application/dto"
@@ -9,6 +11,9 @@ import (
 	"github.com/basetable/basetable/backend/internal/billing/domain/account"
 )
 
+// ErrInvalidRequest is returned when a request fails validation.
+var ErrInvalidRequest = errors.New("invalid request")
+
 type AccountService interface {
 	GetAccount(ctx context.Context, accountID string) (*dto.GetAccountResponse, error)
 	CreateAccount(ctx context.Context, req dto.CreateAccountRequest) (*dto.CreateAccountResponse, error)
@@ -34,28 +39,44 @@ func (s *accountService) GetAccount(ctx context.Context, accountID string) (*dto
 
 	return &dto.GetAccountResponse{
 		Account: dto.Account{
-			ID:        acc.ID().String(),
-			UserID:    acc.UserID(),
-			Balance:   acc.Balance().Value(),
-			CreatedAt: acc.CreatedAt().Format(time.RFC3339),
-			UpdatedAt: acc.UpdatedAt().Format(time.RFC3339),
+			ID:             acc.ID().String(),
+			UserID:         acc.UserID(),
+			Balance:        acc.Balance().Value(),
+			BalanceCredits: acc.Balance().Credits(),
+			RoundingPolicy: acc.RoundingPolicy().String(),
+			Unsettled:      acc.Unsettled().Value(),
+			CreatedAt:      acc.CreatedAt().Format(time.RFC3339),
+			UpdatedAt:      acc.UpdatedAt().Format(time.RFC3339),
 		},
 	}, nil
 }
 
 func (s *accountService) CreateAccount(ctx context.Context, req dto.CreateAccountRequest) (*dto.CreateAccountResponse, error) {
 	acc := account.New(req.UserID)
+	if req.RoundingPolicy != "" {
+		policy, err := account.ParseRoundingPolicy(req.RoundingPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		if err := acc.UseRoundingPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.accountRepository.Save(ctx, acc); err != nil {
 		return nil, err
 	}
 
 	return &dto.CreateAccountResponse{
 		Account: dto.Account{
-			ID:        acc.ID().String(),
-			UserID:    acc.UserID(),
-			Balance:   acc.Balance().Value(),
-			CreatedAt: acc.CreatedAt().Format(time.RFC3339),
-			UpdatedAt: acc.UpdatedAt().Format(time.RFC3339),
+			ID:             acc.ID().String(),
+			UserID:         acc.UserID(),
+			Balance:        acc.Balance().Value(),
+			BalanceCredits: acc.Balance().Credits(),
+			RoundingPolicy: acc.RoundingPolicy().String(),
+			Unsettled:      acc.Unsettled().Value(),
+			CreatedAt:      acc.CreatedAt().Format(time.RFC3339),
+			UpdatedAt:      acc.UpdatedAt().Format(time.RFC3339),
 		},
 	}, nil
 }
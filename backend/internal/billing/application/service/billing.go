@@ -20,18 +20,26 @@ type BillingService interface {
 	ReserveCredits(ctx context.Context, request dto.ReserveCreditRequest) (*dto.ReserveCreditResponse, error)
 	CommitReservation(ctx context.Context, request dto.CommitReservationRequest) (*dto.CommitReservationResponse, error)
 	ReleaseReservation(ctx context.Context, request dto.ReleaseReservationRequest) (*dto.ReleaseReservationResponse, error)
+	// SettleAccounts rounds the usage each account accumulated since its
+	// last settlement up to a whole credit.
+	SettleAccounts(ctx context.Context) (*dto.SettleAccountsResponse, error)
 }
 
 type billingService struct {
-	domainService service.BillingService
-	uow           unitofwork.UnitOfWork[repository.RepositoryProvider]
+	domainService     service.BillingService
+	accountRepository repository.AccountRepository
+	uow               unitofwork.UnitOfWork[repository.RepositoryProvider]
 }
 
 var _ BillingService = (*billingService)(nil)
 
-func NewBillingService(uow unitofwork.UnitOfWork[repository.RepositoryProvider]) BillingService {
+func NewBillingService(
+	accountRepository repository.AccountRepository,
+	uow unitofwork.UnitOfWork[repository.RepositoryProvider],
+) BillingService {
 	return &billingService{
-		uow: uow,
+		accountRepository: accountRepository,
+		uow:               uow,
 	}
 }
 
@@ -51,7 +59,12 @@ func (s *billingService) HandlePaymentCompleted(ctx context.Context, event share
 			return err
 		}
 
-		credits, _ := account.NewAmount(payload.Amount)
+		// Payments are in cents, one credit each.
+		credits, err := account.NewAmountFromCredits(payload.Amount)
+		if err != nil {
+			return err
+		}
+
 		ledgerEntry, err := s.domainService.AddPaymentCredits(acc, credits, payload.PaymentID, map[string]any{
 			"sent_at":      event.Timestamp,
 			"processed_at": time.Now(),
@@ -122,7 +135,7 @@ func (s *billingService) CommitReservation(ctx context.Context, request dto.Comm
 			return err
 		}
 
-		acc, err := provider.AccountRepository().GetByIDForUpdate(ctx, rsvt.UserID())
+		acc, err := provider.AccountRepository().GetByIDForUpdate(ctx, rsvt.AccountID().String())
 		if err != nil {
 			return err
 		}
@@ -166,7 +179,7 @@ func (s *billingService) ReleaseReservation(ctx context.Context, request dto.Rel
 			return err
 		}
 
-		acc, err := provider.AccountRepository().GetByIDForUpdate(ctx, rsvt.UserID())
+		acc, err := provider.AccountRepository().GetByIDForUpdate(ctx, rsvt.AccountID().String())
 		if err != nil {
 			return err
 		}
@@ -192,6 +205,51 @@ func (s *billingService) ReleaseReservation(ctx context.Context, request dto.Rel
 	}, nil
 }
 
+func (s *billingService) SettleAccounts(ctx context.Context) (*dto.SettleAccountsResponse, error) {
+	accounts, err := s.accountRepository.GetUnsettled(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.SettleAccountsResponse{}
+	for _, unsettled := range accounts {
+		var ledgerEnt *ledger.LedgerEntry
+		if err := s.uow.Do(ctx, func(ctx context.Context, provider repository.RepositoryProvider) error {
+			acc, err := provider.AccountRepository().GetByIDForUpdate(ctx, unsettled.ID().String())
+			if err != nil {
+				return err
+			}
+
+			ledgerEnt, err = s.domainService.SettleAccount(acc)
+			if err != nil {
+				return err
+			}
+
+			saves := []func() error{
+				func() error { return provider.AccountRepository().Save(ctx, acc) },
+			}
+
+			// No ledger entry created if the usage came to whole credits
+			if ledgerEnt != nil {
+				saves = append(saves, func() error {
+					return provider.LedgerRepository().Save(ctx, ledgerEnt)
+				})
+			}
+
+			return s.flushSaves(saves)
+		}); err != nil {
+			return response, err
+		}
+
+		response.Settled++
+		if ledgerEnt != nil {
+			response.LedgerEntryIDs = append(response.LedgerEntryIDs, ledgerEnt.ID().String())
+		}
+	}
+
+	return response, nil
+}
+
 func (s *billingService) flushSaves(saves []func() error) error {
 	for _, save := range saves {
 		if err := save(); err != nil {
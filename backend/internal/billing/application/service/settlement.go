@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/shared/log"
+)
+
+// SettlementWorker periodically settles the accounts that accumulate usage
+// at micro-credit precision, rounding each period's usage up to a whole
+// credit.
+type SettlementWorker interface {
+	// Run settles on every interval until ctx is cancelled. It does not
+	// settle on start, so restarts do not shorten the period.
+	Run(ctx context.Context)
+}
+
+type SettlementWorkerConfig struct {
+	Interval time.Duration
+	Logger   log.Logger
+}
+
+const DefaultSettlementInterval = 24 * time.Hour
+
+var _ SettlementWorker = (*settlementWorker)(nil)
+
+type settlementWorker struct {
+	billingService BillingService
+	interval       time.Duration
+	logger         log.Logger
+}
+
+func NewSettlementWorker(billingService BillingService, cfg SettlementWorkerConfig) SettlementWorker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultSettlementInterval
+	}
+
+	return &settlementWorker{
+		billingService: billingService,
+		interval:       cfg.Interval,
+		logger:         cfg.Logger,
+	}
+}
+
+func (w *settlementWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := w.billingService.SettleAccounts(ctx)
+		if w.logger == nil {
+			continue
+		}
+		if err != nil {
+			w.logger.Errorf("Credit settlement failed: %v", err)
+			continue
+		}
+		w.logger.Infof("Settled %d credit accounts, %d rounded", result.Settled, len(result.LedgerEntryIDs))
+	}
+}
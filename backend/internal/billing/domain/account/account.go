@@ -15,22 +15,28 @@ var (
 )
 
 type Account struct {
-	id        ID
-	userID    string
-	balance   Amount
+	id             ID
+	userID         string
+	balance        Amount
+	roundingPolicy RoundingPolicy
+	// unsettled is the usage charged exactly since the account was last
+	// settled, under RoundingAccumulated.
+	unsettled Amount
 	createdAt time.Time
 	updatedAt time.Time
 }
 
-// New creates a new credit account for initial creation
+// New creates a new credit account for initial creation. Its usage is
+// rounded under RoundingAccumulated.
 func New(userID string) *Account {
 	now := time.Now()
 	return &Account{
-		id:        NewID(),
-		userID:    userID,
-		balance:   Amount{0},
-		createdAt: now,
-		updatedAt: now,
+		id:             NewID(),
+		userID:         userID,
+		balance:        Amount{0},
+		roundingPolicy: RoundingAccumulated,
+		createdAt:      now,
+		updatedAt:      now,
 	}
 }
 
@@ -39,15 +45,19 @@ func Hydrate(
 	id string,
 	userID string,
 	balance int64,
+	roundingPolicy string,
+	unsettled int64,
 	createdAt time.Time,
 	updatedAt time.Time,
 ) *Account {
 	return &Account{
-		id:        HydrateID(id),
-		userID:    userID,
-		balance:   Amount{balance},
-		createdAt: createdAt,
-		updatedAt: updatedAt,
+		id:             HydrateID(id),
+		userID:         userID,
+		balance:        Amount{balance},
+		roundingPolicy: RoundingPolicy{roundingPolicy},
+		unsettled:      Amount{unsettled},
+		createdAt:      createdAt,
+		updatedAt:      updatedAt,
 	}
 }
 
@@ -63,6 +73,15 @@ func (ca *Account) Balance() Amount {
 	return ca.balance
 }
 
+func (ca *Account) RoundingPolicy() RoundingPolicy {
+	return ca.roundingPolicy
+}
+
+// Unsettled returns the usage charged since the account was last settled.
+func (ca *Account) Unsettled() Amount {
+	return ca.unsettled
+}
+
 func (ca *Account) CreatedAt() time.Time {
 	return ca.createdAt
 }
@@ -91,12 +110,56 @@ func (ca *Account) DeductCredits(amount Amount) error {
 	return nil
 }
 
+// UseRoundingPolicy changes how usage is rounded. Usage accumulated under
+// the current policy must be settled first.
+func (ca *Account) UseRoundingPolicy(policy RoundingPolicy) error {
+	if !ca.unsettled.IsZero() {
+		return NewUnsettledUsageError(ca.unsettled.Value())
+	}
+	ca.roundingPolicy = policy
+	ca.updatedAt = time.Now()
+	return nil
+}
+
+// ChargeUsage returns what usage costs under the account's rounding policy.
+// Under RoundingAccumulated the usage is charged exactly and added to the
+// unsettled usage that Settle rounds.
+func (ca *Account) ChargeUsage(usage Amount) (Amount, error) {
+	if ca.roundingPolicy == RoundingPerRequest {
+		return usage.RoundUp()
+	}
+
+	unsettled, err := ca.unsettled.Add(usage)
+	if err != nil {
+		return Amount{}, err
+	}
+	ca.unsettled = unsettled
+	return usage, nil
+}
+
+// Settle rounds the unsettled usage up to a whole credit and deducts the
+// difference, or as much of it as the balance covers. It returns the amount
+// deducted.
+func (ca *Account) Settle() (Amount, error) {
+	rounded, err := ca.unsettled.RoundUp()
+	if err != nil {
+		return Amount{}, err
+	}
+
+	charge := Amount{rounded.value - ca.unsettled.value}.Min(ca.balance)
+	ca.balance = Amount{ca.balance.value - charge.value}
+	ca.unsettled = Amount{}
+	ca.updatedAt = time.Now()
+	return charge, nil
+}
+
 func (ca *Account) String() string {
 	return fmt.Sprintf(
-		"Account(ID: %s, UserID: %s, Balance: %s, CreatedAt: %s, UpdatedAt: %s)",
+		"Account(ID: %s, UserID: %s, Balance: %s, RoundingPolicy: %s, CreatedAt: %s, UpdatedAt: %s)",
 		ca.id,
 		ca.userID,
 		ca.balance,
+		ca.roundingPolicy,
 		ca.createdAt.Format(time.RFC3339),
 		ca.updatedAt.Format(time.RFC3339),
 	)
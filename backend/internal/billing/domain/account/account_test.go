@@ -40,10 +40,11 @@ func TestHydrate(t *testing.T) {
 	id := "account-123"
 	userID := "user123"
 	balance := int64(1000)
+	unsettled := int64(250)
 	createdAt := time.Now().Add(-time.Hour)
 	updatedAt := time.Now()
 
-	account := Hydrate(id, userID, balance, createdAt, updatedAt)
+	account := Hydrate(id, userID, balance, RoundingPerRequest.String(), unsettled, createdAt, updatedAt)
 
 	if account.ID().String() != id {
 		t.Errorf("Expected ID %s, got %s", id, account.ID().String())
@@ -57,6 +58,14 @@ func TestHydrate(t *testing.T) {
 		t.Errorf("Expected balance %d, got %d", balance, account.Balance().Value())
 	}
 
+	if account.RoundingPolicy() != RoundingPerRequest {
+		t.Errorf("Expected rounding policy %v, got %v", RoundingPerRequest, account.RoundingPolicy())
+	}
+
+	if account.Unsettled().Value() != unsettled {
+		t.Errorf("Expected unsettled %d, got %d", unsettled, account.Unsettled().Value())
+	}
+
 	if !account.CreatedAt().Equal(createdAt) {
 		t.Errorf("Expected CreatedAt %v, got %v", createdAt, account.CreatedAt())
 	}
@@ -154,6 +163,96 @@ func TestDeductCreditsInsufficientBalance(t *testing.T) {
 	}
 }
 
+func TestChargeUsage(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        RoundingPolicy
+		usage         int64
+		wantCharge    int64
+		wantUnsettled int64
+	}{
+		{"Per request rounds up", RoundingPerRequest, 1_500, MicrocreditsPerCredit, 0},
+		{"Per request whole credit", RoundingPerRequest, 2 * MicrocreditsPerCredit, 2 * MicrocreditsPerCredit, 0},
+		{"Accumulated is exact", RoundingAccumulated, 1_500, 1_500, 1_500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := New("user123")
+			if err := account.UseRoundingPolicy(tt.policy); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			charge, err := account.ChargeUsage(HydrateAmount(tt.usage))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if charge.Value() != tt.wantCharge {
+				t.Errorf("Expected charge %d, got %d", tt.wantCharge, charge.Value())
+			}
+
+			if account.Unsettled().Value() != tt.wantUnsettled {
+				t.Errorf("Expected unsettled %d, got %d", tt.wantUnsettled, account.Unsettled().Value())
+			}
+		})
+	}
+}
+
+func TestSettle(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     int64
+		usage       []int64
+		wantCharge  int64
+		wantBalance int64
+	}{
+		{"Rounds accumulated usage once", 5 * MicrocreditsPerCredit, []int64{300_000, 450_000}, 250_000, 5*MicrocreditsPerCredit - 250_000},
+		{"Whole credits need no rounding", 5 * MicrocreditsPerCredit, []int64{600_000, 400_000}, 0, 5 * MicrocreditsPerCredit},
+		{"Charge is capped at the balance", 100_000, []int64{10_000}, 100_000, 0},
+		{"Nothing to settle", 100_000, nil, 0, 100_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := New("user123")
+			account.AddCredits(HydrateAmount(tt.balance))
+			for _, usage := range tt.usage {
+				if _, err := account.ChargeUsage(HydrateAmount(usage)); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			}
+
+			charge, err := account.Settle()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if charge.Value() != tt.wantCharge {
+				t.Errorf("Expected charge %d, got %d", tt.wantCharge, charge.Value())
+			}
+
+			if account.Balance().Value() != tt.wantBalance {
+				t.Errorf("Expected balance %d, got %d", tt.wantBalance, account.Balance().Value())
+			}
+
+			if !account.Unsettled().IsZero() {
+				t.Errorf("Expected no unsettled usage, got %d", account.Unsettled().Value())
+			}
+		})
+	}
+}
+
+func TestUseRoundingPolicyWithUnsettledUsage(t *testing.T) {
+	account := New("user123")
+	account.ChargeUsage(HydrateAmount(10))
+
+	err := account.UseRoundingPolicy(RoundingPerRequest)
+	if !domain.IsErrorType(err, ErrorTypeUnsettledUsage) {
+		t.Errorf("Expected unsettled usage error, got %v", err)
+	}
+}
+
 func TestString(t *testing.T) {
 	account := New("user123")
 	str := account.String()
@@ -3,9 +3,11 @@ package account
 import (
 	"fmt"
 	"math"
+	"strings"
 )
 
-// Amount represents a credit balance in cents (1 credit = $0.01)
+// Amount represents a credit balance in micro-credits (1 credit = $0.01 =
+// 1,000,000 micro-credits), fine enough to charge a single token exactly.
 type Amount struct {
 	value int64
 }
@@ -13,6 +15,8 @@ type Amount struct {
 const (
 	MinAmount = 0
 	MaxAmount = math.MaxInt64
+
+	MicrocreditsPerCredit = 1_000_000
 )
 
 func NewAmount(value int64) (Amount, error) {
@@ -23,16 +27,39 @@ func NewAmount(value int64) (Amount, error) {
 	return Amount{value}, nil
 }
 
+// NewAmountFromCredits converts whole credits, such as a payment in cents.
+func NewAmountFromCredits(credits int64) (Amount, error) {
+	if credits < MinAmount || credits > MaxAmount/MicrocreditsPerCredit {
+		return Amount{}, NewInvalidAmountError(credits)
+	}
+
+	return Amount{credits * MicrocreditsPerCredit}, nil
+}
+
 func HydrateAmount(value int64) Amount {
 	return Amount{value: value}
 }
 
+// Value returns the amount in micro-credits.
 func (a Amount) Value() int64 {
 	return a.value
 }
 
+// Credits returns the whole credits in the amount, rounded down.
+func (a Amount) Credits() int64 {
+	return a.value / MicrocreditsPerCredit
+}
+
 func (a Amount) String() string {
-	return fmt.Sprintf("$%.2f (%d credits)", a.Dollars(), a.value)
+	return fmt.Sprintf("$%.2f (%s credits)", a.Dollars(), a.creditsString())
+}
+
+func (a Amount) creditsString() string {
+	frac := a.value % MicrocreditsPerCredit
+	if frac == 0 {
+		return fmt.Sprintf("%d", a.Credits())
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%06d", a.Credits(), frac), "0")
 }
 
 func (a Amount) Add(other Amount) (Amount, error) {
@@ -49,6 +76,19 @@ func (a Amount) Subtract(other Amount) (Amount, error) {
 	return Amount{a.value - other.value}, nil
 }
 
+// RoundUp rounds the amount up to a whole credit.
+func (a Amount) RoundUp() (Amount, error) {
+	frac := a.value % MicrocreditsPerCredit
+	if frac == 0 {
+		return a, nil
+	}
+	return a.Add(Amount{MicrocreditsPerCredit - frac})
+}
+
+func (a Amount) Min(other Amount) Amount {
+	return Amount{min(a.value, other.value)}
+}
+
 func (a Amount) IsZero() bool {
 	return a.value == 0
 }
@@ -58,5 +98,5 @@ func (a Amount) Equals(other Amount) bool {
 }
 
 func (a Amount) Dollars() float64 {
-	return float64(a.value) / 100.0
+	return float64(a.value) / (100 * MicrocreditsPerCredit)
 }
@@ -198,7 +198,7 @@ func TestAmountDollars(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			amount := HydrateAmount(tt.cents)
+			amount := HydrateAmount(tt.cents * MicrocreditsPerCredit)
 			result := amount.Dollars()
 
 			if result != tt.expected {
@@ -215,9 +215,11 @@ func TestAmountString(t *testing.T) {
 		contains []string
 	}{
 		{"Zero", 0, []string{"$0.00", "0 credits"}},
-		{"One dollar", 100, []string{"$1.00", "100 credits"}},
-		{"Fifty cents", 50, []string{"$0.50", "50 credits"}},
-		{"Large amount", 123456, []string{"$1234.56", "123456 credits"}},
+		{"One dollar", 100 * MicrocreditsPerCredit, []string{"$1.00", "100 credits"}},
+		{"Fifty cents", 50 * MicrocreditsPerCredit, []string{"$0.50", "50 credits"}},
+		{"Large amount", 123456 * MicrocreditsPerCredit, []string{"$1234.56", "123456 credits"}},
+		{"Fraction of a credit", 1_250_000, []string{"$0.01", "1.25 credits"}},
+		{"One micro-credit", 1, []string{"$0.00", "0.000001 credits"}},
 	}
 
 	for _, tt := range tests {
@@ -234,6 +236,61 @@ func TestAmountString(t *testing.T) {
 	}
 }
 
+func TestNewAmountFromCredits(t *testing.T) {
+	tests := []struct {
+		name        string
+		credits     int64
+		expected    int64
+		expectError bool
+	}{
+		{"Zero", 0, 0, false},
+		{"Whole credits", 150, 150 * MicrocreditsPerCredit, false},
+		{"Negative", -1, 0, true},
+		{"Too large", MaxAmount/MicrocreditsPerCredit + 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := NewAmountFromCredits(tt.credits)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+			if amount.Value() != tt.expected {
+				t.Errorf("Expected value %d, got %d", tt.expected, amount.Value())
+			}
+		})
+	}
+}
+
+func TestAmountRoundUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int64
+		expected int64
+	}{
+		{"Zero", 0, 0},
+		{"Whole credit", MicrocreditsPerCredit, MicrocreditsPerCredit},
+		{"One micro-credit", 1, MicrocreditsPerCredit},
+		{"Just over a credit", MicrocreditsPerCredit + 1, 2 * MicrocreditsPerCredit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HydrateAmount(tt.value).RoundUp()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Value() != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, result.Value())
+			}
+		})
+	}
+
+	if _, err := HydrateAmount(MaxAmount).RoundUp(); !domain.IsErrorType(err, ErrorTypeOverflow) {
+		t.Errorf("Expected overflow error, got %v", err)
+	}
+}
+
 func containsString(s, substr string) bool {
 	if len(substr) > len(s) {
 		return false
@@ -10,6 +10,7 @@ const (
 	ErrorTypeInvalidAmount      domain.ErrorType = "INVALID_AMOUNT"
 	ErrorTypeInsufficientAmount domain.ErrorType = "INSUFFICIENT_AMOUNT"
 	ErrorTypeOverflow           domain.ErrorType = "OVERFLOW"
+	ErrorTypeUnsettledUsage     domain.ErrorType = "UNSETTLED_USAGE"
 )
 
 func NewInvalidAmountError(value int64) *domain.Error {
@@ -32,3 +33,10 @@ func NewOverflowError(available, requested int64) *domain.Error {
 		Message: fmt.Sprintf("amount overflow: %d + %d exceeds maximum value", available, requested),
 	}
 }
+
+func NewUnsettledUsageError(unsettled int64) *domain.Error {
+	return &domain.Error{
+		Type:    ErrorTypeUnsettledUsage,
+		Message: fmt.Sprintf("account has %d unsettled micro-credits of usage", unsettled),
+	}
+}
@@ -0,0 +1,33 @@
+package account
+
+import "fmt"
+
+// RoundingPolicy decides when an account's usage charges are rounded up to
+// whole credits.
+type RoundingPolicy struct {
+	value string
+}
+
+var (
+	// RoundingPerRequest rounds every usage charge up to a whole credit.
+	RoundingPerRequest = RoundingPolicy{"PER_REQUEST"}
+	// RoundingAccumulated charges usage exactly and rounds the usage
+	// accumulated since the last settlement up to a whole credit when the
+	// account is settled, so a period costs at most one partial credit.
+	RoundingAccumulated = RoundingPolicy{"ACCUMULATED"}
+)
+
+func ParseRoundingPolicy(value string) (RoundingPolicy, error) {
+	switch value {
+	case RoundingPerRequest.value:
+		return RoundingPerRequest, nil
+	case RoundingAccumulated.value:
+		return RoundingAccumulated, nil
+	default:
+		return RoundingPolicy{}, fmt.Errorf("unknown rounding policy %q", value)
+	}
+}
+
+func (p RoundingPolicy) String() string {
+	return p.value
+}
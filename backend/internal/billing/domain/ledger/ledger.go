@@ -24,6 +24,9 @@ var (
 	EntryTypeReservation = EntryType{"RESERVATION"}
 	EntryTypeAdjustment  = EntryType{"ADJUSTMENT"}
 	EntryTypeManual      = EntryType{"MANUAL"}
+	// EntryTypeSettlement rounds usage accumulated since the last settlement
+	// up to a whole credit.
+	EntryTypeSettlement = EntryType{"SETTLEMENT"}
 )
 
 func (t EntryType) String() string {
@@ -35,12 +35,21 @@ func (s *BillingService) CommitReservation(
 		return nil, err
 	}
 
-	var (
-		ledgerEnt *ledger.LedgerEntry
-		err       error
-	)
+	// The charge follows the account's rounding policy.
+	charge, err := acc.ChargeUsage(actualAmount)
+	if err != nil {
+		return nil, err
+	}
 
-	diff := rsvt.Amount().Value() - actualAmount.Value()
+	// Like Settle, rounding takes no more than the reservation and the
+	// balance cover; the usage itself is still charged in full.
+	if covered := rsvt.Amount().Value() + acc.Balance().Value(); charge.Value() > covered {
+		charge, _ = account.NewAmount(max(actualAmount.Value(), covered))
+	}
+
+	var ledgerEnt *ledger.LedgerEntry
+
+	diff := rsvt.Amount().Value() - charge.Value()
 	switch {
 	case diff > 0:
 		refund, _ := account.NewAmount(diff)
@@ -66,10 +75,31 @@ func (s *BillingService) CommitReservation(
 		return nil, err
 	}
 
-	rsvt.Commit(actualAmount)
+	rsvt.Commit(charge)
 	return ledgerEnt, nil
 }
 
+// SettleAccount rounds the usage the account accumulated since its last
+// settlement up to a whole credit. No ledger entry is created when nothing
+// is deducted.
+func (s *BillingService) SettleAccount(acc *account.Account) (*ledger.LedgerEntry, error) {
+	unsettled := acc.Unsettled()
+	charge, err := acc.Settle()
+	if err != nil || charge.IsZero() {
+		return nil, err
+	}
+
+	return ledger.NewEntry(
+		acc.ID(),
+		acc.UserID(),
+		ledger.EntryTypeSettlement,
+		ledger.OperationDeduct,
+		acc.ID().String(),
+		charge,
+		map[string]any{"usage": unsettled.Value()},
+	), nil
+}
+
 func (s *BillingService) ReleaseReservation(
 	acc *account.Account,
 	rsvt *reservation.Reservation,
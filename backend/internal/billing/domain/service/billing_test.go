@@ -222,6 +222,136 @@ func TestCommitReservationWithSurcharge(t *testing.T) {
 	}
 }
 
+func TestCommitReservationPerRequestRounding(t *testing.T) {
+	service := &BillingService{}
+	acc := account.New("user123")
+	acc.UseRoundingPolicy(account.RoundingPerRequest)
+
+	initialAmount, _ := account.NewAmountFromCredits(10)
+	acc.AddCredits(initialAmount)
+
+	reserveAmount, _ := account.NewAmount(500_000)
+	rsvt, _, _ := service.ReserveCredits(acc, reserveAmount)
+
+	// 0.3 credits of usage is charged as a whole credit
+	actualAmount, _ := account.NewAmount(300_000)
+	ledgerEnt, err := service.CommitReservation(acc, rsvt, actualAmount)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ledgerEnt.Operation() != ledger.OperationDeduct {
+		t.Errorf("Expected deduct operation for surcharge, got %v", ledgerEnt.Operation())
+	}
+
+	expectedCharge, _ := account.NewAmountFromCredits(1)
+	if !rsvt.Amount().Equals(expectedCharge) {
+		t.Errorf("Expected reservation amount %v, got %v", expectedCharge, rsvt.Amount())
+	}
+
+	expectedBalance, _ := account.NewAmountFromCredits(9)
+	if !acc.Balance().Equals(expectedBalance) {
+		t.Errorf("Expected account balance %v, got %v", expectedBalance, acc.Balance())
+	}
+
+	if !acc.Unsettled().IsZero() {
+		t.Errorf("Expected no unsettled usage, got %v", acc.Unsettled())
+	}
+}
+
+func TestCommitReservationPerRequestRoundingLowBalance(t *testing.T) {
+	tests := []struct {
+		name        string
+		actual      int64
+		wantErr     bool
+		wantCharge  int64
+		wantBalance int64
+	}{
+		{"Rounding capped at the balance", 1_100_000, false, 1_200_000, 0},
+		{"Whole credits need no rounding", 1_000_000, false, 1_000_000, 200_000},
+		{"Usage beyond the balance", 1_500_000, true, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &BillingService{}
+			acc := account.New("user123")
+			acc.UseRoundingPolicy(account.RoundingPerRequest)
+
+			initialAmount, _ := account.NewAmount(1_200_000)
+			acc.AddCredits(initialAmount)
+
+			reserveAmount, _ := account.NewAmountFromCredits(1)
+			rsvt, _, _ := service.ReserveCredits(acc, reserveAmount)
+
+			actualAmount, _ := account.NewAmount(tt.actual)
+			_, err := service.CommitReservation(acc, rsvt, actualAmount)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error for usage the balance cannot cover")
+				}
+				// The proxy falls back to committing what it reserved.
+				if _, err := service.CommitReservation(acc, rsvt, reserveAmount); err != nil {
+					t.Errorf("Expected the reserved amount to commit, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if rsvt.Amount().Value() != tt.wantCharge {
+				t.Errorf("Expected charge %d, got %d", tt.wantCharge, rsvt.Amount().Value())
+			}
+			if acc.Balance().Value() != tt.wantBalance {
+				t.Errorf("Expected account balance %d, got %d", tt.wantBalance, acc.Balance().Value())
+			}
+		})
+	}
+}
+
+func TestSettleAccount(t *testing.T) {
+	service := &BillingService{}
+	acc := account.New("user123")
+
+	initialAmount, _ := account.NewAmountFromCredits(10)
+	acc.AddCredits(initialAmount)
+
+	for _, usage := range []int64{300_000, 450_000} {
+		amount, _ := account.NewAmount(usage)
+		rsvt, _, _ := service.ReserveCredits(acc, amount)
+		if _, err := service.CommitReservation(acc, rsvt, amount); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	ledgerEnt, err := service.SettleAccount(acc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ledgerEnt.Type() != ledger.EntryTypeSettlement {
+		t.Errorf("Expected settlement type, got %v", ledgerEnt.Type())
+	}
+
+	// 0.75 credits of usage settles to a whole credit
+	expectedCharge, _ := account.NewAmount(250_000)
+	if !ledgerEnt.Amount().Equals(expectedCharge) {
+		t.Errorf("Expected settlement amount %v, got %v", expectedCharge, ledgerEnt.Amount())
+	}
+
+	expectedBalance, _ := account.NewAmountFromCredits(9)
+	if !acc.Balance().Equals(expectedBalance) {
+		t.Errorf("Expected account balance %v, got %v", expectedBalance, acc.Balance())
+	}
+
+	// Settling again has nothing to round
+	ledgerEnt, err = service.SettleAccount(acc)
+	if err != nil || ledgerEnt != nil {
+		t.Errorf("Expected no ledger entry and no error, got %v and %v", ledgerEnt, err)
+	}
+}
+
 func TestCommitReservationAccountMismatch(t *testing.T) {
 	service := &BillingService{}
 	acc1 := account.New("user123")
@@ -6,12 +6,16 @@ import (
 	"github.com/basetable/basetable/backend/internal/billing/domain/account"
 )
 
+// AccountModel holds amounts in micro-credits. The whole-credit balance
+// column of older rows is converted by MigrateMicrocredits.
 type AccountModel struct {
-	ID        string    `gorm:"primaryKey;column:id"`
-	UserID    string    `gorm:"column:user_id"`
-	Balance   int64     `gorm:"column:balance"`
-	CreatedAt time.Time `gorm:"column:created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at"`
+	ID             string    `gorm:"primaryKey;column:id"`
+	UserID         string    `gorm:"column:user_id"`
+	Balance        int64     `gorm:"column:balance_microcredits"`
+	RoundingPolicy string    `gorm:"column:rounding_policy"`
+	Unsettled      int64     `gorm:"column:unsettled_microcredits"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at"`
 }
 
 func (m *AccountModel) TableName() string {
@@ -23,6 +27,8 @@ func (m *AccountModel) MapToDomain() *account.Account {
 		m.ID,
 		m.UserID,
 		m.Balance,
+		m.RoundingPolicy,
+		m.Unsettled,
 		m.CreatedAt,
 		m.UpdatedAt,
 	)
@@ -13,7 +13,7 @@ type LedgerEntryModel struct {
 	EntryType string    `gorm:"column:entry_type"`
 	Operation string    `gorm:"column:operation"`
 	SourceID  string    `gorm:"column:source_id;index"`
-	Amount    int64     `gorm:"column:amount"`
+	Amount    int64     `gorm:"column:amount_microcredits"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 }
 
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/basetable/basetable/backend/internal/billing/domain/account"
+)
+
+// MigrateMicrocredits converts balances, ledger amounts and reservations
+// from the whole-credit columns of older rows to micro-credit columns, and
+// keeps older accounts on per-request rounding, as they were billed before.
+// It runs after AutoMigrate has added the new columns and only touches rows
+// whose new columns are still NULL, so it is safe to run on every start.
+func MigrateMicrocredits(db *gorm.DB) error {
+	conversions := []struct {
+		model    any
+		from, to string
+	}{
+		{&AccountModel{}, "balance", "balance_microcredits"},
+		{&LedgerEntryModel{}, "amount", "amount_microcredits"},
+		{&ReservationModel{}, "amount", "amount_microcredits"},
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range conversions {
+			if !tx.Migrator().HasColumn(c.model, c.from) {
+				continue
+			}
+
+			if err := tx.Model(c.model).
+				Where(c.to+" IS NULL").
+				Update(c.to, gorm.Expr("COALESCE("+c.from+", 0) * ?", account.MicrocreditsPerCredit)).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&AccountModel{}).
+			Where("rounding_policy IS NULL OR rounding_policy = ''").
+			Updates(map[string]any{
+				"rounding_policy":        account.RoundingPerRequest.String(),
+				"unsettled_microcredits": gorm.Expr("COALESCE(unsettled_microcredits, 0)"),
+			}).Error
+	})
+}
@@ -10,7 +10,7 @@ type ReservationModel struct {
 	ID        string    `gorm:"primaryKey;column:id"`
 	AccountID string    `gorm:"column:account_id;index"`
 	UserID    string    `gorm:"column:user_id"`
-	Amount    int64     `gorm:"column:amount"`
+	Amount    int64     `gorm:"column:amount_microcredits"`
 	Status    string    `gorm:"column:status"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at"`
@@ -49,12 +49,28 @@ func (r *AccountRepository) GetByIDForUpdate(ctx context.Context, id string) (*a
 	return acc.MapToDomain(), nil
 }
 
+func (r *AccountRepository) GetUnsettled(ctx context.Context) ([]*account.Account, error) {
+	var accounts []*model.AccountModel
+	if err := r.db.WithContext(ctx).Where("unsettled_microcredits > 0").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	domainAccounts := make([]*account.Account, 0, len(accounts))
+	for _, acc := range accounts {
+		domainAccounts = append(domainAccounts, acc.MapToDomain())
+	}
+
+	return domainAccounts, nil
+}
+
 func (r *AccountRepository) MapDomainToModel(ca *account.Account) *model.AccountModel {
 	return &model.AccountModel{
-		ID:        ca.ID().String(),
-		UserID:    ca.UserID(),
-		Balance:   ca.Balance().Value(),
-		CreatedAt: ca.CreatedAt(),
-		UpdatedAt: ca.UpdatedAt(),
+		ID:             ca.ID().String(),
+		UserID:         ca.UserID(),
+		Balance:        ca.Balance().Value(),
+		RoundingPolicy: ca.RoundingPolicy().String(),
+		Unsettled:      ca.Unsettled().Value(),
+		CreatedAt:      ca.CreatedAt(),
+		UpdatedAt:      ca.UpdatedAt(),
 	}
 }
@@ -0,0 +1,514 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/basetable/basetable/backend/internal/proxy/api/payload"
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	authctx "github.com/basetable/basetable/backend/internal/shared/api/authcontext"
+	hutil "github.com/basetable/basetable/backend/internal/shared/api/httputil"
+)
+
+// AnthropicController exposes the canonical proxy through the Anthropic
+// Messages API wire format.
+type AnthropicController interface {
+	Messages(w http.ResponseWriter, r *http.Request)
+}
+
+type anthropicController struct {
+	proxyService service.ProxyService
+}
+
+func NewAnthropicController(proxyService service.ProxyService) AnthropicController {
+	return &anthropicController{
+		proxyService: proxyService,
+	}
+}
+
+func (c *anthropicController) Messages(w http.ResponseWriter, r *http.Request) {
+	var req payload.MessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAnthropicError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	messages, err := convertAnthropicMessages(req.System, req.Messages)
+	if err != nil {
+		writeAnthropicError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	tools := convertAnthropicTools(req.Tools)
+
+	toolChoice, err := convertAnthropicToolChoice(req.ToolChoice)
+	if err != nil {
+		writeAnthropicError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	dtoReq := dto.Request{
+		RequestID:  requestID(w, r),
+		AccountID:  authctx.GetAccountID(r.Context()),
+		Model:      req.Model,
+		Messages:   messages,
+		Stream:     req.Stream,
+		Tools:      tools,
+		ToolChoice: toolChoice,
+		Params: dto.GenerationParams{
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			MaxTokens:   &req.MaxTokens,
+			Stop:        req.StopSequences,
+		},
+	}
+
+	id := "msg_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	if !dtoReq.Stream {
+		response, err := c.proxyService.ProxyRequest(r.Context(), dtoReq)
+		if err != nil {
+			writeAnthropicProxyError(w, r, err)
+			return
+		}
+
+		hutil.WriteJSONResponse(w, r, convertDTOResponseToAnthropic(id, req.Model, response))
+		return
+	}
+
+	responseChan, err := c.proxyService.ProxyRequestStream(r.Context(), dtoReq)
+	if err != nil {
+		writeAnthropicProxyError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAnthropicError(w, r, http.StatusInternalServerError, "api_error", "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	stream := &anthropicStreamWriter{w: w, flusher: flusher}
+	stream.start(id, req.Model)
+	for response := range responseChan {
+		if response.Err != nil {
+			// A failed stream ends with an error event, not message_stop.
+			stream.fail(response.Err)
+			return
+		}
+
+		stream.write(response)
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+	stream.finish()
+}
+
+// writeAnthropicProxyError maps a proxy failure onto the error envelope: unknown
+// models are the client's fault, anything else is an upstream failure.
+func writeAnthropicProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, service.ErrContextWindowExceeded) {
+		writeAnthropicError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrModelNotFound) {
+		writeAnthropicError(w, r, http.StatusNotFound, "not_found_error", err.Error())
+		return
+	}
+	writeAnthropicError(w, r, http.StatusBadGateway, "api_error", err.Error())
+}
+
+func writeAnthropicError(w http.ResponseWriter, r *http.Request, status int, errType, message string) {
+	hutil.WriteJSONResponseWithStatus(w, r, status, payload.AnthropicErrorResponse{
+		Type: "error",
+		Error: payload.AnthropicError{
+			Type:    errType,
+			Message: message,
+		},
+	})
+}
+
+func convertAnthropicMessages(system json.RawMessage, messages []payload.AnthropicMessage) ([]dto.Message, error) {
+	dtoMessages := make([]dto.Message, 0, len(messages)+1)
+
+	if len(system) > 0 && string(system) != "null" {
+		blocks, err := decodeAnthropicContent(system)
+		if err != nil {
+			return nil, fmt.Errorf("invalid system prompt: %w", err)
+		}
+
+		content, _, err := convertAnthropicBlocks(blocks)
+		if err != nil {
+			return nil, err
+		}
+		dtoMessages = append(dtoMessages, dto.Message{Role: dto.MessageRoleSystem, Content: content})
+	}
+
+	for _, msg := range messages {
+		blocks, err := decodeAnthropicContent(msg.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		content, toolCalls, err := convertAnthropicBlocks(blocks)
+		if err != nil {
+			return nil, err
+		}
+
+		switch msg.Role {
+		case "user":
+			dtoMessages = append(dtoMessages, dto.Message{Role: dto.MessageRoleUser, Content: content})
+		case "assistant":
+			dtoMessages = append(dtoMessages, dto.Message{
+				Role:      dto.MessageRoleAssistant,
+				Content:   content,
+				ToolCalls: toolCalls,
+			})
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+
+	return dtoMessages, nil
+}
+
+// decodeAnthropicContent accepts both the string shorthand and the block
+// array form of message content.
+func decodeAnthropicContent(raw json.RawMessage) ([]payload.AnthropicContentBlock, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []payload.AnthropicContentBlock{{Type: "text", Text: &text}}, nil
+	}
+
+	var blocks []payload.AnthropicContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content blocks: %w", err)
+	}
+	return blocks, nil
+}
+
+func convertAnthropicBlocks(blocks []payload.AnthropicContentBlock) (dto.Content, []dto.ToolCall, error) {
+	content := make(dto.Content, 0, len(blocks))
+	var toolCalls []dto.ToolCall
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			content = append(content, dto.Part{Type: dto.PartTypeText, Body: deref(block.Text)})
+
+		case "thinking":
+			content = append(content, dto.Part{Type: dto.PartTypeThink, Body: deref(block.Thinking)})
+
+		case "redacted_thinking":
+			// Encrypted reasoning is only meaningful to the model that produced it.
+			continue
+
+		case "image":
+			if block.Source == nil || block.Source.Type != "base64" {
+				return nil, nil, errors.New("image blocks must use a base64 source")
+			}
+			content = append(content, dto.Part{
+				Type:      dto.PartTypeImage,
+				Body:      block.Source.Data,
+				MediaType: strings.TrimPrefix(block.Source.MediaType, "image/"),
+			})
+
+		case "document":
+			if block.Source == nil || block.Source.Type != "base64" {
+				return nil, nil, errors.New("document blocks must use a base64 source")
+			}
+			content = append(content, dto.Part{Type: dto.PartTypeFile, Body: block.Source.Data})
+
+		case "tool_use":
+			args := string(block.Input)
+			if args == "" {
+				args = "{}"
+			}
+			toolCalls = append(toolCalls, dto.ToolCall{
+				ID:       dto.ToolCallID(block.ID),
+				ToolType: dto.ToolTypeFunction,
+				Call: dto.FunctionCall{
+					Name: block.Name,
+					Arg:  args,
+				},
+			})
+
+		case "tool_result":
+			resultBlocks, err := decodeAnthropicContent(block.Content)
+			if err != nil {
+				return nil, nil, err
+			}
+			result, _, err := convertAnthropicBlocks(resultBlocks)
+			if err != nil {
+				return nil, nil, err
+			}
+			content = append(content, dto.Part{
+				Type:       dto.PartTypeTool,
+				Body:       joinTextParts(result),
+				ToolCallID: block.ToolUseID,
+			})
+
+		default:
+			return nil, nil, fmt.Errorf("unsupported content block type %q", block.Type)
+		}
+	}
+
+	return content, toolCalls, nil
+}
+
+func convertAnthropicTools(tools []payload.AnthropicTool) []dto.Tool {
+	dtoTools := make([]dto.Tool, 0, len(tools))
+	for _, tool := range tools {
+		dtoTools = append(dtoTools, dto.Tool{
+			ToolType: dto.ToolTypeFunction,
+			ToolDefinition: dto.ToolDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  dto.ParameterSchema{Schema: tool.InputSchema},
+			},
+		})
+	}
+	return dtoTools
+}
+
+func convertAnthropicToolChoice(choice *payload.AnthropicToolChoice) (dto.ToolChoice, error) {
+	if choice == nil {
+		return dto.ToolChoice{Type: dto.ToolChoiceAUto}, nil
+	}
+
+	switch choice.Type {
+	case "auto":
+		return dto.ToolChoice{Type: dto.ToolChoiceAUto}, nil
+	case "none":
+		return dto.ToolChoice{Type: dto.ToolChoiceNone}, nil
+	case "any":
+		return dto.ToolChoice{Type: dto.ToolChoiceFunction}, nil
+	case "tool":
+		if choice.Name == "" {
+			return dto.ToolChoice{}, errors.New("tool_choice of type tool requires a name")
+		}
+		name := choice.Name
+		return dto.ToolChoice{Type: dto.ToolChoiceFunction, FunctionName: &name}, nil
+	default:
+		return dto.ToolChoice{}, fmt.Errorf("unsupported tool_choice type %q", choice.Type)
+	}
+}
+
+func convertDTOResponseToAnthropic(id string, model string, response *dto.Response) payload.MessagesResponse {
+	resp := payload.MessagesResponse{
+		ID:      id,
+		Type:    "message",
+		Role:    "assistant",
+		Model:   model,
+		Content: []payload.AnthropicContentBlock{},
+		Usage: payload.AnthropicUsage{
+			InputTokens:  response.Usage.PromptTokens,
+			OutputTokens: response.Usage.CompletionTokens,
+		},
+	}
+
+	// The Messages API has no notion of multiple choices.
+	if len(response.Choices) == 0 {
+		return resp
+	}
+	choice := response.Choices[0]
+
+	for _, part := range choice.Message.Content {
+		switch part.Type {
+		case dto.PartTypeThink:
+			body := part.Body
+			resp.Content = append(resp.Content, payload.AnthropicContentBlock{Type: "thinking", Thinking: &body})
+		case dto.PartTypeText:
+			body := part.Body
+			resp.Content = append(resp.Content, payload.AnthropicContentBlock{Type: "text", Text: &body})
+		}
+	}
+
+	for _, tc := range choice.Message.ToolCalls {
+		input := json.RawMessage(tc.Call.Arg)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		resp.Content = append(resp.Content, payload.AnthropicContentBlock{
+			Type:  "tool_use",
+			ID:    string(tc.ID),
+			Name:  tc.Call.Name,
+			Input: input,
+		})
+	}
+
+	resp.StopReason = convertDTOFinishReasonToAnthropic(choice.FinishReason)
+	return resp
+}
+
+func convertDTOFinishReasonToAnthropic(reason dto.FinishReason) *string {
+	var r string
+	switch reason {
+	case dto.FinishReasonStop:
+		r = "end_turn"
+	case dto.FinishReasonLength:
+		r = "max_tokens"
+	case dto.FinishReasonToolCalls:
+		r = "tool_use"
+	default:
+		return nil
+	}
+	return &r
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// anthropicStreamWriter turns canonical chunks into the Messages API event
+// sequence. Canonical chunks carry flat parts, so a new content block is
+// opened whenever the part kind changes or a new tool call ID appears.
+type anthropicStreamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+
+	blockIndex int
+	blockType  string // "" when no block is open
+	stopReason *string
+	usage      dto.Usage
+}
+
+func (s *anthropicStreamWriter) start(id, model string) {
+	s.blockIndex = -1
+	s.event("message_start", payload.MessageStartEvent{
+		Type: "message_start",
+		Message: payload.MessagesResponse{
+			ID:      id,
+			Type:    "message",
+			Role:    "assistant",
+			Model:   model,
+			Content: []payload.AnthropicContentBlock{},
+		},
+	})
+}
+
+func (s *anthropicStreamWriter) write(response *dto.Response) {
+	if response.Usage.CompletionTokens > 0 || response.Usage.PromptTokens > 0 {
+		s.usage = response.Usage
+	}
+
+	if len(response.Choices) == 0 {
+		return
+	}
+	choice := response.Choices[0]
+
+	for _, part := range choice.Delta.Content {
+		switch part.Type {
+		case dto.PartTypeThink:
+			s.open("thinking", payload.AnthropicContentBlock{Type: "thinking", Thinking: new(string)})
+			s.delta(payload.AnthropicContentDelta{Type: "thinking_delta", Thinking: part.Body})
+		case dto.PartTypeText:
+			s.open("text", payload.AnthropicContentBlock{Type: "text", Text: new(string)})
+			s.delta(payload.AnthropicContentDelta{Type: "text_delta", Text: part.Body})
+		}
+	}
+
+	for _, tc := range choice.Delta.ToolCalls {
+		if tc.ID != "" || s.blockType != "tool_use" {
+			s.close()
+			s.open("tool_use", payload.AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    string(tc.ID),
+				Name:  tc.Call.Name,
+				Input: json.RawMessage("{}"),
+			})
+		}
+		if tc.Call.Arg != "" {
+			s.delta(payload.AnthropicContentDelta{Type: "input_json_delta", PartialJSON: tc.Call.Arg})
+		}
+	}
+
+	if reason := convertDTOFinishReasonToAnthropic(choice.FinishReason); reason != nil {
+		s.stopReason = reason
+	}
+}
+
+func (s *anthropicStreamWriter) finish() {
+	s.close()
+	s.event("message_delta", payload.MessageDeltaEvent{
+		Type:  "message_delta",
+		Delta: payload.AnthropicStopDelta{StopReason: s.stopReason},
+		Usage: payload.AnthropicDeltaUsage{OutputTokens: s.usage.CompletionTokens},
+	})
+	s.event("message_stop", payload.MessageStopEvent{Type: "message_stop"})
+}
+
+func (s *anthropicStreamWriter) fail(err error) {
+	s.event("error", payload.AnthropicErrorResponse{
+		Type: "error",
+		Error: payload.AnthropicError{
+			Type:    "api_error",
+			Message: err.Error(),
+		},
+	})
+}
+
+// open starts a new block unless one of the same kind is already open.
+// Tool use blocks are always opened explicitly by the caller.
+func (s *anthropicStreamWriter) open(blockType string, block payload.AnthropicContentBlock) {
+	if s.blockType == blockType && blockType != "tool_use" {
+		return
+	}
+
+	s.close()
+	s.blockIndex++
+	s.blockType = blockType
+	s.event("content_block_start", payload.ContentBlockStartEvent{
+		Type:         "content_block_start",
+		Index:        s.blockIndex,
+		ContentBlock: block,
+	})
+}
+
+func (s *anthropicStreamWriter) close() {
+	if s.blockType == "" {
+		return
+	}
+
+	s.event("content_block_stop", payload.ContentBlockStopEvent{
+		Type:  "content_block_stop",
+		Index: s.blockIndex,
+	})
+	s.blockType = ""
+}
+
+func (s *anthropicStreamWriter) delta(delta payload.AnthropicContentDelta) {
+	s.event("content_block_delta", payload.ContentBlockDeltaEvent{
+		Type:  "content_block_delta",
+		Index: s.blockIndex,
+		Delta: delta,
+	})
+}
+
+func (s *anthropicStreamWriter) event(name string, data any) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", name, b)
+	s.flusher.Flush()
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/basetable/basetable/backend/internal/proxy/api/payload"
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	"github.com/basetable/basetable/backend/internal/proxy/catalog"
+	hutil "github.com/basetable/basetable/backend/internal/shared/api/httputil"
+)
+
+// maxCatalogSize bounds the body of a catalog import.
+const maxCatalogSize = 8 << 20
+
+type CatalogController interface {
+	// ImportCatalog reconciles providers to a YAML or JSON catalog. The
+	// prune and dry_run query parameters are passed through to the import.
+	ImportCatalog(w http.ResponseWriter, r *http.Request)
+	// ExportCatalog writes every provider as a catalog, in YAML unless
+	// format=json is given.
+	ExportCatalog(w http.ResponseWriter, r *http.Request)
+}
+
+type catalogController struct {
+	catalogService service.CatalogService
+}
+
+func NewCatalogController(catalogService service.CatalogService) CatalogController {
+	return &catalogController{catalogService: catalogService}
+}
+
+func (c *catalogController) ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	prune, err := boolQuery(r, "prune")
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	dryRun, err := boolQuery(r, "dry_run")
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCatalogSize))
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	cat, err := catalog.Decode(body)
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	result, err := c.catalogService.ImportCatalog(r.Context(), dto.ImportCatalogRequest{
+		Catalog: cat,
+		Prune:   prune,
+		DryRun:  dryRun,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCatalog) {
+			hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+			return
+		}
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, payload.ImportCatalogResponse{
+		Created:   nonNil(result.Created),
+		Updated:   nonNil(result.Updated),
+		Unchanged: nonNil(result.Unchanged),
+		Removed:   nonNil(result.Removed),
+		DryRun:    result.DryRun,
+	})
+}
+
+func (c *catalogController) ExportCatalog(w http.ResponseWriter, r *http.Request) {
+	format := catalog.Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = catalog.FormatYAML
+	}
+	if !format.IsValid() {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(fmt.Errorf("unknown format %q", format)))
+		return
+	}
+
+	cat, err := c.catalogService.ExportCatalog(r.Context())
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	data, err := catalog.Encode(*cat, format)
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	contentType := "application/yaml"
+	if format == catalog.FormatJSON {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func boolQuery(r *http.Request, key string) (bool, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func nonNil(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
@@ -0,0 +1,617 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/basetable/basetable/backend/internal/proxy/api/payload"
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	authctx "github.com/basetable/basetable/backend/internal/shared/api/authcontext"
+	hutil "github.com/basetable/basetable/backend/internal/shared/api/httputil"
+)
+
+// OpenAIController exposes the canonical proxy through the OpenAI Chat
+// Completions wire format.
+type OpenAIController interface {
+	ChatCompletions(w http.ResponseWriter, r *http.Request)
+}
+
+type openAIController struct {
+	proxyService service.ProxyService
+}
+
+func NewOpenAIController(proxyService service.ProxyService) OpenAIController {
+	return &openAIController{
+		proxyService: proxyService,
+	}
+}
+
+func (c *openAIController) ChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req payload.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	messages, err := convertOpenAIMessages(req.Messages)
+	if err != nil {
+		writeOpenAIError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	toolChoice, err := convertOpenAIToolChoice(req.ToolChoice)
+	if err != nil {
+		writeOpenAIError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	tools, err := convertOpenAITools(req.Tools)
+	if err != nil {
+		writeOpenAIError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	params, err := convertOpenAIGenerationParams(req)
+	if err != nil {
+		writeOpenAIError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	responseFormat, err := convertOpenAIResponseFormat(req.ResponseFormat)
+	if err != nil {
+		writeOpenAIError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	dtoReq := dto.Request{
+		RequestID:  requestID(w, r),
+		AccountID:  authctx.GetAccountID(r.Context()),
+		Model:      req.Model,
+		Messages:   messages,
+		Stream:     req.Stream,
+		Tools:      tools,
+		ToolChoice: toolChoice,
+		Params:     params,
+
+		ResponseFormat: responseFormat,
+	}
+
+	id := "chatcmpl-" + uuid.NewString()
+	created := time.Now().Unix()
+
+	if !dtoReq.Stream {
+		response, err := c.proxyService.ProxyRequest(r.Context(), dtoReq)
+		if err != nil {
+			writeOpenAIProxyError(w, r, err)
+			return
+		}
+
+		hutil.WriteJSONResponse(w, r, convertDTOResponseToOpenAI(id, created, req.Model, response))
+		return
+	}
+
+	responseChan, err := c.proxyService.ProxyRequestStream(r.Context(), dtoReq)
+	if err != nil {
+		writeOpenAIProxyError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeOpenAIError(w, r, http.StatusInternalServerError, "api_error", "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+	indexer := newToolCallIndexer()
+	first := true
+	for response := range responseChan {
+		if response.Err != nil {
+			writeOpenAIStreamError(w, flusher, "api_error", response.Err.Error())
+			return
+		}
+
+		chunk := convertDTOResponseToOpenAIChunk(id, created, req.Model, response, indexer, first)
+		first = false
+		if !includeUsage {
+			chunk.Usage = nil
+		}
+
+		chunkJSON, err := json.Marshal(chunk)
+		if err != nil {
+			// A stream missing a chunk must not look complete, so it ends
+			// with an error instead of [DONE].
+			writeOpenAIStreamError(w, flusher, "api_error", fmt.Sprintf("failed to encode chunk: %v", err))
+			return
+		}
+
+		fmt.Fprintf(w, "data: %s\n\n", chunkJSON)
+		flusher.Flush()
+
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+// writeOpenAIProxyError maps a proxy failure onto the error envelope: unknown
+// models are the client's fault, anything else is an upstream failure.
+func writeOpenAIProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrInvalidRequest) {
+		writeOpenAIError(w, r, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrContextWindowExceeded) {
+		code := "context_length_exceeded"
+		hutil.WriteJSONResponseWithStatus(w, r, http.StatusBadRequest, payload.ChatErrorResponse{
+			Error: payload.ChatError{
+				Message: err.Error(),
+				Type:    "invalid_request_error",
+				Code:    &code,
+			},
+		})
+		return
+	}
+	if errors.Is(err, service.ErrResponseFormatMismatch) {
+		writeOpenAIError(w, r, http.StatusBadGateway, "response_format_error", err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrModelNotFound) {
+		writeOpenAIError(w, r, http.StatusNotFound, "invalid_request_error", err.Error())
+		return
+	}
+	writeOpenAIError(w, r, http.StatusBadGateway, "api_error", err.Error())
+}
+
+// writeOpenAIStreamError reports a failure once a stream has started, when the
+// status can no longer change, as a data event carrying the error envelope.
+func writeOpenAIStreamError(w http.ResponseWriter, flusher http.Flusher, errType, message string) {
+	errJSON, _ := json.Marshal(payload.ChatErrorResponse{
+		Error: payload.ChatError{
+			Message: message,
+			Type:    errType,
+		},
+	})
+
+	fmt.Fprintf(w, "data: %s\n\n", errJSON)
+	flusher.Flush()
+}
+
+func writeOpenAIError(w http.ResponseWriter, r *http.Request, status int, errType, message string) {
+	hutil.WriteJSONResponseWithStatus(w, r, status, payload.ChatErrorResponse{
+		Error: payload.ChatError{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
+
+func convertOpenAIMessages(messages []payload.ChatMessage) ([]dto.Message, error) {
+	dtoMessages := make([]dto.Message, 0, len(messages))
+	for _, msg := range messages {
+		content, err := convertOpenAIContent(msg.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		switch msg.Role {
+		case "system", "developer":
+			dtoMessages = append(dtoMessages, dto.Message{
+				Role:    dto.MessageRoleSystem,
+				Content: content,
+			})
+
+		case "user":
+			dtoMessages = append(dtoMessages, dto.Message{
+				Role:    dto.MessageRoleUser,
+				Content: content,
+			})
+
+		case "assistant":
+			if msg.ReasoningContent != "" {
+				content = append(dto.Content{{Type: dto.PartTypeThink, Body: msg.ReasoningContent}}, content...)
+			}
+
+			toolCalls := make([]dto.ToolCall, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				toolCalls[i] = dto.ToolCall{
+					ID:       dto.ToolCallID(tc.ID),
+					ToolType: dto.ToolTypeFunction,
+					Call: dto.FunctionCall{
+						Name: tc.Function.Name,
+						Arg:  tc.Function.Arguments,
+					},
+				}
+			}
+
+			dtoMessages = append(dtoMessages, dto.Message{
+				Role:      dto.MessageRoleAssistant,
+				Content:   content,
+				ToolCalls: toolCalls,
+			})
+
+		case "tool":
+			// Canonical requests carry tool results as tool parts on a user
+			// message, so provider templates only have one shape to handle.
+			dtoMessages = append(dtoMessages, dto.Message{
+				Role: dto.MessageRoleUser,
+				Content: dto.Content{{
+					Type:       dto.PartTypeTool,
+					Body:       joinTextParts(content),
+					ToolCallID: msg.ToolCallID,
+				}},
+			})
+
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+
+	return dtoMessages, nil
+}
+
+func convertOpenAIContent(raw json.RawMessage) (dto.Content, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return dto.Content{}, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return dto.Content{{Type: dto.PartTypeText, Body: text}}, nil
+	}
+
+	var parts []payload.ChatContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of parts: %w", err)
+	}
+
+	content := make(dto.Content, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			content = append(content, dto.Part{Type: dto.PartTypeText, Body: part.Text})
+
+		case "image_url":
+			if part.ImageURL == nil {
+				return nil, errors.New("image_url part is missing image_url")
+			}
+			image, err := convertOpenAIImageURL(part.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, image)
+
+		case "file":
+			if part.File == nil {
+				return nil, errors.New("file part is missing file")
+			}
+			_, data, err := parseDataURL(part.File.FileData)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, dto.Part{Type: dto.PartTypeFile, Body: data})
+
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", part.Type)
+		}
+	}
+
+	return content, nil
+}
+
+// convertOpenAIImageURL inlines base64 data URLs and passes http(s) URLs on
+// for the provider to fetch. Basetable never fetches images itself, so
+// providers that cannot take image URLs receive a note in their place.
+func convertOpenAIImageURL(url string) (dto.Part, error) {
+	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+		return dto.Part{Type: dto.PartTypeImage, URL: url}, nil
+	}
+
+	mediaType, data, err := parseDataURL(url)
+	if err != nil {
+		return dto.Part{}, fmt.Errorf("image_url must be an http(s) URL or a base64 data URL: %w", err)
+	}
+
+	return dto.Part{
+		Type:      dto.PartTypeImage,
+		Body:      data,
+		MediaType: strings.TrimPrefix(mediaType, "image/"),
+	}, nil
+}
+
+// parseDataURL splits a base64 data URL into its media type and payload.
+// Remote URLs are not fetched.
+func parseDataURL(url string) (string, string, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", "", errors.New("only base64 data URLs are supported")
+	}
+
+	meta, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", "", errors.New("malformed data URL")
+	}
+
+	mediaType, ok := strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return "", "", errors.New("data URL must be base64 encoded")
+	}
+
+	return mediaType, data, nil
+}
+
+func joinTextParts(content dto.Content) string {
+	var sb strings.Builder
+	for _, part := range content {
+		if part.Type == dto.PartTypeText {
+			sb.WriteString(part.Body)
+		}
+	}
+	return sb.String()
+}
+
+func convertOpenAIToolChoice(raw json.RawMessage) (dto.ToolChoice, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return dto.ToolChoice{Type: dto.ToolChoiceAUto}, nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		switch mode {
+		case "none":
+			return dto.ToolChoice{Type: dto.ToolChoiceNone}, nil
+		case "auto":
+			return dto.ToolChoice{Type: dto.ToolChoiceAUto}, nil
+		case "required":
+			return dto.ToolChoice{Type: dto.ToolChoiceFunction}, nil
+		default:
+			return dto.ToolChoice{}, fmt.Errorf("unsupported tool_choice %q", mode)
+		}
+	}
+
+	var choice payload.ChatToolChoice
+	if err := json.Unmarshal(raw, &choice); err != nil || choice.Type != "function" || choice.Function.Name == "" {
+		return dto.ToolChoice{}, errors.New("tool_choice must be none, auto, required or a function")
+	}
+
+	name := choice.Function.Name
+	return dto.ToolChoice{Type: dto.ToolChoiceFunction, FunctionName: &name}, nil
+}
+
+func convertOpenAIGenerationParams(req payload.ChatCompletionRequest) (dto.GenerationParams, error) {
+	params := dto.GenerationParams{
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		MaxTokens:        req.MaxTokens,
+		Seed:             req.Seed,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+	}
+	if req.MaxCompletionTokens != nil {
+		params.MaxTokens = req.MaxCompletionTokens
+	}
+
+	if len(req.Stop) == 0 || string(req.Stop) == "null" {
+		return params, nil
+	}
+
+	var stop string
+	if err := json.Unmarshal(req.Stop, &stop); err == nil {
+		params.Stop = []string{stop}
+		return params, nil
+	}
+
+	if err := json.Unmarshal(req.Stop, &params.Stop); err != nil {
+		return dto.GenerationParams{}, errors.New("stop must be a string or an array of strings")
+	}
+
+	return params, nil
+}
+
+func convertOpenAIResponseFormat(format *payload.ChatResponseFormat) (dto.ResponseFormat, error) {
+	if format == nil {
+		return dto.ResponseFormat{}, nil
+	}
+
+	responseFormat := dto.ResponseFormat{Type: dto.ResponseFormatType(format.Type)}
+	if responseFormat.Type == dto.ResponseFormatJSONSchema {
+		if format.JSONSchema == nil {
+			return dto.ResponseFormat{}, errors.New("response_format.json_schema is required")
+		}
+		responseFormat.Name = format.JSONSchema.Name
+		responseFormat.Schema = format.JSONSchema.Schema
+		responseFormat.Strict = format.JSONSchema.Strict
+	}
+
+	return responseFormat, nil
+}
+
+func convertOpenAITools(tools []payload.ChatTool) ([]dto.Tool, error) {
+	dtoTools := make([]dto.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if tool.Type != "function" {
+			return nil, fmt.Errorf("unsupported tool type %q", tool.Type)
+		}
+
+		dtoTools = append(dtoTools, dto.Tool{
+			ToolType: dto.ToolTypeFunction,
+			ToolDefinition: dto.ToolDefinition{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  dto.ParameterSchema{Schema: tool.Function.Parameters},
+			},
+		})
+	}
+
+	return dtoTools, nil
+}
+
+func convertDTOResponseToOpenAI(id string, created int64, model string, response *dto.Response) payload.ChatCompletionResponse {
+	choices := make([]payload.ChatChoice, len(response.Choices))
+	for i, choice := range response.Choices {
+		choices[i] = payload.ChatChoice{
+			Index:        choice.Index,
+			Message:      convertDTOMessageToOpenAI(string(choice.Message.Role), choice.Message.Content, choice.Message.ToolCalls, nil),
+			FinishReason: convertDTOFinishReasonToOpenAI(choice.FinishReason),
+		}
+	}
+
+	return payload.ChatCompletionResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   model,
+		Choices: choices,
+		Usage:   convertDTOUsageToOpenAI(response.Usage),
+	}
+}
+
+func convertDTOResponseToOpenAIChunk(
+	id string,
+	created int64,
+	model string,
+	response *dto.Response,
+	indexer *toolCallIndexer,
+	first bool,
+) payload.ChatCompletionChunk {
+	choices := make([]payload.ChatChunkChoice, len(response.Choices))
+	for i, choice := range response.Choices {
+		role := choice.Delta.Role
+		if role == "" && first {
+			role = string(dto.MessageRoleAssistant)
+		}
+
+		choices[i] = payload.ChatChunkChoice{
+			Index:        choice.Index,
+			Delta:        convertDTOMessageToOpenAI(role, choice.Delta.Content, choice.Delta.ToolCalls, indexer.forChoice(choice.Index)),
+			FinishReason: convertDTOFinishReasonToOpenAI(choice.FinishReason),
+		}
+	}
+
+	return payload.ChatCompletionChunk{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: choices,
+		Usage:   convertDTOUsageToOpenAI(response.Usage),
+	}
+}
+
+func convertDTOMessageToOpenAI(role string, content dto.Content, toolCalls []dto.ToolCall, indexOf func(dto.ToolCallID) int) payload.ChatResponseMessage {
+	var text, reasoning strings.Builder
+	for _, part := range content {
+		switch part.Type {
+		case dto.PartTypeThink:
+			reasoning.WriteString(part.Body)
+		case dto.PartTypeText:
+			text.WriteString(part.Body)
+		}
+	}
+
+	msg := payload.ChatResponseMessage{
+		Role:             role,
+		ReasoningContent: reasoning.String(),
+	}
+	if text.Len() > 0 || len(toolCalls) == 0 {
+		body := text.String()
+		msg.Content = &body
+	}
+
+	for _, tc := range toolCalls {
+		call := payload.ChatToolCall{
+			ID: string(tc.ID),
+			Function: payload.ChatFunctionCall{
+				Name:      tc.Call.Name,
+				Arguments: tc.Call.Arg,
+			},
+		}
+		if tc.ID != "" {
+			call.Type = "function"
+		}
+		if indexOf != nil {
+			index := indexOf(tc.ID)
+			call.Index = &index
+		}
+		msg.ToolCalls = append(msg.ToolCalls, call)
+	}
+
+	return msg
+}
+
+func convertDTOFinishReasonToOpenAI(reason dto.FinishReason) *string {
+	if reason == "" {
+		return nil
+	}
+	r := string(reason)
+	return &r
+}
+
+func convertDTOUsageToOpenAI(usage dto.Usage) *payload.ChatUsage {
+	if usage.TotalTokens == 0 && usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return nil
+	}
+	chatUsage := &payload.ChatUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+	if usage.CachedPromptTokens > 0 {
+		chatUsage.PromptTokensDetails = &payload.ChatPromptTokensDetails{CachedTokens: usage.CachedPromptTokens}
+	}
+	if usage.ReasoningTokens > 0 {
+		chatUsage.CompletionTokensDetails = &payload.ChatCompletionTokensDetails{ReasoningTokens: usage.ReasoningTokens}
+	}
+	return chatUsage
+}
+
+// toolCallIndexer assigns the positional index OpenAI streams use to stitch
+// tool call fragments together. Canonical chunks identify a call by ID on its
+// first fragment only, so fragments without an ID belong to the last call seen.
+type toolCallIndexer struct {
+	choices map[int]*choiceToolCalls
+}
+
+type choiceToolCalls struct {
+	indexes map[dto.ToolCallID]int
+	last    int
+}
+
+func newToolCallIndexer() *toolCallIndexer {
+	return &toolCallIndexer{choices: make(map[int]*choiceToolCalls)}
+}
+
+func (t *toolCallIndexer) forChoice(choice int) func(dto.ToolCallID) int {
+	calls, ok := t.choices[choice]
+	if !ok {
+		calls = &choiceToolCalls{indexes: make(map[dto.ToolCallID]int), last: -1}
+		t.choices[choice] = calls
+	}
+
+	return func(id dto.ToolCallID) int {
+		if id == "" {
+			return max(calls.last, 0)
+		}
+		if index, ok := calls.indexes[id]; ok {
+			calls.last = index
+			return index
+		}
+		calls.last = len(calls.indexes)
+		calls.indexes[id] = calls.last
+		return calls.last
+	}
+}
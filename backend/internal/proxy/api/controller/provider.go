@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 
 	"github.com/go-chi/chi"
 
@@ -15,15 +18,28 @@ import (
 type ProviderController interface {
 	CreateProvider(w http.ResponseWriter, r *http.Request)
 	UpdateProviderTemplate(w http.ResponseWriter, r *http.Request)
+	PreviewTemplate(w http.ResponseWriter, r *http.Request)
+	UpdateHealthProbe(w http.ResponseWriter, r *http.Request)
+	RotateCredentialKeys(w http.ResponseWriter, r *http.Request)
 	GetProvider(w http.ResponseWriter, r *http.Request)
 	RemoveProvider(w http.ResponseWriter, r *http.Request)
+	UpdateProvider(w http.ResponseWriter, r *http.Request)
+	ActivateProvider(w http.ResponseWriter, r *http.Request)
+	DeactivateProvider(w http.ResponseWriter, r *http.Request)
 	ListProviders(w http.ResponseWriter, r *http.Request)
 	AddModels(w http.ResponseWriter, r *http.Request)
+	UpdateModel(w http.ResponseWriter, r *http.Request)
 	RemoveModel(w http.ResponseWriter, r *http.Request)
 	AddEndpoints(w http.ResponseWriter, r *http.Request)
+	UpdateEndpoint(w http.ResponseWriter, r *http.Request)
 	RemoveEndpoint(w http.ResponseWriter, r *http.Request)
 	ActivateEndpoint(w http.ResponseWriter, r *http.Request)
 	DeactivateEndpoint(w http.ResponseWriter, r *http.Request)
+	ListKeys(w http.ResponseWriter, r *http.Request)
+	AddKey(w http.ResponseWriter, r *http.Request)
+	UpdateKey(w http.ResponseWriter, r *http.Request)
+	RetireKey(w http.ResponseWriter, r *http.Request)
+	ReinstateKey(w http.ResponseWriter, r *http.Request)
 }
 
 type providerController struct {
@@ -44,21 +60,25 @@ func (c *providerController) CreateProvider(w http.ResponseWriter, r *http.Reque
 
 	dtoReq := dto.CreateProviderRequest{
 		Name:             req.Name,
+		Preset:           req.Preset,
 		BaseURL:          req.BaseURL,
 		RequestTemplate:  req.RequestTemplate,
 		ResponseTemplate: req.ResponseTemplate,
 		Headers:          req.Headers,
-		Auth: dto.AuthConfig{
-			Type:       req.Auth.Type,
-			Header:     req.Auth.Header,
-			Prefix:     req.Auth.Prefix,
-			Credential: req.Auth.Credential,
-		},
+		SampleResponse:   req.SampleResponse,
+		Author:           req.Author,
+		Auth:             convertPayloadAuthToDTO(req.Auth),
+	}
+	if req.HealthProbe != nil {
+		dtoReq.HealthProbe = dto.HealthProbe{
+			ModelKey: req.HealthProbe.ModelKey,
+			Prompt:   req.HealthProbe.Prompt,
+		}
 	}
 
 	provider, err := c.providerService.CreateProvider(r.Context(), dtoReq)
 	if err != nil {
-		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		writeProviderError(w, r, err)
 		return
 	}
 
@@ -78,6 +98,72 @@ func (c *providerController) UpdateProviderTemplate(w http.ResponseWriter, r *ht
 		ProviderID:       providerID,
 		RequestTemplate:  req.RequestTemplate,
 		ResponseTemplate: req.ResponseTemplate,
+		SampleResponse:   req.SampleResponse,
+		Author:           req.Author,
+	})
+	if err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *providerController) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	var req payload.PreviewTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	dtoReq := dto.PreviewTemplateRequest{
+		ProviderID:       providerID,
+		RequestTemplate:  req.RequestTemplate,
+		ResponseTemplate: req.ResponseTemplate,
+		ProviderResponse: req.ProviderResponse,
+	}
+	if req.Request != nil {
+		request := convertProxyRequest(*req.Request)
+		dtoReq.Request = &request
+	}
+
+	preview, err := c.providerService.PreviewTemplate(r.Context(), dtoReq)
+	if err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	response := payload.PreviewTemplateResponse{
+		Method:        preview.Method,
+		Target:        preview.Target,
+		Headers:       preview.Headers,
+		RequestBody:   string(preview.RequestBody),
+		RequestError:  preview.RequestError,
+		ResponseError: preview.ResponseError,
+	}
+	if preview.Response != nil {
+		mapped := convertDTOResponseToPayload(preview.Response)
+		response.Response = &mapped
+	}
+
+	hutil.WriteJSONResponse(w, r, response)
+}
+
+func (c *providerController) UpdateHealthProbe(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	var req payload.HealthProbe
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	err := c.providerService.UpdateHealthProbe(r.Context(), dto.UpdateHealthProbeRequest{
+		ProviderID: providerID,
+		HealthProbe: dto.HealthProbe{
+			ModelKey: req.ModelKey,
+			Prompt:   req.Prompt,
+		},
 	})
 	if err != nil {
 		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
@@ -87,6 +173,19 @@ func (c *providerController) UpdateProviderTemplate(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (c *providerController) RotateCredentialKeys(w http.ResponseWriter, r *http.Request) {
+	result, err := c.providerService.RotateCredentialKeys(r.Context())
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, payload.RotateCredentialKeysResponse{
+		KeyID:   result.KeyID,
+		Rotated: result.Rotated,
+	})
+}
+
 func (c *providerController) GetProvider(w http.ResponseWriter, r *http.Request) {
 	providerID := chi.URLParam(r, "providerID")
 	if providerID == "" {
@@ -120,6 +219,53 @@ func (c *providerController) RemoveProvider(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (c *providerController) UpdateProvider(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	var req payload.UpdateProviderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	dtoReq := dto.UpdateProviderRequest{
+		ProviderID:  providerID,
+		BaseURL:     req.BaseURL,
+		Headers:     req.Headers,
+		KeyStrategy: req.KeyStrategy,
+	}
+	if req.Auth != nil {
+		auth := convertPayloadAuthToDTO(*req.Auth)
+		dtoReq.Auth = &auth
+	}
+
+	if err := c.providerService.UpdateProvider(r.Context(), dtoReq); err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *providerController) ActivateProvider(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	if err := c.providerService.ActivateProvider(r.Context(), providerID); err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *providerController) DeactivateProvider(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	if err := c.providerService.DeactivateProvider(r.Context(), providerID); err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (c *providerController) ListProviders(w http.ResponseWriter, r *http.Request) {
 	providers, err := c.providerService.ListProviders(r.Context())
 	if err != nil {
@@ -160,6 +306,55 @@ func (c *providerController) AddModels(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// UpdateModel accepts the model's ID or its key. Keys containing a slash
+// must be escaped in the path.
+func (c *providerController) UpdateModel(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	modelID, err := url.PathUnescape(chi.URLParam(r, "modelID"))
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	var req payload.UpdateModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	dtoReq := dto.UpdateModelRequest{
+		ProviderID:  providerID,
+		Model:       modelID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if req.Capabilities != nil {
+		dtoReq.Capabilities = &dto.Capabilities{
+			FunctionCalling:  req.Capabilities.FunctionCalling,
+			Streaming:        req.Capabilities.Streaming,
+			StructuredOutput: req.Capabilities.StructuredOutput,
+		}
+	}
+	if req.Limits != nil {
+		dtoReq.Limits = &dto.Limits{
+			ContextWindow:    req.Limits.ContextWindow,
+			MaxOutputTokens:  req.Limits.MaxOutputTokens,
+			MaxStopSequences: req.Limits.MaxStopSequences,
+		}
+	}
+	if req.Pricing != nil {
+		pricing := convertPayloadPricingToDTO(*req.Pricing)
+		dtoReq.Pricing = &pricing
+	}
+
+	if err := c.providerService.UpdateModel(r.Context(), dtoReq); err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (c *providerController) RemoveModel(w http.ResponseWriter, r *http.Request) {
 	providerID := chi.URLParam(r, "providerID")
 	modelID := chi.URLParam(r, "modelID")
@@ -202,13 +397,39 @@ func (c *providerController) AddEndpoints(w http.ResponseWriter, r *http.Request
 
 	err := c.providerService.AddEndpoints(r.Context(), dtoReq)
 	if err != nil {
-		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		writeProviderError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (c *providerController) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	endpointURL := chi.URLParam(r, "endpointURL")
+	var req payload.UpdateEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	err := c.providerService.UpdateEndpoint(r.Context(), dto.UpdateEndpointRequest{
+		ProviderID:  providerID,
+		EndpointURL: endpointURL,
+		Method:      req.Method,
+		Path:        req.Path,
+		Query:       req.Query,
+		Stream:      convertPayloadEndpointRouteToDTO(req.Stream),
+		Probe:       convertPayloadEndpointRouteToDTO(req.Probe),
+	})
+	if err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (c *providerController) RemoveEndpoint(w http.ResponseWriter, r *http.Request) {
 	providerID := chi.URLParam(r, "providerID")
 	endpointURL := chi.URLParam(r, "endpointURL")
@@ -285,6 +506,98 @@ func (c *providerController) DeactivateEndpoint(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusOK)
 }
 
+func (c *providerController) ListKeys(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	keys, err := c.providerService.ListKeys(r.Context(), providerID)
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, payload.ListKeysResponse{
+		KeyStrategy: keys.KeyStrategy,
+		Keys:        convertDTOKeysToPayload(keys.Keys),
+	})
+}
+
+func (c *providerController) AddKey(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	var req payload.AddKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	key, err := c.providerService.AddKey(r.Context(), dto.AddKeyRequest{
+		ProviderID: providerID,
+		Name:       req.Name,
+		Credential: req.Credential,
+		Weight:     req.Weight,
+	})
+	if err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	hutil.WriteJSONResponseWithStatus(w, r, http.StatusCreated, convertDTOKeyToPayload(key.ProviderKey))
+}
+
+func (c *providerController) UpdateKey(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	keyID := chi.URLParam(r, "keyID")
+	var req payload.UpdateKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	err := c.providerService.UpdateKey(r.Context(), dto.UpdateKeyRequest{
+		ProviderID: providerID,
+		KeyID:      keyID,
+		Weight:     req.Weight,
+	})
+	if err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *providerController) RetireKey(w http.ResponseWriter, r *http.Request) {
+	c.changeKey(w, r, c.providerService.RetireKey)
+}
+
+func (c *providerController) ReinstateKey(w http.ResponseWriter, r *http.Request) {
+	c.changeKey(w, r, c.providerService.ReinstateKey)
+}
+
+func (c *providerController) changeKey(w http.ResponseWriter, r *http.Request, change func(context.Context, dto.KeyRequest) error) {
+	err := change(r.Context(), dto.KeyRequest{
+		ProviderID: chi.URLParam(r, "providerID"),
+		KeyID:      chi.URLParam(r, "keyID"),
+	})
+	if err != nil {
+		writeProviderError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeProviderError reports template validation failures, unknown presets
+// and rejected updates as bad requests.
+func writeProviderError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrInvalidTemplate) ||
+		errors.Is(err, service.ErrUnknownPreset) ||
+		errors.Is(err, service.ErrInvalidRequest) ||
+		errors.Is(err, service.ErrModelNotFound) {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+	hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+}
+
 // Helper conversion functions
 
 func convertProviderDTOToPayload(provider dto.Provider) payload.ProviderResponse {
@@ -297,8 +610,62 @@ func convertProviderDTOToPayload(provider dto.Provider) payload.ProviderResponse
 		Endpoints:        convertDTOEndpointsToPayload(provider.Endpoints),
 		RequestTemplate:  provider.RequestTemplate,
 		ResponseTemplate: provider.ResponseTemplate,
+		TemplateVersion:  provider.TemplateVersion,
+		StagedTemplate:   convertDTOStagedTemplateToPayload(provider.StagedTemplate),
 		Headers:          provider.Headers,
+		Auth:             convertDTOAuthToPayload(provider.AuthConfig),
+		HealthProbe: payload.HealthProbe{
+			ModelKey: provider.HealthProbe.ModelKey,
+			Prompt:   provider.HealthProbe.Prompt,
+		},
+		Circuit:     convertDTOCircuitToPayload(provider.Circuit),
+		KeyStrategy: provider.KeyStrategy,
+		Keys:        convertDTOKeysToPayload(provider.Keys),
+	}
+}
+
+func convertDTOKeysToPayload(keys []dto.ProviderKey) []payload.ProviderKey {
+	payloadKeys := make([]payload.ProviderKey, len(keys))
+	for i, key := range keys {
+		payloadKeys[i] = convertDTOKeyToPayload(key)
 	}
+	return payloadKeys
+}
+
+// convertDTOKeyToPayload leaves out the rate limit times this instance has
+// not seen.
+func convertDTOKeyToPayload(key dto.ProviderKey) payload.ProviderKey {
+	k := payload.ProviderKey{
+		ID:              key.ID,
+		Name:            key.Name,
+		Weight:          key.Weight,
+		Status:          key.Status,
+		MasterKeyID:     key.MasterKeyID,
+		CreatedAt:       key.CreatedAt,
+		StatusChangedAt: key.StatusChangedAt,
+		RateLimited:     key.RateLimited,
+	}
+	if !key.CoolingDownUntil.IsZero() {
+		k.CoolingDownUntil = &key.CoolingDownUntil
+	}
+	if !key.LastLimitedAt.IsZero() {
+		k.LastLimitedAt = &key.LastLimitedAt
+	}
+	if !key.LastUsedAt.IsZero() {
+		k.LastUsedAt = &key.LastUsedAt
+	}
+	return k
+}
+
+func convertDTOCircuitToPayload(circuit dto.CircuitState) payload.CircuitState {
+	state := payload.CircuitState{
+		State:               circuit.State,
+		ConsecutiveFailures: circuit.ConsecutiveFailures,
+	}
+	if !circuit.OpenedAt.IsZero() {
+		state.OpenedAt = &circuit.OpenedAt
+	}
+	return state
 }
 
 func convertDTOModelsToPayload(dtoModels map[string]dto.Model) map[string]payload.Model {
@@ -309,30 +676,106 @@ func convertDTOModelsToPayload(dtoModels map[string]dto.Model) map[string]payloa
 			Key:         model.Key,
 			Description: model.Description,
 			Capabilities: payload.Capabilities{
-				FunctionCalling: model.Capabilities.FunctionCalling,
-				Streaming:       model.Capabilities.Streaming,
+				FunctionCalling:  model.Capabilities.FunctionCalling,
+				Streaming:        model.Capabilities.Streaming,
+				StructuredOutput: model.Capabilities.StructuredOutput,
 			},
 			Limits: payload.Limits{
-				ContextWindow:   model.Limits.ContextWindow,
-				MaxOutputTokens: model.Limits.MaxOutputTokens,
-			},
-			Pricing: payload.Pricing{
-				PromptTokenPrice:     model.Pricing.PromptTokenPrice,
-				CompletionTokenPrice: model.Pricing.CompletionTokenPrice,
-				Currency:             model.Pricing.Currency,
-				Unit:                 model.Pricing.Unit,
+				ContextWindow:    model.Limits.ContextWindow,
+				MaxOutputTokens:  model.Limits.MaxOutputTokens,
+				MaxStopSequences: model.Limits.MaxStopSequences,
 			},
+			Pricing: convertDTOPricingToPayload(model.Pricing),
 		}
 	}
 	return payloadModels
 }
 
+func convertDTOPricingToPayload(pricing dto.Pricing) payload.Pricing {
+	rules := make([]payload.PriceRule, len(pricing.Rules))
+	for i, r := range pricing.Rules {
+		rules[i] = payload.PriceRule{
+			Dimension:         r.Dimension,
+			Price:             json.Number(r.Price),
+			AbovePromptTokens: r.AbovePromptTokens,
+		}
+	}
+
+	return payload.Pricing{
+		PromptTokenPrice:     json.Number(pricing.PromptTokenPrice),
+		CompletionTokenPrice: json.Number(pricing.CompletionTokenPrice),
+		Currency:             pricing.Currency,
+		Unit:                 pricing.Unit,
+		Rules:                rules,
+	}
+}
+
+func convertPayloadPricingToDTO(pricing payload.Pricing) dto.Pricing {
+	rules := make([]dto.PriceRule, len(pricing.Rules))
+	for i, r := range pricing.Rules {
+		rules[i] = dto.PriceRule{
+			Dimension:         r.Dimension,
+			Price:             r.Price.String(),
+			AbovePromptTokens: r.AbovePromptTokens,
+		}
+	}
+
+	return dto.Pricing{
+		PromptTokenPrice:     pricing.PromptTokenPrice.String(),
+		CompletionTokenPrice: pricing.CompletionTokenPrice.String(),
+		Currency:             pricing.Currency,
+		Unit:                 pricing.Unit,
+		Rules:                rules,
+	}
+}
+
+func convertPayloadAuthToDTO(auth payload.AuthConfig) dto.AuthConfig {
+	cfg := dto.AuthConfig{
+		Type:       auth.Type,
+		Header:     auth.Header,
+		Prefix:     auth.Prefix,
+		QueryParam: auth.QueryParam,
+		Credential: auth.Credential,
+	}
+	if auth.OAuth2 != nil {
+		cfg.OAuth2 = dto.OAuth2Config{TokenURL: auth.OAuth2.TokenURL, ClientID: auth.OAuth2.ClientID, Scopes: auth.OAuth2.Scopes}
+	}
+	if auth.SigV4 != nil {
+		cfg.SigV4 = dto.SigV4Config{AccessKeyID: auth.SigV4.AccessKeyID, Region: auth.SigV4.Region, Service: auth.SigV4.Service}
+	}
+	return cfg
+}
+
+// convertDTOAuthToPayload includes the OAuth2 and SigV4 settings only for
+// the auth types that use them.
+func convertDTOAuthToPayload(auth dto.AuthConfig) payload.AuthConfig {
+	cfg := payload.AuthConfig{
+		Type:       auth.Type,
+		Header:     auth.Header,
+		Prefix:     auth.Prefix,
+		QueryParam: auth.QueryParam,
+		Credential: auth.Credential,
+		KeyID:      auth.KeyID,
+	}
+	if auth.OAuth2.TokenURL != "" {
+		cfg.OAuth2 = &payload.OAuth2Config{TokenURL: auth.OAuth2.TokenURL, ClientID: auth.OAuth2.ClientID, Scopes: auth.OAuth2.Scopes}
+	}
+	if auth.SigV4 != (dto.SigV4Config{}) {
+		cfg.SigV4 = &payload.SigV4Config{AccessKeyID: auth.SigV4.AccessKeyID, Region: auth.SigV4.Region, Service: auth.SigV4.Service}
+	}
+	return cfg
+}
+
 func convertDTOEndpointsToPayload(dtoEndpoints map[string]dto.Endpoint) map[string]payload.Endpoint {
 	payloadEndpoints := make(map[string]payload.Endpoint)
 	for key, endpoint := range dtoEndpoints {
 		payloadEndpoints[key] = payload.Endpoint{
 			Name:            endpoint.Name,
+			Method:          endpoint.Method,
 			Path:            endpoint.Path,
+			Query:           endpoint.Query,
+			Stream:          convertDTOEndpointRouteToPayload(endpoint.Stream),
+			Probe:           convertDTOEndpointRouteToPayload(endpoint.Probe),
 			Status:          endpoint.Status,
 			Health:          endpoint.Health,
 			LastHealthCheck: endpoint.LastHealthCheck,
@@ -349,19 +792,16 @@ func convertPayloadModelsToDTO(payloadModels []payload.Model) []dto.Model {
 			Key:         model.Key,
 			Description: model.Description,
 			Capabilities: dto.Capabilities{
-				FunctionCalling: model.Capabilities.FunctionCalling,
-				Streaming:       model.Capabilities.Streaming,
+				FunctionCalling:  model.Capabilities.FunctionCalling,
+				Streaming:        model.Capabilities.Streaming,
+				StructuredOutput: model.Capabilities.StructuredOutput,
 			},
 			Limits: dto.Limits{
-				ContextWindow:   model.Limits.ContextWindow,
-				MaxOutputTokens: model.Limits.MaxOutputTokens,
-			},
-			Pricing: dto.Pricing{
-				PromptTokenPrice:     model.Pricing.PromptTokenPrice,
-				CompletionTokenPrice: model.Pricing.CompletionTokenPrice,
-				Currency:             model.Pricing.Currency,
-				Unit:                 model.Pricing.Unit,
+				ContextWindow:    model.Limits.ContextWindow,
+				MaxOutputTokens:  model.Limits.MaxOutputTokens,
+				MaxStopSequences: model.Limits.MaxStopSequences,
 			},
+			Pricing: convertPayloadPricingToDTO(model.Pricing),
 		}
 	}
 	return dtoModels
@@ -372,7 +812,11 @@ func convertPayloadEndpointsToDTO(payloadEndpoints []payload.Endpoint) []dto.End
 	for i, endpoint := range payloadEndpoints {
 		dtoEndpoints[i] = dto.Endpoint{
 			Name:            endpoint.Name,
+			Method:          endpoint.Method,
 			Path:            endpoint.Path,
+			Query:           endpoint.Query,
+			Stream:          convertPayloadEndpointRouteToDTO(endpoint.Stream),
+			Probe:           convertPayloadEndpointRouteToDTO(endpoint.Probe),
 			Status:          endpoint.Status,
 			Health:          endpoint.Health,
 			LastHealthCheck: endpoint.LastHealthCheck,
@@ -380,3 +824,17 @@ func convertPayloadEndpointsToDTO(payloadEndpoints []payload.Endpoint) []dto.End
 	}
 	return dtoEndpoints
 }
+
+func convertDTOEndpointRouteToPayload(route *dto.EndpointRoute) *payload.EndpointRoute {
+	if route == nil {
+		return nil
+	}
+	return &payload.EndpointRoute{Method: route.Method, Path: route.Path, Query: route.Query}
+}
+
+func convertPayloadEndpointRouteToDTO(route *payload.EndpointRoute) *dto.EndpointRoute {
+	if route == nil {
+		return nil
+	}
+	return &dto.EndpointRoute{Method: route.Method, Path: route.Path, Query: route.Query}
+}
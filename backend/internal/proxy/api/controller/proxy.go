@@ -2,17 +2,22 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/basetable/basetable/backend/internal/proxy/api/payload"
 	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
 	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	authctx "github.com/basetable/basetable/backend/internal/shared/api/authcontext"
 	hutil "github.com/basetable/basetable/backend/internal/shared/api/httputil"
+	"github.com/basetable/basetable/backend/internal/shared/domain"
 )
 
 type ProxyController interface {
 	ProxyRequest(w http.ResponseWriter, r *http.Request)
+	Tokenize(w http.ResponseWriter, r *http.Request)
 }
 
 type proxyController struct {
@@ -33,15 +38,9 @@ func (c *proxyController) ProxyRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert payload to DTO
-	dtoReq := dto.Request{
-		ProviderID: req.ProviderID,
-		Endpoint:   req.Endpoint,
-		ModelKey:   req.ModelKey,
-		Messages:   convertMessages(req.Messages),
-		Stream:     req.Stream,
-		Tools:      convertTools(req.Tools),
-		ToolChoice: convertToolChoice(req.ToolChoice),
-	}
+	dtoReq := convertProxyRequest(req)
+	dtoReq.AccountID = authctx.GetAccountID(r.Context())
+	dtoReq.RequestID = requestID(w, r)
 
 	b, _ := json.Marshal(dtoReq)
 	fmt.Println(string(b))
@@ -50,7 +49,7 @@ func (c *proxyController) ProxyRequest(w http.ResponseWriter, r *http.Request) {
 		// Handle streaming response
 		responseChan, err := c.proxyService.ProxyRequestStream(r.Context(), dtoReq)
 		if err != nil {
-			hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+			writeProxyError(w, r, err)
 			fmt.Println(err)
 			return
 		}
@@ -70,19 +69,17 @@ func (c *proxyController) ProxyRequest(w http.ResponseWriter, r *http.Request) {
 
 		// Stream response chunks to client
 		for response := range responseChan {
-			// Convert response to payload format
-			payloadResp := payload.ProxyResponse{
-				Model:    response.Model,
-				Choices:  convertDTOChoicesToPayload(response.Choices),
-				Provider: response.Provider,
-				Usage: payload.Usage{
-					PromptTokens:     response.Usage.PromptTokens,
-					CompletionTokens: response.Usage.CompletionTokens,
-					TotalTokens:      response.Usage.TotalTokens,
-				},
-				SearchResults: convertDTOSearchResultsToPayload(response.SearchResults),
+			if response.Err != nil {
+				// Skip the [DONE] marker so the stream does not look complete.
+				errJSON, _ := json.Marshal(hutil.NewCustomError(response.Err, http.StatusBadGateway, response.Err.Error()).Payload())
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", errJSON)
+				flusher.Flush()
+				return
 			}
 
+			// Convert response to payload format
+			payloadResp := convertDTOResponseToPayload(response)
+
 			// Convert to JSON
 			responseJSON, err := json.Marshal(payloadResp)
 			if err != nil {
@@ -108,22 +105,12 @@ func (c *proxyController) ProxyRequest(w http.ResponseWriter, r *http.Request) {
 		// Handle regular response
 		response, err := c.proxyService.ProxyRequest(r.Context(), dtoReq)
 		if err != nil {
-			hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+			writeProxyError(w, r, err)
 			return
 		}
 
 		// Convert DTO to payload response
-		payloadResp := payload.ProxyResponse{
-			Model:    response.Model,
-			Choices:  convertDTOChoicesToPayload(response.Choices),
-			Provider: response.Provider,
-			Usage: payload.Usage{
-				PromptTokens:     response.Usage.PromptTokens,
-				CompletionTokens: response.Usage.CompletionTokens,
-				TotalTokens:      response.Usage.TotalTokens,
-			},
-			SearchResults: convertDTOSearchResultsToPayload(response.SearchResults),
-		}
+		payloadResp := convertDTOResponseToPayload(response)
 
 		// b, _ := json.Marshal(payloadResp)
 		// fmt.Println(string(b))
@@ -132,6 +119,138 @@ func (c *proxyController) ProxyRequest(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (c *proxyController) Tokenize(w http.ResponseWriter, r *http.Request) {
+	var req payload.ProxyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	response, err := c.proxyService.Tokenize(r.Context(), convertProxyRequest(req))
+	if err != nil {
+		if errors.Is(err, service.ErrModelNotFound) {
+			hutil.WriteJSONErrorResponse(w, r, hutil.NewCustomError(err, http.StatusNotFound, err.Error()))
+			return
+		}
+		writeProxyError(w, r, err)
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, payload.TokenizeResponse{
+		ProviderID:      response.ProviderID,
+		ModelKey:        response.ModelKey,
+		Tokenizer:       response.Tokenizer,
+		PromptTokens:    response.PromptTokens,
+		ContextWindow:   response.ContextWindow,
+		MaxOutputTokens: response.MaxOutputTokens,
+		Fits:            response.Fits,
+	})
+}
+
+// requestIDHeader carries the ID usage records are filed under, so callers
+// can quote it when disputing a charge.
+const requestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// requestID returns the caller's request ID, or a new one when it is missing
+// or too long, and echoes it in the response headers.
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get(requestIDHeader))
+	if id == "" || len(id) > maxRequestIDLength {
+		id = domain.GenerateID()
+	}
+
+	w.Header().Set(requestIDHeader, id)
+	return id
+}
+
+func writeProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrInvalidRequest) || errors.Is(err, service.ErrContextWindowExceeded) {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+	if errors.Is(err, service.ErrResponseFormatMismatch) {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewCustomError(err, http.StatusBadGateway, err.Error()))
+		return
+	}
+	hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+}
+
+func convertProxyRequest(req payload.ProxyRequest) dto.Request {
+	return dto.Request{
+		Model:      req.Model,
+		ProviderID: req.ProviderID,
+		Endpoint:   req.Endpoint,
+		ModelKey:   req.ModelKey,
+		Messages:   convertMessages(req.Messages),
+		Stream:     req.Stream,
+		Tools:      convertTools(req.Tools),
+		ToolChoice: convertToolChoice(req.ToolChoice),
+		Params:     convertGenerationParams(req.Params),
+
+		ResponseFormat:    convertResponseFormat(req.ResponseFormat),
+		ContextManagement: convertContextManagement(req.ContextManagement),
+	}
+}
+
+func convertContextManagement(cm *payload.ContextManagement) dto.ContextManagement {
+	if cm == nil {
+		return dto.ContextManagement{}
+	}
+
+	return dto.ContextManagement{
+		Strategy:     dto.ContextStrategy(cm.Strategy),
+		SummaryModel: cm.SummaryModel,
+	}
+}
+
+func convertResponseFormat(format *payload.ResponseFormat) dto.ResponseFormat {
+	if format == nil {
+		return dto.ResponseFormat{}
+	}
+
+	return dto.ResponseFormat{
+		Type:     dto.ResponseFormatType(format.Type),
+		Name:     format.Name,
+		Schema:   format.Schema,
+		Strict:   format.Strict,
+		Validate: format.Validate,
+	}
+}
+
+func convertGenerationParams(params *payload.GenerationParams) dto.GenerationParams {
+	if params == nil {
+		return dto.GenerationParams{}
+	}
+
+	return dto.GenerationParams{
+		Temperature:      params.Temperature,
+		TopP:             params.TopP,
+		MaxTokens:        params.MaxTokens,
+		Stop:             params.Stop,
+		Seed:             params.Seed,
+		PresencePenalty:  params.PresencePenalty,
+		FrequencyPenalty: params.FrequencyPenalty,
+	}
+}
+
+func convertDTOResponseToPayload(response *dto.Response) payload.ProxyResponse {
+	return payload.ProxyResponse{
+		Model:    response.Model,
+		Choices:  convertDTOChoicesToPayload(response.Choices),
+		Provider: response.Provider,
+		Usage: payload.Usage{
+			PromptTokens:       response.Usage.PromptTokens,
+			CompletionTokens:   response.Usage.CompletionTokens,
+			TotalTokens:        response.Usage.TotalTokens,
+			CachedPromptTokens: response.Usage.CachedPromptTokens,
+			ReasoningTokens:    response.Usage.ReasoningTokens,
+		},
+		SearchResults: convertDTOSearchResultsToPayload(response.SearchResults),
+	}
+}
+
 func convertMessages(payloadMessages []payload.Message) []dto.Message {
 	dtoMessages := make([]dto.Message, len(payloadMessages))
 	for i, msg := range payloadMessages {
@@ -168,10 +287,7 @@ func convertTools(payloadTools []payload.Tool) []dto.Tool {
 			ToolDefinition: dto.ToolDefinition{
 				Name:        tool.ToolDefinition.Name,
 				Description: tool.ToolDefinition.Description,
-				Parameters: dto.ParameterSchema{
-					Properties: convertParameterProperties(tool.ToolDefinition.Parameters.Properties),
-					Required:   tool.ToolDefinition.Parameters.Required,
-				},
+				Parameters:  dto.ParameterSchema{Schema: tool.ToolDefinition.Parameters},
 			},
 		}
 	}
@@ -192,19 +308,6 @@ func convertContent(content payload.Content) dto.Content {
 	return dtoContent
 }
 
-func convertParameterProperties(payloadProps map[string]payload.ParameterProperty) map[string]dto.ParameterProperty {
-	dtoProps := make(map[string]dto.ParameterProperty)
-	for key, prop := range payloadProps {
-		dtoProps[key] = dto.ParameterProperty{
-			Type:        prop.Type,
-			Description: prop.Description,
-			Enum:        prop.Enum,
-			Default:     prop.Default,
-		}
-	}
-	return dtoProps
-}
-
 func convertToolChoice(payloadToolChoice *payload.ToolChoice) dto.ToolChoice {
 	if payloadToolChoice == nil {
 		return dto.ToolChoice{Type: dto.ToolChoiceAUto}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/basetable/basetable/backend/internal/proxy/api/payload"
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	hutil "github.com/basetable/basetable/backend/internal/shared/api/httputil"
+)
+
+type RouteController interface {
+	CreateRoute(w http.ResponseWriter, r *http.Request)
+	GetRoute(w http.ResponseWriter, r *http.Request)
+	ListRoutes(w http.ResponseWriter, r *http.Request)
+	UpdateRouteTargets(w http.ResponseWriter, r *http.Request)
+	RemoveRoute(w http.ResponseWriter, r *http.Request)
+}
+
+type routeController struct {
+	routeService service.RouteService
+}
+
+func NewRouteController(routeService service.RouteService) RouteController {
+	return &routeController{routeService: routeService}
+}
+
+func (c *routeController) CreateRoute(w http.ResponseWriter, r *http.Request) {
+	var req payload.CreateRouteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	route, err := c.routeService.CreateRoute(r.Context(), dto.CreateRouteRequest{
+		Model:   req.Model,
+		Targets: convertPayloadTargetsToDTO(req.Targets),
+	})
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, convertRouteDTOToPayload(route.Route))
+}
+
+func (c *routeController) GetRoute(w http.ResponseWriter, r *http.Request) {
+	routeID := chi.URLParam(r, "routeID")
+	if routeID == "" {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(http.ErrMissingFile))
+		return
+	}
+
+	route, err := c.routeService.GetRoute(r.Context(), routeID)
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, convertRouteDTOToPayload(route.Route))
+}
+
+func (c *routeController) ListRoutes(w http.ResponseWriter, r *http.Request) {
+	routes, err := c.routeService.ListRoutes(r.Context())
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	response := payload.ListRoutesResponse{
+		Routes: make([]payload.RouteResponse, len(routes.Routes)),
+	}
+
+	for i, route := range routes.Routes {
+		response.Routes[i] = convertRouteDTOToPayload(route)
+	}
+
+	hutil.WriteJSONResponse(w, r, response)
+}
+
+func (c *routeController) UpdateRouteTargets(w http.ResponseWriter, r *http.Request) {
+	routeID := chi.URLParam(r, "routeID")
+	var req payload.UpdateRouteTargetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	err := c.routeService.UpdateRouteTargets(r.Context(), dto.UpdateRouteTargetsRequest{
+		RouteID: routeID,
+		Targets: convertPayloadTargetsToDTO(req.Targets),
+	})
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *routeController) RemoveRoute(w http.ResponseWriter, r *http.Request) {
+	routeID := chi.URLParam(r, "routeID")
+	if routeID == "" {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(http.ErrMissingFile))
+		return
+	}
+
+	if err := c.routeService.RemoveRoute(r.Context(), routeID); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper conversion functions
+
+func convertRouteDTOToPayload(route dto.Route) payload.RouteResponse {
+	targets := make([]payload.Target, len(route.Targets))
+	for i, t := range route.Targets {
+		targets[i] = payload.Target{
+			ProviderID: t.ProviderID,
+			ModelKey:   t.ModelKey,
+			Endpoint:   t.Endpoint,
+		}
+	}
+
+	return payload.RouteResponse{
+		ID:        route.ID,
+		Model:     route.Model,
+		Targets:   targets,
+		UpdatedAt: route.UpdatedAt,
+	}
+}
+
+func convertPayloadTargetsToDTO(payloadTargets []payload.Target) []dto.Target {
+	dtoTargets := make([]dto.Target, len(payloadTargets))
+	for i, t := range payloadTargets {
+		dtoTargets[i] = dto.Target{
+			ProviderID: t.ProviderID,
+			ModelKey:   t.ModelKey,
+			Endpoint:   t.Endpoint,
+		}
+	}
+	return dtoTargets
+}
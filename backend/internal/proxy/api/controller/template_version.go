@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+
+	"github.com/basetable/basetable/backend/internal/proxy/api/payload"
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	hutil "github.com/basetable/basetable/backend/internal/shared/api/httputil"
+)
+
+type TemplateVersionController interface {
+	ListTemplateVersions(w http.ResponseWriter, r *http.Request)
+	GetTemplateVersion(w http.ResponseWriter, r *http.Request)
+	// DiffTemplateVersions compares the from and to query parameters. to
+	// defaults to the serving version.
+	DiffTemplateVersions(w http.ResponseWriter, r *http.Request)
+	RollbackTemplate(w http.ResponseWriter, r *http.Request)
+	StageTemplate(w http.ResponseWriter, r *http.Request)
+	PromoteStagedTemplate(w http.ResponseWriter, r *http.Request)
+	DiscardStagedTemplate(w http.ResponseWriter, r *http.Request)
+}
+
+type templateVersionController struct {
+	templateVersionService service.TemplateVersionService
+}
+
+func NewTemplateVersionController(templateVersionService service.TemplateVersionService) TemplateVersionController {
+	return &templateVersionController{templateVersionService: templateVersionService}
+}
+
+func (c *templateVersionController) ListTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+
+	result, err := c.templateVersionService.ListTemplateVersions(r.Context(), providerID)
+	if err != nil {
+		writeTemplateVersionError(w, r, err)
+		return
+	}
+
+	response := payload.ListTemplateVersionsResponse{
+		Serving:  result.Serving,
+		Staged:   convertDTOStagedTemplateToPayload(result.Staged),
+		Versions: make([]payload.TemplateVersion, len(result.Versions)),
+	}
+	for i, v := range result.Versions {
+		response.Versions[i] = payload.TemplateVersion{
+			Version:   v.Number,
+			Author:    v.Author,
+			CreatedAt: v.CreatedAt,
+		}
+	}
+
+	hutil.WriteJSONResponse(w, r, response)
+}
+
+func (c *templateVersionController) GetTemplateVersion(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(fmt.Errorf("invalid version: %w", err)))
+		return
+	}
+
+	v, err := c.templateVersionService.GetTemplateVersion(r.Context(), dto.GetTemplateVersionRequest{
+		ProviderID: providerID,
+		Version:    version,
+	})
+	if err != nil {
+		writeTemplateVersionError(w, r, err)
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, payload.TemplateVersion{
+		Version:          v.Number,
+		RequestTemplate:  v.RequestTemplate,
+		ResponseTemplate: v.ResponseTemplate,
+		Author:           v.Author,
+		CreatedAt:        v.CreatedAt,
+	})
+}
+
+func (c *templateVersionController) DiffTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	from, err := intQuery(r, "from")
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	to, err := intQuery(r, "to")
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	diff, err := c.templateVersionService.DiffTemplateVersions(r.Context(), dto.DiffTemplateVersionsRequest{
+		ProviderID: providerID,
+		From:       from,
+		To:         to,
+	})
+	if err != nil {
+		writeTemplateVersionError(w, r, err)
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, payload.DiffTemplateVersionsResponse{
+		From:         diff.From,
+		To:           diff.To,
+		RequestDiff:  diff.RequestDiff,
+		ResponseDiff: diff.ResponseDiff,
+	})
+}
+
+func (c *templateVersionController) RollbackTemplate(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	var req payload.RollbackTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	err := c.templateVersionService.RollbackTemplate(r.Context(), dto.RollbackTemplateRequest{
+		ProviderID: providerID,
+		Version:    req.Version,
+	})
+	if err != nil {
+		writeTemplateVersionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *templateVersionController) StageTemplate(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	var req payload.StageTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+		return
+	}
+
+	result, err := c.templateVersionService.StageTemplate(r.Context(), dto.StageTemplateRequest{
+		ProviderID:       providerID,
+		Version:          req.Version,
+		RequestTemplate:  req.RequestTemplate,
+		ResponseTemplate: req.ResponseTemplate,
+		SampleResponse:   req.SampleResponse,
+		Percent:          req.Percent,
+		Author:           req.Author,
+	})
+	if err != nil {
+		writeTemplateVersionError(w, r, err)
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, payload.StageTemplateResponse{Version: result.Version})
+}
+
+func (c *templateVersionController) PromoteStagedTemplate(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	if err := c.templateVersionService.PromoteStagedTemplate(r.Context(), providerID); err != nil {
+		writeTemplateVersionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *templateVersionController) DiscardStagedTemplate(w http.ResponseWriter, r *http.Request) {
+	providerID := chi.URLParam(r, "providerID")
+	if err := c.templateVersionService.DiscardStagedTemplate(r.Context(), providerID); err != nil {
+		writeTemplateVersionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTemplateVersionError reports missing versions as not found and
+// otherwise follows writeProviderError.
+func writeTemplateVersionError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrTemplateVersionNotFound) {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewNotFoundError(err))
+		return
+	}
+	writeProviderError(w, r, err)
+}
+
+func intQuery(r *http.Request, key string) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func convertDTOStagedTemplateToPayload(staged *dto.StagedTemplate) *payload.StagedTemplate {
+	if staged == nil {
+		return nil
+	}
+	return &payload.StagedTemplate{Version: staged.Version, Percent: staged.Percent}
+}
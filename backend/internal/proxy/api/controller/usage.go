@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/api/payload"
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	authctx "github.com/basetable/basetable/backend/internal/shared/api/authcontext"
+	hutil "github.com/basetable/basetable/backend/internal/shared/api/httputil"
+)
+
+type UsageController interface {
+	// GetUsage returns the caller's own usage.
+	GetUsage(w http.ResponseWriter, r *http.Request)
+	// GetAllUsage returns usage across all accounts, or one account when
+	// account_id is given.
+	GetAllUsage(w http.ResponseWriter, r *http.Request)
+}
+
+type usageController struct {
+	usageService service.UsageService
+}
+
+func NewUsageController(usageService service.UsageService) UsageController {
+	return &usageController{usageService: usageService}
+}
+
+func (c *usageController) GetUsage(w http.ResponseWriter, r *http.Request) {
+	// Without an account the query would cover every account.
+	accountID := authctx.GetAccountID(r.Context())
+	if accountID == "" {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewUnauthorizedError(errors.New("account is required")))
+		return
+	}
+
+	c.writeUsage(w, r, accountID)
+}
+
+func (c *usageController) GetAllUsage(w http.ResponseWriter, r *http.Request) {
+	c.writeUsage(w, r, r.URL.Query().Get("account_id"))
+}
+
+func (c *usageController) writeUsage(w http.ResponseWriter, r *http.Request, accountID string) {
+	query := r.URL.Query()
+
+	from, err := parseUsageDate(query.Get("from"))
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(fmt.Errorf("invalid from: %w", err)))
+		return
+	}
+
+	to, err := parseUsageDate(query.Get("to"))
+	if err != nil {
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(fmt.Errorf("invalid to: %w", err)))
+		return
+	}
+
+	response, err := c.usageService.GetUsage(r.Context(), dto.GetUsageRequest{
+		AccountID: accountID,
+		From:      from,
+		To:        to,
+		Period:    query.Get("period"),
+		GroupBy:   query.Get("group_by"),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			hutil.WriteJSONErrorResponse(w, r, hutil.NewBadRequestError(err))
+			return
+		}
+		hutil.WriteJSONErrorResponse(w, r, hutil.NewInternalError(err))
+		return
+	}
+
+	hutil.WriteJSONResponse(w, r, convertUsageDTOToPayload(response))
+}
+
+// parseUsageDate accepts a date (2006-01-02) or an RFC 3339 timestamp. An
+// empty value is the zero time, leaving the default to the service.
+func parseUsageDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func convertUsageDTOToPayload(response *dto.GetUsageResponse) payload.UsageResponse {
+	buckets := make([]payload.UsageBucket, len(response.Buckets))
+	for i, b := range response.Buckets {
+		buckets[i] = payload.UsageBucket{
+			Start:       b.Start,
+			Key:         b.Key,
+			UsageTotals: convertUsageTotalsDTOToPayload(b.UsageTotals),
+		}
+	}
+
+	return payload.UsageResponse{
+		AccountID: response.AccountID,
+		From:      response.From,
+		To:        response.To,
+		Period:    response.Period,
+		GroupBy:   response.GroupBy,
+		Buckets:   buckets,
+		Totals:    convertUsageTotalsDTOToPayload(response.Totals),
+	}
+}
+
+func convertUsageTotalsDTOToPayload(totals dto.UsageTotals) payload.UsageTotals {
+	return payload.UsageTotals{
+		Requests:         totals.Requests,
+		FailedRequests:   totals.FailedRequests,
+		ErrorRate:        totals.ErrorRate,
+		PromptTokens:     totals.PromptTokens,
+		CompletionTokens: totals.CompletionTokens,
+		TotalTokens:      totals.TotalTokens,
+		Cost:             microcreditsToCredits(totals.Cost),
+	}
+}
+
+// microcreditsToCredits formats micro-credits as an exact number of credits.
+func microcreditsToCredits(microcredits int64) json.Number {
+	credits, frac := microcredits/1_000_000, microcredits%1_000_000
+	if frac == 0 {
+		return json.Number(strconv.FormatInt(credits, 10))
+	}
+	return json.Number(strings.TrimRight(fmt.Sprintf("%d.%06d", credits, frac), "0"))
+}
@@ -0,0 +1,153 @@
+package payload
+
+import "encoding/json"
+
+// The types in this file mirror the Anthropic Messages API wire format so
+// that Anthropic SDKs can talk to the proxy unchanged.
+
+// MessagesRequest represents an Anthropic messages request
+type MessagesRequest struct {
+	Model      string               `json:"model"`
+	MaxTokens  int                  `json:"max_tokens"`
+	System     json.RawMessage      `json:"system,omitempty"` // string or array of text blocks
+	Messages   []AnthropicMessage   `json:"messages"`
+	Stream     bool                 `json:"stream,omitempty"`
+	Tools      []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice *AnthropicToolChoice `json:"tool_choice,omitempty"`
+	Metadata   map[string]any       `json:"metadata,omitempty"`
+
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+}
+
+// AnthropicMessage represents a conversation turn. Content is either a
+// string or an array of AnthropicContentBlock.
+type AnthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// AnthropicContentBlock represents any request or response content block.
+// Which fields are set depends on Type.
+type AnthropicContentBlock struct {
+	Type string `json:"type"` // "text" | "image" | "document" | "tool_use" | "tool_result" | "thinking" | "redacted_thinking"
+
+	// text
+	Text *string `json:"text,omitempty"`
+
+	// image, document
+	Source *AnthropicSource `json:"source,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"` // string or array of blocks
+	IsError   bool            `json:"is_error,omitempty"`
+
+	// thinking
+	Thinking  *string `json:"thinking,omitempty"`
+	Signature string  `json:"signature,omitempty"`
+	Data      string  `json:"data,omitempty"` // redacted_thinking
+}
+
+type AnthropicSource struct {
+	Type      string `json:"type"` // "base64"
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// AnthropicTool represents a client tool definition
+type AnthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// AnthropicToolChoice represents tool choice configuration
+type AnthropicToolChoice struct {
+	Type string `json:"type"` // "auto" | "any" | "tool" | "none"
+	Name string `json:"name,omitempty"`
+}
+
+// MessagesResponse represents a non-streaming Anthropic message
+type MessagesResponse struct {
+	ID           string                  `json:"id"`
+	Type         string                  `json:"type"`
+	Role         string                  `json:"role"`
+	Model        string                  `json:"model"`
+	Content      []AnthropicContentBlock `json:"content"`
+	StopReason   *string                 `json:"stop_reason"`
+	StopSequence *string                 `json:"stop_sequence"`
+	Usage        AnthropicUsage          `json:"usage"`
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Streaming events
+
+type MessageStartEvent struct {
+	Type    string           `json:"type"`
+	Message MessagesResponse `json:"message"`
+}
+
+type ContentBlockStartEvent struct {
+	Type         string                `json:"type"`
+	Index        int                   `json:"index"`
+	ContentBlock AnthropicContentBlock `json:"content_block"`
+}
+
+type ContentBlockDeltaEvent struct {
+	Type  string                `json:"type"`
+	Index int                   `json:"index"`
+	Delta AnthropicContentDelta `json:"delta"`
+}
+
+type AnthropicContentDelta struct {
+	Type        string `json:"type"` // "text_delta" | "thinking_delta" | "input_json_delta"
+	Text        string `json:"text,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+}
+
+type ContentBlockStopEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+type MessageDeltaEvent struct {
+	Type  string              `json:"type"`
+	Delta AnthropicStopDelta  `json:"delta"`
+	Usage AnthropicDeltaUsage `json:"usage"`
+}
+
+type AnthropicStopDelta struct {
+	StopReason   *string `json:"stop_reason"`
+	StopSequence *string `json:"stop_sequence"`
+}
+
+type AnthropicDeltaUsage struct {
+	OutputTokens int `json:"output_tokens"`
+}
+
+type MessageStopEvent struct {
+	Type string `json:"type"`
+}
+
+// AnthropicErrorResponse represents an error in the Anthropic error envelope
+type AnthropicErrorResponse struct {
+	Type  string         `json:"type"`
+	Error AnthropicError `json:"error"`
+}
+
+type AnthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,10 @@
+package payload
+
+// ImportCatalogResponse lists provider names by what the import did to them
+type ImportCatalogResponse struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	Removed   []string `json:"removed"`
+	DryRun    bool     `json:"dry_run"`
+}
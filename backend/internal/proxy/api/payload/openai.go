@@ -0,0 +1,174 @@
+package payload
+
+import "encoding/json"
+
+// The types in this file mirror the OpenAI Chat Completions wire format so
+// that existing OpenAI SDKs can talk to the proxy unchanged.
+
+// ChatCompletionRequest represents an OpenAI chat completion request
+type ChatCompletionRequest struct {
+	Model         string             `json:"model"`
+	Messages      []ChatMessage      `json:"messages"`
+	Stream        bool               `json:"stream,omitempty"`
+	StreamOptions *ChatStreamOptions `json:"stream_options,omitempty"`
+	Tools         []ChatTool         `json:"tools,omitempty"`
+	ToolChoice    json.RawMessage    `json:"tool_choice,omitempty"` // "none" | "auto" | "required" | {"type": "function", ...}
+
+	Temperature         *float64        `json:"temperature,omitempty"`
+	TopP                *float64        `json:"top_p,omitempty"`
+	MaxTokens           *int            `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int            `json:"max_completion_tokens,omitempty"` // supersedes max_tokens
+	Stop                json.RawMessage `json:"stop,omitempty"`                  // string or array of strings
+	Seed                *int64          `json:"seed,omitempty"`
+	PresencePenalty     *float64        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty    *float64        `json:"frequency_penalty,omitempty"`
+
+	ResponseFormat *ChatResponseFormat `json:"response_format,omitempty"`
+}
+
+// ChatResponseFormat represents response_format: "text", "json_object" or
+// "json_schema"
+type ChatResponseFormat struct {
+	Type       string          `json:"type"`
+	JSONSchema *ChatJSONSchema `json:"json_schema,omitempty"`
+}
+
+type ChatJSONSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+type ChatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ChatMessage represents an OpenAI chat message. Content is either a string
+// or an array of ChatContentPart.
+type ChatMessage struct {
+	Role             string          `json:"role"`
+	Content          json.RawMessage `json:"content,omitempty"`
+	Name             string          `json:"name,omitempty"`
+	ToolCalls        []ChatToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID       string          `json:"tool_call_id,omitempty"`
+	ReasoningContent string          `json:"reasoning_content,omitempty"`
+}
+
+// ChatContentPart represents one element of an array-form message content
+type ChatContentPart struct {
+	Type     string        `json:"type"` // "text" | "image_url" | "file"
+	Text     string        `json:"text,omitempty"`
+	ImageURL *ChatImageURL `json:"image_url,omitempty"`
+	File     *ChatFile     `json:"file,omitempty"`
+}
+
+type ChatImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type ChatFile struct {
+	FileData string `json:"file_data,omitempty"`
+	Filename string `json:"filename,omitempty"`
+}
+
+// ChatTool represents an OpenAI tool definition
+type ChatTool struct {
+	Type     string           `json:"type"`
+	Function ChatToolFunction `json:"function"`
+}
+
+type ChatToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ChatToolChoice represents the object form of tool_choice
+type ChatToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// ChatToolCall represents a tool call in a message or a streamed delta
+type ChatToolCall struct {
+	Index    *int             `json:"index,omitempty"` // only set in streamed deltas
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ChatFunctionCall `json:"function"`
+}
+
+type ChatFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatCompletionResponse represents a non-streaming OpenAI chat completion
+type ChatCompletionResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   *ChatUsage   `json:"usage,omitempty"`
+}
+
+type ChatChoice struct {
+	Index        int                 `json:"index"`
+	Message      ChatResponseMessage `json:"message"`
+	FinishReason *string             `json:"finish_reason"`
+}
+
+// ChatResponseMessage is used for both full messages and streamed deltas
+type ChatResponseMessage struct {
+	Role             string         `json:"role,omitempty"`
+	Content          *string        `json:"content"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []ChatToolCall `json:"tool_calls,omitempty"`
+}
+
+// ChatCompletionChunk represents one streamed OpenAI chat completion chunk
+type ChatCompletionChunk struct {
+	ID      string            `json:"id"`
+	Object  string            `json:"object"`
+	Created int64             `json:"created"`
+	Model   string            `json:"model"`
+	Choices []ChatChunkChoice `json:"choices"`
+	Usage   *ChatUsage        `json:"usage,omitempty"`
+}
+
+type ChatChunkChoice struct {
+	Index        int                 `json:"index"`
+	Delta        ChatResponseMessage `json:"delta"`
+	FinishReason *string             `json:"finish_reason"`
+}
+
+type ChatUsage struct {
+	PromptTokens            int                          `json:"prompt_tokens"`
+	CompletionTokens        int                          `json:"completion_tokens"`
+	TotalTokens             int                          `json:"total_tokens"`
+	PromptTokensDetails     *ChatPromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *ChatCompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+type ChatPromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+type ChatCompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// ChatErrorResponse represents an error in the OpenAI error envelope
+type ChatErrorResponse struct {
+	Error ChatError `json:"error"`
+}
+
+type ChatError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Code    *string `json:"code"`
+}
@@ -1,28 +1,84 @@
 package payload
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // CreateProviderRequest represents the payload for creating a provider
 type CreateProviderRequest struct {
 	Name             string            `json:"name"`
+	Preset           string            `json:"preset,omitempty"`
 	BaseURL          string            `json:"base_url"`
 	RequestTemplate  string            `json:"request_template"`
 	ResponseTemplate string            `json:"response_template"`
 	Headers          map[string]string `json:"headers,omitempty"`
 	Auth             AuthConfig        `json:"auth"`
+	HealthProbe      *HealthProbe      `json:"health_probe,omitempty"`
+	SampleResponse   json.RawMessage   `json:"sample_response,omitempty"`
+	Author           string            `json:"author,omitempty"`
 }
 
 type UpdateProviderTemplateRequest struct {
-	RequestTemplate  string `json:"request_template"`
-	ResponseTemplate string `json:"response_template"`
+	RequestTemplate  string          `json:"request_template"`
+	ResponseTemplate string          `json:"response_template"`
+	SampleResponse   json.RawMessage `json:"sample_response,omitempty"`
+	Author           string          `json:"author,omitempty"`
 }
 
-// AuthConfig represents authentication configuration
+// PreviewTemplateRequest represents the payload for previewing a provider's
+// templates. Draft templates, when given, are used instead of the saved ones.
+type PreviewTemplateRequest struct {
+	RequestTemplate  string          `json:"request_template,omitempty"`
+	ResponseTemplate string          `json:"response_template,omitempty"`
+	Request          *ProxyRequest   `json:"request,omitempty"`
+	ProviderResponse json.RawMessage `json:"provider_response,omitempty"`
+}
+
+// PreviewTemplateResponse represents the rendered upstream request and mapped response
+type PreviewTemplateResponse struct {
+	Method        string            `json:"method,omitempty"`
+	Target        string            `json:"target,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	RequestBody   string            `json:"request_body,omitempty"`
+	RequestError  string            `json:"request_error,omitempty"`
+	Response      *ProxyResponse    `json:"response,omitempty"`
+	ResponseError string            `json:"response_error,omitempty"`
+}
+
+// HealthProbe represents the request used to health check a provider's endpoints
+type HealthProbe struct {
+	ModelKey string `json:"model_key,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+}
+
+// AuthConfig represents authentication configuration. Type is bearer,
+// apikey, query, oauth2_client_credentials or aws_sigv4; the credential is
+// the API key, OAuth2 client secret or AWS secret access key.
 type AuthConfig struct {
-	Type       string `json:"type"`
-	Header     string `json:"header"`
-	Prefix     string `json:"prefix"`
-	Credential string `json:"credential"`
+	Type       string        `json:"type"`
+	Header     string        `json:"header"`
+	Prefix     string        `json:"prefix"`
+	QueryParam string        `json:"query_param,omitempty"`
+	OAuth2     *OAuth2Config `json:"oauth2,omitempty"`
+	SigV4      *SigV4Config  `json:"sigv4,omitempty"`
+	Credential string        `json:"credential"` // always redacted in responses
+	KeyID      string        `json:"key_id,omitempty"`
+}
+
+// OAuth2Config represents the client credentials grant a provider's tokens
+// are fetched with
+type OAuth2Config struct {
+	TokenURL string   `json:"token_url"`
+	ClientID string   `json:"client_id"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// SigV4Config represents the AWS identity and scope requests are signed for
+type SigV4Config struct {
+	AccessKeyID string `json:"access_key_id"`
+	Region      string `json:"region"`
+	Service     string `json:"service"`
 }
 
 // ProviderResponse represents a provider in API responses
@@ -35,7 +91,21 @@ type ProviderResponse struct {
 	Endpoints        map[string]Endpoint `json:"endpoints"`
 	RequestTemplate  string              `json:"request_template"`
 	ResponseTemplate string              `json:"response_template"`
+	TemplateVersion  int                 `json:"template_version"`
+	StagedTemplate   *StagedTemplate     `json:"staged_template,omitempty"`
 	Headers          map[string]string   `json:"headers,omitempty"`
+	Auth             AuthConfig          `json:"auth"`
+	HealthProbe      HealthProbe         `json:"health_probe"`
+	Circuit          CircuitState        `json:"circuit"`
+	KeyStrategy      string              `json:"key_strategy"`
+	Keys             []ProviderKey       `json:"keys"`
+}
+
+// CircuitState represents the circuit breaker guarding a provider's upstream
+type CircuitState struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
 }
 
 // Model represents a model configuration
@@ -50,31 +120,131 @@ type Model struct {
 
 // Capabilities represents model capabilities
 type Capabilities struct {
-	FunctionCalling bool `json:"function_calling"`
-	Streaming       bool `json:"streaming"`
+	FunctionCalling  bool `json:"function_calling"`
+	Streaming        bool `json:"streaming"`
+	StructuredOutput bool `json:"structured_output"`
 }
 
 // Limits represents model limits
 type Limits struct {
-	ContextWindow   int `json:"context_window"`
-	MaxOutputTokens int `json:"max_output_tokens"`
+	ContextWindow    int `json:"context_window"`
+	MaxOutputTokens  int `json:"max_output_tokens"`
+	MaxStopSequences int `json:"max_stop_sequences"`
 }
 
 // Pricing represents model pricing
+// Pricing holds prices as JSON numbers or numeric strings, which are kept
+// exact. The prompt and completion prices are shorthands for base rules.
 type Pricing struct {
-	PromptTokenPrice     float64 `json:"prompt_token_price"`
-	CompletionTokenPrice float64 `json:"completion_token_price"`
-	Currency             string  `json:"currency"`
-	Unit                 string  `json:"unit"`
+	PromptTokenPrice     json.Number `json:"prompt_token_price"`
+	CompletionTokenPrice json.Number `json:"completion_token_price"`
+	Currency             string      `json:"currency"`
+	Unit                 string      `json:"unit"`
+	Rules                []PriceRule `json:"rules,omitempty"`
 }
 
-// Endpoint represents an endpoint configuration
+// PriceRule prices one of prompt_tokens, cached_prompt_tokens,
+// completion_tokens, reasoning_tokens, images or requests. A rule with
+// above_prompt_tokens applies to requests with longer prompts.
+type PriceRule struct {
+	Dimension         string      `json:"dimension"`
+	Price             json.Number `json:"price"`
+	AbovePromptTokens int         `json:"above_prompt_tokens,omitempty"`
+}
+
+// Endpoint represents an endpoint configuration. Path and query values are
+// templates rendered against the request, e.g.
+// "v1beta/models/{{.ModelKey}}:generateContent".
 type Endpoint struct {
-	Name            string    `json:"name"`
-	Path            string    `json:"path"`
-	Status          string    `json:"status"`
-	Health          string    `json:"health"`
-	LastHealthCheck time.Time `json:"last_health_check"`
+	Name            string            `json:"name"`
+	Method          string            `json:"method,omitempty"`
+	Path            string            `json:"path"`
+	Query           map[string]string `json:"query,omitempty"`
+	Stream          *EndpointRoute    `json:"stream,omitempty"`
+	Probe           *EndpointRoute    `json:"probe,omitempty"`
+	Status          string            `json:"status"`
+	Health          string            `json:"health"`
+	LastHealthCheck time.Time         `json:"last_health_check"`
+}
+
+// EndpointRoute represents the route taken by an endpoint's streaming
+// requests or health probes
+type EndpointRoute struct {
+	Method string            `json:"method,omitempty"`
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query,omitempty"`
+}
+
+// UpdateProviderRequest represents a partial update of a provider's
+// connection and auth. Omitted fields are left unchanged.
+type UpdateProviderRequest struct {
+	BaseURL     *string           `json:"base_url,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Auth        *AuthConfig       `json:"auth,omitempty"`
+	KeyStrategy *string           `json:"key_strategy,omitempty"`
+}
+
+// UpdateModelRequest represents a partial update of a model. Omitted fields
+// are left unchanged.
+type UpdateModelRequest struct {
+	Name         *string       `json:"name,omitempty"`
+	Description  *string       `json:"description,omitempty"`
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+	Limits       *Limits       `json:"limits,omitempty"`
+	Pricing      *Pricing      `json:"pricing,omitempty"`
+}
+
+// UpdateEndpointRequest represents a partial update of an endpoint's route.
+// Omitted fields are left unchanged; a stream or probe route with an empty
+// path removes that route.
+type UpdateEndpointRequest struct {
+	Method *string           `json:"method,omitempty"`
+	Path   *string           `json:"path,omitempty"`
+	Query  map[string]string `json:"query,omitempty"`
+	Stream *EndpointRoute    `json:"stream,omitempty"`
+	Probe  *EndpointRoute    `json:"probe,omitempty"`
+}
+
+// ProviderKey represents a key in a provider's credential pool. Status is
+// active, quarantined or retired; the rate limit fields are what the
+// answering instance has seen.
+type ProviderKey struct {
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Weight           int        `json:"weight"`
+	Status           string     `json:"status"`
+	MasterKeyID      string     `json:"master_key_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	StatusChangedAt  time.Time  `json:"status_changed_at"`
+	CoolingDownUntil *time.Time `json:"cooling_down_until,omitempty"`
+	LastLimitedAt    *time.Time `json:"last_limited_at,omitempty"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	RateLimited      int        `json:"rate_limited"`
+}
+
+// ListKeysResponse represents a provider's credential pool. KeyStrategy is
+// weighted_round_robin or least_recently_limited.
+type ListKeysResponse struct {
+	KeyStrategy string        `json:"key_strategy"`
+	Keys        []ProviderKey `json:"keys"`
+}
+
+// AddKeyRequest represents the payload for adding a key to a provider's pool
+type AddKeyRequest struct {
+	Name       string `json:"name"`
+	Credential string `json:"credential"`
+	Weight     int    `json:"weight,omitempty"`
+}
+
+// UpdateKeyRequest represents the payload for reweighting a key
+type UpdateKeyRequest struct {
+	Weight int `json:"weight"`
+}
+
+// RotateCredentialKeysResponse reports the outcome of rewrapping provider credentials
+type RotateCredentialKeysResponse struct {
+	KeyID   string `json:"key_id"`
+	Rotated int    `json:"rotated"`
 }
 
 // ListProvidersResponse represents the response for listing providers
@@ -1,7 +1,10 @@
 package payload
 
+import "encoding/json"
+
 // ProxyRequest represents the JSON payload for proxy requests
 type ProxyRequest struct {
+	Model      string      `json:"model,omitempty"` // routed to a provider when provider_id is empty
 	ProviderID string      `json:"provider_id"`
 	Endpoint   string      `json:"endpoint"`
 	ModelKey   string      `json:"model_key"`
@@ -9,6 +12,40 @@ type ProxyRequest struct {
 	Stream     bool        `json:"stream"`
 	Tools      []Tool      `json:"tools,omitempty"`
 	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	Params            *GenerationParams  `json:"params,omitempty"`
+	ResponseFormat    *ResponseFormat    `json:"response_format,omitempty"`
+	ContextManagement *ContextManagement `json:"context_management,omitempty"`
+}
+
+// ContextManagement selects how conversations longer than the model's context
+// window are shortened: "none", "drop_oldest", "keep_tool_pairs" or
+// "summarize"
+type ContextManagement struct {
+	Strategy     string `json:"strategy"`
+	SummaryModel string `json:"summary_model,omitempty"` // summarize only
+}
+
+// ResponseFormat represents the requested reply format: "text", "json_object"
+// or "json_schema" with a full JSON Schema
+type ResponseFormat struct {
+	Type     string          `json:"type"`
+	Name     string          `json:"name,omitempty"`
+	Schema   json.RawMessage `json:"schema,omitempty"`
+	Strict   bool            `json:"strict,omitempty"`
+	Validate bool            `json:"validate,omitempty"` // check the reply against the format
+}
+
+// GenerationParams represents optional sampling controls. Omitted fields use
+// the provider's defaults.
+type GenerationParams struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	MaxTokens        *int     `json:"max_tokens,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
 }
 
 type Content []Part
@@ -42,25 +79,12 @@ type Tool struct {
 	ToolDefinition ToolDefinition `json:"tool_definition"`
 }
 
-// ToolDefinition represents the definition of a tool
+// ToolDefinition represents the definition of a tool. Parameters is a JSON
+// Schema document and is passed through unchanged.
 type ToolDefinition struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
-	Parameters  ParameterSchema `json:"parameters"`
-}
-
-// ParameterSchema represents the schema for tool parameters
-type ParameterSchema struct {
-	Properties map[string]ParameterProperty `json:"properties"`
-	Required   []string                     `json:"required"`
-}
-
-// ParameterProperty represents a parameter property
-type ParameterProperty struct {
-	Type        string      `json:"type"`
-	Description string      `json:"description"`
-	Enum        []string    `json:"enum,omitempty"`
-	Default     interface{} `json:"default,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
 }
 
 // ToolCall represents a tool call
@@ -104,9 +128,22 @@ type Choice struct {
 	FinishReason string  `json:"finish_reason"`
 }
 
+// TokenizeResponse represents the estimated prompt size of a proxy request
+type TokenizeResponse struct {
+	ProviderID      string `json:"provider_id"`
+	ModelKey        string `json:"model_key"`
+	Tokenizer       string `json:"tokenizer"`
+	PromptTokens    int    `json:"prompt_tokens"`
+	ContextWindow   int    `json:"context_window"`
+	MaxOutputTokens int    `json:"max_output_tokens"`
+	Fits            bool   `json:"fits"`
+}
+
 // Usage represents token usage information
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens       int `json:"prompt_tokens"`
+	CompletionTokens   int `json:"completion_tokens"`
+	TotalTokens        int `json:"total_tokens"`
+	CachedPromptTokens int `json:"cached_prompt_tokens,omitempty"`
+	ReasoningTokens    int `json:"reasoning_tokens,omitempty"`
 }
@@ -0,0 +1,34 @@
+package payload
+
+import "time"
+
+// CreateRouteRequest represents the payload for creating a model route
+type CreateRouteRequest struct {
+	Model   string   `json:"model"`
+	Targets []Target `json:"targets"`
+}
+
+// UpdateRouteTargetsRequest represents the payload for replacing a route's targets
+type UpdateRouteTargetsRequest struct {
+	Targets []Target `json:"targets"`
+}
+
+// Target represents one provider a route can send requests to
+type Target struct {
+	ProviderID string `json:"provider_id"`
+	ModelKey   string `json:"model_key"`
+	Endpoint   string `json:"endpoint,omitempty"`
+}
+
+// RouteResponse represents a model route in API responses
+type RouteResponse struct {
+	ID        string    `json:"id"`
+	Model     string    `json:"model"`
+	Targets   []Target  `json:"targets"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListRoutesResponse represents the response for listing model routes
+type ListRoutesResponse struct {
+	Routes []RouteResponse `json:"routes"`
+}
@@ -0,0 +1,58 @@
+package payload
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TemplateVersion represents one recorded version of a provider's templates
+type TemplateVersion struct {
+	Version          int       `json:"version"`
+	RequestTemplate  string    `json:"request_template,omitempty"`
+	ResponseTemplate string    `json:"response_template,omitempty"`
+	Author           string    `json:"author,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// StagedTemplate represents a template version receiving part of a provider's traffic
+type StagedTemplate struct {
+	Version int `json:"version"`
+	Percent int `json:"percent"`
+}
+
+// ListTemplateVersionsResponse lists a provider's template versions, newest first.
+// Template contents are left out; fetch a single version to see them.
+type ListTemplateVersionsResponse struct {
+	Serving  int               `json:"serving"`
+	Staged   *StagedTemplate   `json:"staged,omitempty"`
+	Versions []TemplateVersion `json:"versions"`
+}
+
+// DiffTemplateVersionsResponse holds a unified diff per template
+type DiffTemplateVersionsResponse struct {
+	From         int    `json:"from"`
+	To           int    `json:"to"`
+	RequestDiff  string `json:"request_diff"`
+	ResponseDiff string `json:"response_diff"`
+}
+
+// RollbackTemplateRequest represents the payload for rolling back to a template version
+type RollbackTemplateRequest struct {
+	Version int `json:"version"`
+}
+
+// StageTemplateRequest represents the payload for staging a template version. Either
+// an existing version or new templates are given.
+type StageTemplateRequest struct {
+	Version          int             `json:"version,omitempty"`
+	RequestTemplate  string          `json:"request_template,omitempty"`
+	ResponseTemplate string          `json:"response_template,omitempty"`
+	SampleResponse   json.RawMessage `json:"sample_response,omitempty"`
+	Percent          int             `json:"percent"`
+	Author           string          `json:"author,omitempty"`
+}
+
+// StageTemplateResponse reports the version that was staged
+type StageTemplateResponse struct {
+	Version int `json:"version"`
+}
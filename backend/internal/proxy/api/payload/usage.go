@@ -0,0 +1,36 @@
+package payload
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// UsageResponse represents aggregated usage in API responses
+type UsageResponse struct {
+	AccountID string        `json:"account_id,omitempty"`
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Period    string        `json:"period"`
+	GroupBy   string        `json:"group_by"`
+	Buckets   []UsageBucket `json:"buckets"`
+	Totals    UsageTotals   `json:"totals"`
+}
+
+// UsageBucket represents the usage of one group in one time bucket
+type UsageBucket struct {
+	Start time.Time `json:"start"`
+	Key   string    `json:"key"`
+	UsageTotals
+}
+
+// UsageTotals represents request, token and spend counts. Cost is in
+// credits, exact to the micro-credit
+type UsageTotals struct {
+	Requests         int64       `json:"requests"`
+	FailedRequests   int64       `json:"failed_requests"`
+	ErrorRate        float64     `json:"error_rate"`
+	PromptTokens     int64       `json:"prompt_tokens"`
+	CompletionTokens int64       `json:"completion_tokens"`
+	TotalTokens      int64       `json:"total_tokens"`
+	Cost             json.Number `json:"cost"`
+}
@@ -0,0 +1,52 @@
+package dto
+
+// Catalog describes providers completely, so the database can be reconciled
+// to it.
+type Catalog struct {
+	Providers []CatalogProvider
+}
+
+// CatalogProvider is one provider in a catalog. Providers are matched to
+// existing ones by name.
+type CatalogProvider struct {
+	Name             string
+	BaseURL          string
+	Status           string // "active" (default) or "inactive"
+	Auth             CatalogAuth
+	Headers          map[string]string
+	HealthProbe      HealthProbe
+	Endpoints        []Endpoint
+	Models           []Model
+	RequestTemplate  string
+	ResponseTemplate string
+}
+
+// CatalogAuth is how a provider authenticates. Credential is plaintext on
+// import and never exported; an empty credential keeps the existing one.
+type CatalogAuth struct {
+	Type       string
+	Header     string
+	Prefix     string
+	QueryParam string
+	OAuth2     OAuth2Config
+	SigV4      SigV4Config
+	Credential string
+}
+
+type ImportCatalogRequest struct {
+	Catalog
+	// Prune removes providers, models and endpoints the catalog does not
+	// list. Without it the import only creates and updates.
+	Prune bool
+	// DryRun reports what would change without saving anything.
+	DryRun bool
+}
+
+// ImportCatalogResponse lists provider names by what the import did to them.
+type ImportCatalogResponse struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+	Removed   []string
+	DryRun    bool
+}
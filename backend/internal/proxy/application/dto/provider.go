@@ -11,7 +11,16 @@ type Provider struct {
 	Endpoints        map[string]Endpoint
 	RequestTemplate  string
 	ResponseTemplate string
+	TemplateVersion  int
+	StagedTemplate   *StagedTemplate
 	Headers          map[string]string
+	HealthProbe      HealthProbe
+	Circuit          CircuitState
+	// Keys is the provider's credential pool, spread across by KeyStrategy.
+	// Requests use the auth credential while the pool has no active keys.
+	Keys        []ProviderKey
+	KeyStrategy string
+	UpdatedAt   time.Time
 
 	// internal field, do not expose
 	AuthConfig AuthConfig
@@ -27,20 +36,32 @@ type Model struct {
 }
 
 type Capabilities struct {
-	FunctionCalling bool
-	Streaming       bool
+	FunctionCalling  bool
+	Streaming        bool
+	StructuredOutput bool
 }
 
 type Limits struct {
-	ContextWindow   int
-	MaxOutputTokens int
+	ContextWindow    int
+	MaxOutputTokens  int
+	MaxStopSequences int
 }
 
+// Pricing is a model's price sheet, with prices as decimal strings.
+// PromptTokenPrice and CompletionTokenPrice are shorthands for the base
+// prompt and completion rules, used when Rules does not price them.
 type Pricing struct {
-	PromptTokenPrice     float64
-	CompletionTokenPrice float64
+	PromptTokenPrice     string
+	CompletionTokenPrice string
 	Currency             string
 	Unit                 string
+	Rules                []PriceRule
+}
+
+type PriceRule struct {
+	Dimension         string
+	Price             string
+	AbovePromptTokens int
 }
 
 type GetProviderResponse struct {
@@ -52,12 +73,21 @@ type ListProvidersResponse struct {
 }
 
 type CreateProviderRequest struct {
-	Name             string
+	Name string
+	// Preset names a built-in provider configuration that fills in the
+	// base URL, templates, headers, auth header and endpoints left unset.
+	Preset           string
 	BaseURL          string
 	RequestTemplate  string
 	ResponseTemplate string
 	Headers          map[string]string
 	Auth             AuthConfig
+	HealthProbe      HealthProbe
+	// SampleResponse optionally replaces the built-in provider responses the
+	// response template is validated against.
+	SampleResponse []byte
+	// Author is recorded on the first template version.
+	Author string
 }
 
 type CreateProviderResponse struct {
@@ -68,13 +98,171 @@ type UpdateProviderTemplateRequest struct {
 	ProviderID       string
 	RequestTemplate  string
 	ResponseTemplate string
+	SampleResponse   []byte
+	Author           string
+}
+
+// PreviewTemplateRequest renders a request and/or maps a raw provider
+// response through a provider's templates without calling upstream. Draft
+// templates, when set, are used instead of the saved ones.
+type PreviewTemplateRequest struct {
+	ProviderID       string
+	RequestTemplate  string
+	ResponseTemplate string
+	Request          *Request
+	ProviderResponse []byte
+}
+
+type PreviewTemplateResponse struct {
+	Method        string
+	Target        string
+	Headers       map[string]string
+	RequestBody   []byte
+	RequestError  string
+	Response      *Response
+	ResponseError string
+}
+
+// CircuitState is the state of the circuit breaker guarding a provider's
+// upstream.
+type CircuitState struct {
+	State               string
+	ConsecutiveFailures int
+	OpenedAt            time.Time
+}
+
+type UpdateHealthProbeRequest struct {
+	ProviderID string
+	HealthProbe
+}
+
+// HealthProbe is the request the health checker sends to a provider. An
+// empty ModelKey probes with the provider's first model by key.
+type HealthProbe struct {
+	ModelKey string
+	Prompt   string
 }
 
 type AuthConfig struct {
 	Type       string
 	Header     string
 	Prefix     string
+	QueryParam string
+	OAuth2     OAuth2Config
+	SigV4      SigV4Config
+	Credential string // plaintext on create, redacted on read
+
+	// sealed credential, only opened on the proxy path
+	EncryptedCredential string
+	DataKey             string
+	KeyID               string
+}
+
+type OAuth2Config struct {
+	TokenURL string
+	ClientID string
+	Scopes   []string
+}
+
+type SigV4Config struct {
+	AccessKeyID string
+	Region      string
+	Service     string
+}
+
+// UpdateProviderRequest changes how a provider is reached. Nil fields are
+// left unchanged, and a non-nil Headers replaces all headers.
+type UpdateProviderRequest struct {
+	ProviderID string
+	BaseURL    *string
+	Headers    map[string]string
+	// Auth replaces the auth settings. An empty Type keeps the current type
+	// and an empty Credential keeps the current credential.
+	Auth        *AuthConfig
+	KeyStrategy *string
+}
+
+// ProviderKey is one credential in a provider's pool, with the rate limits
+// this instance has seen it hit.
+type ProviderKey struct {
+	ID              string
+	Name            string
+	Weight          int
+	Status          string
+	CreatedAt       time.Time
+	StatusChangedAt time.Time
+	// CoolingDownUntil is set while the key rests after a rate limit.
+	CoolingDownUntil time.Time
+	LastLimitedAt    time.Time
+	LastUsedAt       time.Time
+	RateLimited      int
+
+	// sealed credential, only opened on the proxy path
+	EncryptedCredential string
+	DataKey             string
+	MasterKeyID         string
+}
+
+type ListKeysResponse struct {
+	KeyStrategy string
+	Keys        []ProviderKey
+}
+
+// AddKeyRequest adds a key to a provider's pool. The credential is
+// plaintext and is sealed before it is stored; a zero Weight is 1.
+type AddKeyRequest struct {
+	ProviderID string
+	Name       string
 	Credential string
+	Weight     int
+}
+
+type AddKeyResponse struct {
+	ProviderKey
+}
+
+// UpdateKeyRequest changes a key's weight.
+type UpdateKeyRequest struct {
+	ProviderID string
+	KeyID      string
+	Weight     int
+}
+
+// KeyRequest addresses one key of a provider's pool.
+type KeyRequest struct {
+	ProviderID string
+	KeyID      string
+}
+
+// UpdateModelRequest changes a model's settings. Nil fields are left
+// unchanged.
+type UpdateModelRequest struct {
+	ProviderID string
+	// Model is the model's ID or key.
+	Model        string
+	Name         *string
+	Description  *string
+	Capabilities *Capabilities
+	Limits       *Limits
+	Pricing      *Pricing
+}
+
+// UpdateEndpointRequest changes where an endpoint sends requests. Nil
+// fields are left unchanged; a Stream or Probe with an empty path removes
+// that route.
+type UpdateEndpointRequest struct {
+	ProviderID  string
+	EndpointURL string
+	Method      *string
+	Path        *string
+	Query       map[string]string
+	Stream      *EndpointRoute
+	Probe       *EndpointRoute
+}
+
+type RotateCredentialKeysResponse struct {
+	KeyID   string
+	Rotated int
 }
 
 type AddModelsRequest struct {
@@ -98,13 +286,27 @@ type RemoveEndpointRequest struct {
 }
 
 type Endpoint struct {
-	Name            string
-	Path            string
+	Name string
+	// Path and query values are templates rendered against the request.
+	Method string
+	Path   string
+	Query  map[string]string
+	// Stream, when set, is the route taken by streaming requests.
+	Stream *EndpointRoute
+	// Probe, when set, is the route the health checker requests instead of
+	// sending the probe prompt.
+	Probe           *EndpointRoute
 	Status          string
 	Health          string
 	LastHealthCheck time.Time
 }
 
+type EndpointRoute struct {
+	Method string
+	Path   string
+	Query  map[string]string
+}
+
 type ActivateEndpointRequest struct {
 	ProviderID  string
 	EndpointURL string
@@ -114,3 +316,16 @@ type DeactivateEndpointRequest struct {
 	ProviderID  string
 	EndpointURL string
 }
+
+// ResolveModelRequest looks up a model by a client-facing name, either
+// "<provider name>/<model key>" or a bare model key.
+type ResolveModelRequest struct {
+	Model string
+}
+
+type ResolveModelResponse struct {
+	ProviderID   string
+	ProviderName string
+	ModelKey     string
+	Endpoint     string
+}
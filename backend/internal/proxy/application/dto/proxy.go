@@ -1,5 +1,7 @@
 package dto
 
+import "encoding/json"
+
 type MessageRole string
 
 const (
@@ -55,7 +57,13 @@ type ToolDefinition struct {
 	Parameters  ParameterSchema
 }
 
+// ParameterSchema describes a tool's parameters. Schema carries the full JSON
+// Schema document; Properties and Required are its top-level object
+// properties, derived from it for templates that only need the flat form.
+// Marshalled to JSON it is the full schema, so {{json .Parameters}} emits it
+// unchanged.
 type ParameterSchema struct {
+	Schema     json.RawMessage
 	Properties map[string]ParameterProperty
 	Required   []string
 }
@@ -65,6 +73,38 @@ type ParameterProperty struct {
 	Description string
 	Enum        []string
 	Default     interface{}
+	// Schema is the property's full subschema.
+	Schema json.RawMessage
+}
+
+func (p ParameterSchema) MarshalJSON() ([]byte, error) {
+	if len(p.Schema) > 0 {
+		return p.Schema, nil
+	}
+
+	properties := p.Properties
+	if properties == nil {
+		properties = map[string]ParameterProperty{}
+	}
+
+	return json.Marshal(struct {
+		Type       string                       `json:"type"`
+		Properties map[string]ParameterProperty `json:"properties"`
+		Required   []string                     `json:"required,omitempty"`
+	}{"object", properties, p.Required})
+}
+
+func (p ParameterProperty) MarshalJSON() ([]byte, error) {
+	if len(p.Schema) > 0 {
+		return p.Schema, nil
+	}
+
+	return json.Marshal(struct {
+		Type        string      `json:"type,omitempty"`
+		Description string      `json:"description,omitempty"`
+		Enum        []string    `json:"enum,omitempty"`
+		Default     interface{} `json:"default,omitempty"`
+	}{p.Type, p.Description, p.Enum, p.Default})
 }
 
 type ToolCall struct {
@@ -109,6 +149,13 @@ func (t ToolChoiceType) IsValid() bool {
 }
 
 type Request struct {
+	// RequestID ties the usage records of every attempt at serving the
+	// request together. It is generated when empty.
+	RequestID string
+	AccountID string
+	// Model is a logical model name routed to one of several providers. It is
+	// only used when ProviderID is empty.
+	Model      string
 	ProviderID string
 	Endpoint   string
 	ModelKey   string
@@ -116,6 +163,96 @@ type Request struct {
 	Stream     bool
 	Tools      []Tool
 	ToolChoice ToolChoice
+	Params     GenerationParams
+	// ResponseFormat constrains the shape of the assistant's reply.
+	ResponseFormat ResponseFormat
+	// ContextManagement decides what happens to conversations that do not
+	// fit the target model's context window.
+	ContextManagement ContextManagement
+}
+
+type ContextStrategy string
+
+const (
+	// ContextStrategyNone rejects requests that do not fit (the default).
+	ContextStrategyNone ContextStrategy = "none"
+	// ContextStrategyDropOldest drops the oldest turns.
+	ContextStrategyDropOldest ContextStrategy = "drop_oldest"
+	// ContextStrategyKeepToolPairs drops the oldest messages one at a time,
+	// but never separates a tool call from its results.
+	ContextStrategyKeepToolPairs ContextStrategy = "keep_tool_pairs"
+	// ContextStrategySummarize replaces the oldest turns with a summary.
+	ContextStrategySummarize ContextStrategy = "summarize"
+)
+
+func (s ContextStrategy) String() string {
+	return string(s)
+}
+
+func (s ContextStrategy) IsValid() bool {
+	switch s {
+	case ContextStrategyNone, ContextStrategyDropOldest, ContextStrategyKeepToolPairs, ContextStrategySummarize:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// ContextManagement selects how an oversized conversation is shortened.
+// Leading system messages are always kept.
+type ContextManagement struct {
+	Strategy ContextStrategy
+	// SummaryModel is the logical model that writes summaries. Empty uses
+	// the server's configured summary model.
+	SummaryModel string
+}
+
+type ResponseFormatType string
+
+const (
+	ResponseFormatText       ResponseFormatType = "text"
+	ResponseFormatJSONObject ResponseFormatType = "json_object"
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema"
+)
+
+func (t ResponseFormatType) String() string {
+	return string(t)
+}
+
+func (t ResponseFormatType) IsValid() bool {
+	switch t {
+	case ResponseFormatText, ResponseFormatJSONObject, ResponseFormatJSONSchema:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// ResponseFormat asks for plain text (the default), any JSON object, or JSON
+// matching Schema. Templates map it to the provider's native mechanism.
+type ResponseFormat struct {
+	Type   ResponseFormatType
+	Name   string          // json_schema only
+	Schema json.RawMessage // json_schema only, a full JSON Schema document
+	Strict bool
+	// Validate makes the proxy check the returned content against the
+	// format. Only non-streaming requests can be validated.
+	Validate bool
+}
+
+// GenerationParams are optional sampling controls. Unset fields are left to
+// the provider's defaults, so templates should only render the ones that are
+// set, e.g. {{with .Params.Temperature}}"temperature": {{.}},{{end}}.
+type GenerationParams struct {
+	Temperature      *float64
+	TopP             *float64
+	MaxTokens        *int
+	Stop             []string
+	Seed             *int64
+	PresencePenalty  *float64
+	FrequencyPenalty *float64
 }
 
 type Response struct {
@@ -124,6 +261,10 @@ type Response struct {
 	Provider      string
 	Usage         Usage
 	SearchResults []SearchResult
+	// Err ends a stream that failed part way, so clients can tell a
+	// truncated answer from a complete one. It is only set on the last
+	// chunk, which carries nothing else.
+	Err error
 }
 
 type SearchResult struct {
@@ -154,6 +295,7 @@ type Part struct {
 	Type       PartType
 	Body       string
 	MediaType  string // for image. specify jpeg, png, gif, or webp
+	URL        string // for image passed by URL instead of inline in Body
 	ToolCallID string // for tool parts
 }
 
@@ -169,10 +311,14 @@ type Message struct {
 	ToolCalls []ToolCall
 }
 
+// Usage counts the tokens of a response. PromptTokens includes
+// CachedPromptTokens and CompletionTokens includes ReasoningTokens.
 type Usage struct {
-	PromptTokens     int
-	CompletionTokens int
-	TotalTokens      int
+	PromptTokens       int
+	CompletionTokens   int
+	TotalTokens        int
+	CachedPromptTokens int
+	ReasoningTokens    int
 }
 
 type FinishReason string
@@ -182,3 +328,16 @@ const (
 	FinishReasonLength    FinishReason = "length"
 	FinishReasonToolCalls FinishReason = "tool_calls"
 )
+
+// TokenizeResponse is the estimated prompt size of a request against the
+// first target that serves it.
+type TokenizeResponse struct {
+	ProviderID      string
+	ModelKey        string
+	Tokenizer       string
+	PromptTokens    int
+	ContextWindow   int // 0 when the model does not declare one
+	MaxOutputTokens int
+	// Fits reports whether the prompt leaves room for output in the window.
+	Fits bool
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+// Route maps a logical model name to the targets that can serve it, in
+// order of preference.
+type Route struct {
+	ID        string
+	Model     string
+	Targets   []Target
+	UpdatedAt time.Time
+}
+
+type Target struct {
+	ProviderID string
+	ModelKey   string
+	Endpoint   string
+}
+
+type GetRouteResponse struct {
+	Route
+}
+
+type ListRoutesResponse struct {
+	Routes []Route
+}
+
+type CreateRouteRequest struct {
+	Model   string
+	Targets []Target
+}
+
+type CreateRouteResponse struct {
+	Route
+}
+
+type UpdateRouteTargetsRequest struct {
+	RouteID string
+	Targets []Target
+}
@@ -0,0 +1,72 @@
+package dto
+
+import "time"
+
+type TemplateVersion struct {
+	Number           int
+	RequestTemplate  string
+	ResponseTemplate string
+	Author           string
+	CreatedAt        time.Time
+}
+
+// StagedTemplate is a template version receiving Percent of a provider's
+// traffic ahead of promotion.
+type StagedTemplate struct {
+	Version          int
+	RequestTemplate  string
+	ResponseTemplate string
+	Percent          int
+}
+
+type ListTemplateVersionsResponse struct {
+	// Versions are ordered newest first.
+	Versions []TemplateVersion
+	// Serving is the version serving traffic, or 0 for a provider whose
+	// templates predate versioning and have not changed since.
+	Serving int
+	Staged  *StagedTemplate
+}
+
+type GetTemplateVersionRequest struct {
+	ProviderID string
+	Version    int
+}
+
+// DiffTemplateVersionsRequest compares two versions. A zero To compares
+// against the serving version.
+type DiffTemplateVersionsRequest struct {
+	ProviderID string
+	From       int
+	To         int
+}
+
+// DiffTemplateVersionsResponse holds a unified diff per template, empty
+// when the template did not change.
+type DiffTemplateVersionsResponse struct {
+	From         int
+	To           int
+	RequestDiff  string
+	ResponseDiff string
+}
+
+type RollbackTemplateRequest struct {
+	ProviderID string
+	Version    int
+}
+
+// StageTemplateRequest stages an existing version, or records the given
+// templates as a new version and stages that.
+type StageTemplateRequest struct {
+	ProviderID       string
+	Version          int
+	RequestTemplate  string
+	ResponseTemplate string
+	SampleResponse   []byte
+	Percent          int
+	Author           string
+}
+
+type StageTemplateResponse struct {
+	Version int
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// GetUsageRequest selects aggregated usage. Zero values fall back to daily
+// buckets grouped by model over the last 30 days; an empty AccountID covers
+// every account.
+type GetUsageRequest struct {
+	AccountID string
+	From      time.Time
+	To        time.Time
+	Period    string
+	GroupBy   string
+}
+
+type GetUsageResponse struct {
+	AccountID string
+	From      time.Time
+	To        time.Time
+	Period    string
+	GroupBy   string
+	Buckets   []UsageBucket
+	Totals    UsageTotals
+}
+
+// UsageBucket is the usage of one group in one time bucket. Cost is in
+// micro-credits.
+type UsageBucket struct {
+	Start time.Time
+	Key   string
+	UsageTotals
+}
+
+type UsageTotals struct {
+	Requests         int64
+	FailedRequests   int64
+	ErrorRate        float64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+	Cost             int64
+}
@@ -0,0 +1,5 @@
+package repository
+
+import "errors"
+
+var ErrNotFound = errors.New("record not found")
@@ -8,6 +8,9 @@ import (
 
 type ProviderRepository interface {
 	Save(ctx context.Context, provider *provider.Provider) error
+	// SaveEndpointHealth stores only the health of the provider's endpoints,
+	// leaving the rest of the provider and its updated time as they are.
+	SaveEndpointHealth(ctx context.Context, provider *provider.Provider) error
 	GetByID(ctx context.Context, id string) (*provider.Provider, error)
 	GetByIDForUpdate(ctx context.Context, id string) (*provider.Provider, error)
 	GetAll(ctx context.Context) ([]*provider.Provider, error)
@@ -2,4 +2,7 @@ package repository
 
 type RepositoryProvider interface {
 	ProviderRepository() ProviderRepository
+	RouteRepository() RouteRepository
+	UsageRepository() UsageRepository
+	TemplateVersionRepository() TemplateVersionRepository
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/route"
+)
+
+type RouteRepository interface {
+	Save(ctx context.Context, route *route.Route) error
+	GetByID(ctx context.Context, id string) (*route.Route, error)
+	GetByIDForUpdate(ctx context.Context, id string) (*route.Route, error)
+	GetByModel(ctx context.Context, model string) (*route.Route, error)
+	GetAll(ctx context.Context) ([]*route.Route, error)
+	Delete(ctx context.Context, id string) error
+}
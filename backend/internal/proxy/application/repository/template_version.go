@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+type TemplateVersionRepository interface {
+	Save(ctx context.Context, version provider.TemplateVersion) error
+	// GetByNumber returns ErrNotFound when the provider has no such version.
+	GetByNumber(ctx context.Context, providerID string, number int) (provider.TemplateVersion, error)
+	// ListByProvider returns the provider's versions, newest first.
+	ListByProvider(ctx context.Context, providerID string) ([]provider.TemplateVersion, error)
+	// LatestNumber returns 0 when the provider has no versions.
+	LatestNumber(ctx context.Context, providerID string) (int, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/usage"
+)
+
+type UsageRepository interface {
+	Save(ctx context.Context, record *usage.Record) error
+	GetByRequestID(ctx context.Context, requestID string) ([]*usage.Record, error)
+	Aggregate(ctx context.Context, query usage.Query) ([]usage.Aggregate, error)
+}
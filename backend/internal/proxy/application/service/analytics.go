@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/usage"
+)
+
+// DefaultUsageRange is how far back usage queries look when no start is given.
+const DefaultUsageRange = 30 * 24 * time.Hour
+
+type UsageService interface {
+	// GetUsage aggregates usage from the daily rollups. Dates are truncated
+	// to UTC days, so buckets at the edges of the range may be partial.
+	GetUsage(ctx context.Context, request dto.GetUsageRequest) (*dto.GetUsageResponse, error)
+}
+
+var _ UsageService = (*usageService)(nil)
+
+type usageService struct {
+	usageRepository repository.UsageRepository
+}
+
+func NewUsageService(usageRepository repository.UsageRepository) UsageService {
+	return &usageService{usageRepository: usageRepository}
+}
+
+func (s *usageService) GetUsage(ctx context.Context, request dto.GetUsageRequest) (*dto.GetUsageResponse, error) {
+	query := usage.Query{
+		AccountID: request.AccountID,
+		From:      request.From,
+		To:        request.To,
+		Period:    usage.Period(request.Period),
+		GroupBy:   usage.GroupBy(request.GroupBy),
+	}
+
+	if query.Period == "" {
+		query.Period = usage.PeriodDay
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = usage.GroupByModel
+	}
+
+	// The end of the range is exclusive, so a day given as the end date
+	// is included in full.
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+	query.To = usage.PeriodDay.Start(query.To).AddDate(0, 0, 1)
+	if query.From.IsZero() {
+		query.From = query.To.Add(-DefaultUsageRange)
+	}
+	query.From = usage.PeriodDay.Start(query.From)
+
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	aggregates, err := s.usageRepository.Aggregate(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.GetUsageResponse{
+		AccountID: query.AccountID,
+		From:      query.From,
+		To:        query.To,
+		Period:    query.Period.String(),
+		GroupBy:   query.GroupBy.String(),
+		Buckets:   make([]dto.UsageBucket, len(aggregates)),
+	}
+
+	var total usage.Aggregate
+	for i, a := range aggregates {
+		response.Buckets[i] = dto.UsageBucket{
+			Start:       a.BucketStart,
+			Key:         a.Key,
+			UsageTotals: mapAggregateToDTO(a),
+		}
+
+		total.Requests += a.Requests
+		total.FailedRequests += a.FailedRequests
+		total.PromptTokens += a.PromptTokens
+		total.CompletionTokens += a.CompletionTokens
+		total.Cost += a.Cost
+	}
+	response.Totals = mapAggregateToDTO(total)
+
+	return response, nil
+}
+
+func mapAggregateToDTO(a usage.Aggregate) dto.UsageTotals {
+	return dto.UsageTotals{
+		Requests:         a.Requests,
+		FailedRequests:   a.FailedRequests,
+		ErrorRate:        a.ErrorRate(),
+		PromptTokens:     a.PromptTokens,
+		CompletionTokens: a.CompletionTokens,
+		TotalTokens:      a.TotalTokens(),
+		Cost:             a.Cost,
+	}
+}
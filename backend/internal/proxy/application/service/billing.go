@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider/model"
+)
+
+// BillingGateway is the proxy's view of the billing context. Amounts are in
+// micro-credits (1 credit = $0.01 = 1,000,000 micro-credits).
+type BillingGateway interface {
+	ReserveCredits(ctx context.Context, accountID string, amount int64) (reservationID string, err error)
+	CommitReservation(ctx context.Context, reservationID string, amount int64) error
+	ReleaseReservation(ctx context.Context, reservationID string) error
+}
+
+const (
+	microcreditsPerDollar = 100_000_000
+	charsPerToken         = 4
+)
+
+// costInMicrocredits prices usage exactly with the model pricing and rounds
+// it up to a whole micro-credit. Rounding to whole credits is left to the
+// billing context, under the account's rounding policy.
+func costInMicrocredits(pricing model.Pricing, usage model.Usage) int64 {
+	return pricing.Cost(usage).MulInt(microcreditsPerDollar).Ceil().Int64()
+}
+
+// estimateReservation returns the worst-case cost of a request: the
+// estimated prompt size, none of it cached, plus the model's full output
+// allowance, or the requested max_tokens when that is lower.
+func estimateReservation(m dto.Model, pricing model.Pricing, request dto.Request, promptTokens int) int64 {
+	maxOutputTokens := m.Limits.MaxOutputTokens
+	if request.Params.MaxTokens != nil && (maxOutputTokens <= 0 || *request.Params.MaxTokens < maxOutputTokens) {
+		maxOutputTokens = *request.Params.MaxTokens
+	}
+	return costInMicrocredits(pricing, model.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: maxOutputTokens,
+		Images:           countImages(request),
+	})
+}
+
+func countImages(request dto.Request) int {
+	n := 0
+	for _, msg := range request.Messages {
+		for _, part := range msg.Content {
+			if part.Type == dto.PartTypeImage {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func contentLength(content dto.Content) int {
+	n := 0
+	for _, part := range content {
+		n += len(part.Body)
+	}
+	return n
+}
+
+func tokensFromChars(chars int) int {
+	return (chars + charsPerToken - 1) / charsPerToken
+}
+
+// billedUsage is the usage a request is charged for. Providers that do not
+// report usage are billed from the estimated prompt size and the generated
+// output length.
+func billedUsage(usage dto.Usage, promptTokens, completionChars int) dto.Usage {
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		usage.PromptTokens = promptTokens
+		usage.CompletionTokens = tokensFromChars(completionChars)
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	return usage
+}
+
+// mergeUsage folds the usage reported by a stream chunk into what earlier
+// chunks reported. Counts are cumulative, so later non-zero counts win.
+func mergeUsage(reported, chunk dto.Usage) dto.Usage {
+	if chunk.PromptTokens > 0 {
+		reported.PromptTokens = chunk.PromptTokens
+	}
+	if chunk.CompletionTokens > 0 {
+		reported.CompletionTokens = chunk.CompletionTokens
+	}
+	if chunk.TotalTokens > 0 {
+		reported.TotalTokens = chunk.TotalTokens
+	}
+	if chunk.CachedPromptTokens > 0 {
+		reported.CachedPromptTokens = chunk.CachedPromptTokens
+	}
+	if chunk.ReasoningTokens > 0 {
+		reported.ReasoningTokens = chunk.ReasoningTokens
+	}
+	if sum := reported.PromptTokens + reported.CompletionTokens; reported.TotalTokens < sum {
+		reported.TotalTokens = sum
+	}
+	return reported
+}
+
+// actualCost prices a finished request from its billed usage.
+func actualCost(pricing model.Pricing, request dto.Request, usage dto.Usage) int64 {
+	return costInMicrocredits(pricing, model.Usage{
+		PromptTokens:       usage.PromptTokens,
+		CachedPromptTokens: usage.CachedPromptTokens,
+		CompletionTokens:   usage.CompletionTokens,
+		ReasoningTokens:    usage.ReasoningTokens,
+		Images:             countImages(request),
+	})
+}
+
+// settleReservation commits the actual cost. If the account cannot cover an
+// overrun beyond the reserved amount, the reservation is committed as-is.
+func (s *proxyService) settleReservation(ctx context.Context, reservationID string, reserved, actual int64) error {
+	err := s.billingGateway.CommitReservation(ctx, reservationID, actual)
+	if err != nil && actual > reserved {
+		return s.billingGateway.CommitReservation(ctx, reservationID, reserved)
+	}
+	return err
+}
+
+func responseLength(response *dto.Response) int {
+	n := 0
+	for _, choice := range response.Choices {
+		n += contentLength(choice.Message.Content) + contentLength(choice.Delta.Content)
+		for _, tc := range choice.Message.ToolCalls {
+			n += len(tc.Call.Arg)
+		}
+		for _, tc := range choice.Delta.ToolCalls {
+			n += len(tc.Call.Arg)
+		}
+	}
+	return n
+}
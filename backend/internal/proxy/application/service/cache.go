@@ -0,0 +1,187 @@
+package service
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// ProviderCacheConfig bounds the in-process provider cache. Each instance
+// keeps its own cache and only sees its own invalidations, so the TTL caps
+// how long a change made through another instance goes unnoticed.
+type ProviderCacheConfig struct {
+	Size int
+	TTL  time.Duration
+}
+
+const (
+	DefaultProviderCacheSize = 256
+	DefaultProviderCacheTTL  = 30 * time.Second
+)
+
+// ProviderCache keeps hydrated providers and their compiled templates for the
+// proxy hot path, evicting the least recently used provider when full.
+type ProviderCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	// generation is bumped by every invalidation so a load that raced with
+	// one is not cached.
+	generation uint64
+	// all is every provider in the order models are resolved in, or nil
+	// until loaded. Any invalidation drops it.
+	all          []dto.Provider
+	allExpiresAt time.Time
+}
+
+type cacheEntry struct {
+	id        string
+	provider  dto.Provider
+	templates *providerTemplates
+	expiresAt time.Time
+}
+
+type providerLoader func(ctx context.Context, id string) (*dto.GetProviderResponse, error)
+
+type providersLoader func(ctx context.Context) (*dto.ListProvidersResponse, error)
+
+func NewProviderCache(cfg ProviderCacheConfig) *ProviderCache {
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultProviderCacheSize
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultProviderCacheTTL
+	}
+
+	return &ProviderCache{
+		size:    cfg.Size,
+		ttl:     cfg.TTL,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Invalidate drops the provider so the next request reloads it.
+func (c *ProviderCache) Invalidate(providerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.all = nil
+	if elem, ok := c.entries[providerID]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, providerID)
+	}
+}
+
+// provider returns the provider and its compiled templates, loading it when
+// missing or expired. Templates are only recompiled when the reloaded
+// provider's updatedAt differs from the cached one.
+func (c *ProviderCache) provider(ctx context.Context, id string, load providerLoader) (dto.Provider, *providerTemplates, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	var stale *cacheEntry
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if now.Before(entry.expiresAt) {
+			c.order.MoveToFront(elem)
+			c.mu.Unlock()
+			return entry.provider, entry.templates, nil
+		}
+		stale = entry
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	loaded, err := load(ctx, id)
+	if err != nil {
+		return dto.Provider{}, nil, err
+	}
+
+	var templates *providerTemplates
+	if stale != nil && stale.provider.UpdatedAt.Equal(loaded.UpdatedAt) {
+		templates = stale.templates
+	} else {
+		templates, err = compileTemplates(loaded.Provider)
+		if err != nil {
+			return dto.Provider{}, nil, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation == generation {
+		c.put(&cacheEntry{
+			id:        id,
+			provider:  loaded.Provider,
+			templates: templates,
+			expiresAt: now.Add(c.ttl),
+		})
+	}
+
+	return loaded.Provider, templates, nil
+}
+
+// providers returns every provider ordered by name, then ID, loading them
+// when missing or expired. Callers must not modify the returned slice.
+func (c *ProviderCache) providers(ctx context.Context, load providersLoader) ([]dto.Provider, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if c.all != nil && now.Before(c.allExpiresAt) {
+		all := c.all
+		c.mu.Unlock()
+		return all, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	loaded, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(make([]dto.Provider, 0, len(loaded.Providers)), loaded.Providers...)
+	slices.SortFunc(all, func(a, b dto.Provider) int {
+		return cmp.Or(
+			cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation == generation {
+		c.all = all
+		c.allExpiresAt = now.Add(c.ttl)
+	}
+
+	return all, nil
+}
+
+func (c *ProviderCache) put(entry *cacheEntry) {
+	if elem, ok := c.entries[entry.id]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.id] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+}
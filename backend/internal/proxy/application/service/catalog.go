@@ -0,0 +1,579 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider/model"
+)
+
+// ErrInvalidCatalog is returned for catalogs that cannot be imported.
+var ErrInvalidCatalog = errors.New("invalid catalog")
+
+// catalogAuthor is recorded on template versions written by an import.
+const catalogAuthor = "catalog"
+
+type CatalogService interface {
+	// ImportCatalog reconciles the providers in the database to the
+	// catalog in a single transaction. Importing the same catalog twice
+	// changes nothing the second time.
+	ImportCatalog(ctx context.Context, request dto.ImportCatalogRequest) (*dto.ImportCatalogResponse, error)
+	// ExportCatalog describes every provider, without credentials.
+	ExportCatalog(ctx context.Context) (*dto.Catalog, error)
+}
+
+var _ CatalogService = (*catalogService)(nil)
+
+type catalogService struct {
+	providerService    ProviderService
+	providerRepository ProviderRepository
+	uow                UnitOfWork
+	vault              CredentialVault
+	cache              *ProviderCache
+}
+
+func NewCatalogService(
+	providerService ProviderService,
+	providerRepository ProviderRepository,
+	uow UnitOfWork,
+	vault CredentialVault,
+	cache *ProviderCache,
+) CatalogService {
+	return &catalogService{
+		providerService:    providerService,
+		providerRepository: providerRepository,
+		uow:                uow,
+		vault:              vault,
+		cache:              cache,
+	}
+}
+
+func (s *catalogService) ImportCatalog(ctx context.Context, request dto.ImportCatalogRequest) (*dto.ImportCatalogResponse, error) {
+	if err := validateCatalog(request.Catalog); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.providerRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*provider.Provider, len(existing))
+	for _, p := range existing {
+		byName[p.Name()] = p
+	}
+
+	// Mutations are only visible once the transaction commits.
+	var touched []string
+	defer func() {
+		for _, id := range touched {
+			s.cache.Invalidate(id)
+		}
+	}()
+
+	response := &dto.ImportCatalogResponse{DryRun: request.DryRun}
+	err = s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		repo := repoProvider.ProviderRepository()
+		listed := make(map[string]bool, len(request.Providers))
+
+		for _, cp := range request.Providers {
+			listed[cp.Name] = true
+
+			current, ok := byName[cp.Name]
+			if !ok {
+				pvd, err := s.createProvider(cp)
+				if err != nil {
+					return fmt.Errorf("%w: provider %s: %w", ErrInvalidCatalog, cp.Name, err)
+				}
+
+				response.Created = append(response.Created, cp.Name)
+				if !request.DryRun {
+					if err := recordInitialTemplateVersion(ctx, repoProvider.TemplateVersionRepository(), pvd, catalogAuthor); err != nil {
+						return err
+					}
+					if err := repo.Save(ctx, pvd); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			pvd, err := repo.GetByIDForUpdate(ctx, current.ID().String())
+			if err != nil {
+				return err
+			}
+
+			changed, err := s.reconcileProvider(pvd, cp, request.Prune)
+			if err != nil {
+				return fmt.Errorf("%w: provider %s: %w", ErrInvalidCatalog, cp.Name, err)
+			}
+
+			if templatesDiffer(pvd, cp) {
+				changed = true
+				if !request.DryRun {
+					version, err := recordTemplateVersion(ctx, repoProvider.TemplateVersionRepository(), pvd,
+						provider.Template{Content: cp.RequestTemplate},
+						provider.Template{Content: cp.ResponseTemplate},
+						catalogAuthor)
+					if err != nil {
+						return err
+					}
+					if err := pvd.ApplyTemplateVersion(version); err != nil {
+						return err
+					}
+				}
+			}
+
+			if !changed {
+				response.Unchanged = append(response.Unchanged, cp.Name)
+				continue
+			}
+
+			response.Updated = append(response.Updated, cp.Name)
+			touched = append(touched, pvd.ID().String())
+			if !request.DryRun {
+				if err := repo.Save(ctx, pvd); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !request.Prune {
+			return nil
+		}
+
+		for _, p := range existing {
+			if listed[p.Name()] {
+				continue
+			}
+
+			response.Removed = append(response.Removed, p.Name())
+			touched = append(touched, p.ID().String())
+			if !request.DryRun {
+				if err := repo.Delete(ctx, p.ID().String()); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *catalogService) ExportCatalog(ctx context.Context) (*dto.Catalog, error) {
+	providers, err := s.providerService.ListProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &dto.Catalog{Providers: make([]dto.CatalogProvider, 0, len(providers.Providers))}
+	for _, p := range providers.Providers {
+		cp := dto.CatalogProvider{
+			Name:    p.Name,
+			BaseURL: p.BaseURL,
+			Status:  p.Status,
+			Auth: dto.CatalogAuth{
+				Type:       p.AuthConfig.Type,
+				Header:     p.AuthConfig.Header,
+				Prefix:     p.AuthConfig.Prefix,
+				QueryParam: p.AuthConfig.QueryParam,
+				OAuth2:     p.AuthConfig.OAuth2,
+				SigV4:      p.AuthConfig.SigV4,
+			},
+			Headers:          p.Headers,
+			HealthProbe:      p.HealthProbe,
+			RequestTemplate:  p.RequestTemplate,
+			ResponseTemplate: p.ResponseTemplate,
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(p.Models)) {
+			cp.Models = append(cp.Models, p.Models[key])
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(p.Endpoints)) {
+			ep := p.Endpoints[name]
+			cp.Endpoints = append(cp.Endpoints, dto.Endpoint{
+				Name:   ep.Name,
+				Method: ep.Method,
+				Path:   ep.Path,
+				Query:  ep.Query,
+				Stream: ep.Stream,
+				Probe:  ep.Probe,
+				Status: ep.Status,
+			})
+		}
+
+		catalog.Providers = append(catalog.Providers, cp)
+	}
+
+	slices.SortFunc(catalog.Providers, func(a, b dto.CatalogProvider) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return catalog, nil
+}
+
+func validateCatalog(catalog dto.Catalog) error {
+	names := make(map[string]bool, len(catalog.Providers))
+	for _, cp := range catalog.Providers {
+		if cp.Name == "" {
+			return fmt.Errorf("%w: every provider needs a name", ErrInvalidCatalog)
+		}
+		if names[cp.Name] {
+			return fmt.Errorf("%w: provider %s is listed twice", ErrInvalidCatalog, cp.Name)
+		}
+		names[cp.Name] = true
+
+		if err := validateCatalogProvider(cp); err != nil {
+			return fmt.Errorf("%w: provider %s: %w", ErrInvalidCatalog, cp.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateCatalogProvider(cp dto.CatalogProvider) error {
+	if cp.BaseURL == "" {
+		return errors.New("base_url is required")
+	}
+
+	switch provider.Status(cp.Status) {
+	case "", provider.StatusActive, provider.StatusInactive:
+	default:
+		return fmt.Errorf("unknown status %q", cp.Status)
+	}
+
+	if err := validateTemplates(cp.RequestTemplate, cp.ResponseTemplate, nil); err != nil {
+		return err
+	}
+
+	keys := make(map[string]bool, len(cp.Models))
+	for _, m := range cp.Models {
+		if m.Key == "" {
+			return errors.New("every model needs a key")
+		}
+		if keys[m.Key] {
+			return fmt.Errorf("model %s is listed twice", m.Key)
+		}
+		keys[m.Key] = true
+	}
+
+	if key := cp.HealthProbe.ModelKey; key != "" && !keys[key] {
+		return fmt.Errorf("health probe model %s is not listed", key)
+	}
+
+	endpoints := make(map[string]bool, len(cp.Endpoints))
+	for _, ep := range cp.Endpoints {
+		if ep.Name == "" || ep.Path == "" {
+			return errors.New("every endpoint needs a name and a path")
+		}
+		if endpoints[ep.Name] {
+			return fmt.Errorf("endpoint %s is listed twice", ep.Name)
+		}
+		endpoints[ep.Name] = true
+
+		switch provider.EndpointStatus(ep.Status) {
+		case "", provider.EndpointStatusActive, provider.EndpointStatusInactive:
+		default:
+			return fmt.Errorf("endpoint %s has unknown status %q", ep.Name, ep.Status)
+		}
+	}
+
+	return nil
+}
+
+func templatesDiffer(pvd *provider.Provider, cp dto.CatalogProvider) bool {
+	return pvd.RequestTemplate().Content != cp.RequestTemplate || pvd.ResponseTemplate().Content != cp.ResponseTemplate
+}
+
+func (s *catalogService) createProvider(cp dto.CatalogProvider) (*provider.Provider, error) {
+	auth := authConfigFromDTO(catalogAuthToDTO(cp.Auth), provider.Credential{})
+	if err := auth.Type.ValidateSecret(cp.Auth.Credential); err != nil {
+		return nil, err
+	}
+
+	credential, err := s.vault.Seal(cp.Auth.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt credential: %w", err)
+	}
+	auth.Credential = credential
+
+	pvd, err := provider.New(provider.Config{
+		Name:         cp.Name,
+		BaseURL:      cp.BaseURL,
+		Auth:         auth,
+		Headers:      cp.Headers,
+		RequestTmpl:  provider.Template{Content: cp.RequestTemplate},
+		ResponseTmpl: provider.Template{Content: cp.ResponseTemplate},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.reconcileProvider(pvd, cp, false); err != nil {
+		return nil, err
+	}
+
+	return pvd, nil
+}
+
+// reconcileProvider brings the provider in line with the catalog entry and
+// reports whether anything changed. Templates are left to the caller, which
+// records a changed pair as a new template version.
+func (s *catalogService) reconcileProvider(pvd *provider.Provider, cp dto.CatalogProvider, prune bool) (bool, error) {
+	changed := false
+
+	if pvd.BaseURL() != cp.BaseURL || !maps.Equal(pvd.Headers(), cp.Headers) {
+		if err := pvd.UpdateConnection(cp.BaseURL, cp.Headers); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	authChanged, err := s.reconcileAuth(pvd, cp.Auth)
+	if err != nil {
+		return false, err
+	}
+	changed = changed || authChanged
+
+	modelsChanged, err := reconcileModels(pvd, cp.Models, prune)
+	if err != nil {
+		return false, err
+	}
+	changed = changed || modelsChanged
+
+	probe := provider.HealthProbe{ModelKey: cp.HealthProbe.ModelKey, Prompt: cp.HealthProbe.Prompt}
+	if pvd.HealthProbe() != probe {
+		if err := pvd.UpdateHealthProbe(probe); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	// Activating or deactivating a provider sets every endpoint's status,
+	// so it goes before the endpoints are reconciled.
+	status := provider.Status(cp.Status)
+	if status == "" {
+		status = provider.StatusActive
+	}
+	if pvd.Status() != status {
+		if status == provider.StatusActive {
+			err = pvd.Activate()
+		} else {
+			err = pvd.Deactivate()
+		}
+		if err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	endpointsChanged, err := reconcileEndpoints(pvd, cp.Endpoints, prune)
+	if err != nil {
+		return false, err
+	}
+
+	return changed || endpointsChanged, nil
+}
+
+// reconcileAuth updates how the provider authenticates. The credential is
+// only resealed when it differs from the stored one, so reimporting the same
+// catalog is a no-op.
+func (s *catalogService) reconcileAuth(pvd *provider.Provider, auth dto.CatalogAuth) (bool, error) {
+	current := pvd.Auth()
+	desired := authConfigFromDTO(catalogAuthToDTO(auth), current.Credential)
+
+	if auth.Credential != "" {
+		if plaintext, err := s.vault.Open(current.Credential); err != nil || plaintext != auth.Credential {
+			if err := desired.Type.ValidateSecret(auth.Credential); err != nil {
+				return false, err
+			}
+
+			desired.Credential, err = s.vault.Seal(auth.Credential)
+			if err != nil {
+				return false, fmt.Errorf("failed to encrypt credential: %w", err)
+			}
+		}
+	}
+
+	if desired.Equal(current) {
+		return false, nil
+	}
+
+	return true, pvd.UpdateAuth(desired)
+}
+
+func reconcileModels(pvd *provider.Provider, models []dto.Model, prune bool) (bool, error) {
+	changed := false
+	listed := make(map[string]bool, len(models))
+
+	for _, m := range models {
+		listed[m.Key] = true
+		capabilities, limits, pricing, err := modelSettingsFromDTO(m)
+		if err != nil {
+			return false, fmt.Errorf("model %s: %w", m.Key, err)
+		}
+
+		existing := findModel(pvd, m.Key)
+		if existing == nil {
+			if _, err := pvd.AddModel(m.Name, m.Key, m.Description, capabilities, limits, pricing); err != nil {
+				return false, err
+			}
+			changed = true
+			continue
+		}
+
+		if existing.Name() == m.Name && existing.Description() == m.Description &&
+			existing.Capabilities() == capabilities && existing.Limits() == limits && existing.Pricing().Equal(pricing) {
+			continue
+		}
+
+		if err := pvd.UpdateModel(m.Key, m.Name, m.Description, capabilities, limits, pricing); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	if prune {
+		for _, m := range slices.Clone(pvd.Models()) {
+			if listed[m.Key()] {
+				continue
+			}
+			if err := pvd.RemoveModel(m.ID()); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// reconcileEndpoints removes endpoints whose route changed before adding any,
+// so two endpoints can swap routes.
+func reconcileEndpoints(pvd *provider.Provider, endpoints []dto.Endpoint, prune bool) (bool, error) {
+	changed := false
+	listed := make(map[string]dto.Endpoint, len(endpoints))
+	for _, ep := range endpoints {
+		listed[ep.Name] = ep
+	}
+
+	for _, ep := range slices.Clone(pvd.Endpoints()) {
+		desired, ok := listed[ep.Name]
+		if (ok && !sameEndpointRoutes(ep, desired)) || (!ok && prune) {
+			if err := pvd.RemoveEndpoint(ep.Name); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+
+	for _, ep := range endpoints {
+		if !pvd.HasEndpoint(ep.Name) {
+			route, stream := endpointRoutesFromDTO(ep)
+			if err := validateEndpointTemplates(&route, stream); err != nil {
+				return false, fmt.Errorf("endpoint %s: %w", ep.Name, err)
+			}
+			if err := pvd.AddEndpoint(ep.Name, route, stream); err != nil {
+				return false, fmt.Errorf("endpoint %s: %w", ep.Name, err)
+			}
+			changed = true
+		}
+
+		// A changed probe leaves the endpoint and its health in place.
+		if probe := optionalRouteFromDTO(ep.Probe); !sameOptionalRoute(findEndpoint(pvd, ep.Name).Probe, probe) {
+			if err := validateEndpointTemplates(probe); err != nil {
+				return false, fmt.Errorf("endpoint %s: %w", ep.Name, err)
+			}
+			if err := pvd.UpdateEndpointProbe(ep.Name, probe); err != nil {
+				return false, fmt.Errorf("endpoint %s: %w", ep.Name, err)
+			}
+			changed = true
+		}
+
+		// Endpoints without a status follow the provider's.
+		status := provider.EndpointStatus(ep.Status)
+		if status == "" && pvd.IsActive() {
+			status = provider.EndpointStatusActive
+		} else if status == "" {
+			status = provider.EndpointStatusInactive
+		}
+		if findEndpoint(pvd, ep.Name).Status == status {
+			continue
+		}
+
+		var err error
+		if status.IsActive() {
+			err = pvd.ActivateEndpoint(ep.Name)
+		} else {
+			err = pvd.DeactivateEndpoint(ep.Name)
+		}
+		if err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+func catalogAuthToDTO(auth dto.CatalogAuth) dto.AuthConfig {
+	return dto.AuthConfig{
+		Type:       auth.Type,
+		Header:     auth.Header,
+		Prefix:     auth.Prefix,
+		QueryParam: auth.QueryParam,
+		OAuth2:     auth.OAuth2,
+		SigV4:      auth.SigV4,
+	}
+}
+
+func modelSettingsFromDTO(m dto.Model) (model.Capabilities, model.Limits, model.Pricing, error) {
+	pricing, err := pricingFromDTO(m.Pricing)
+	if err != nil {
+		return model.Capabilities{}, model.Limits{}, model.Pricing{}, err
+	}
+
+	return model.Capabilities{
+			FunctionCalling:  m.Capabilities.FunctionCalling,
+			Streaming:        m.Capabilities.Streaming,
+			StructuredOutput: m.Capabilities.StructuredOutput,
+		},
+		model.Limits{
+			ContextWindow:    m.Limits.ContextWindow,
+			MaxOutputTokens:  m.Limits.MaxOutputTokens,
+			MaxStopSequences: m.Limits.MaxStopSequences,
+		},
+		pricing,
+		nil
+}
+
+func findModel(pvd *provider.Provider, key string) *model.Model {
+	for _, m := range pvd.Models() {
+		if m.Key() == key {
+			return m
+		}
+	}
+	return nil
+}
+
+func findEndpoint(pvd *provider.Provider, name string) provider.Endpoint {
+	for _, ep := range pvd.Endpoints() {
+		if ep.Name == name {
+			return ep
+		}
+	}
+	return provider.Endpoint{}
+}
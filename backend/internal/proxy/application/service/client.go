@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -11,15 +15,73 @@ type ProxyRequest struct {
 	Method  string
 	Headers map[string]string
 	Body    []byte
+	// ReturnRateLimits asks for 429 responses to be returned at once rather
+	// than retried, for callers that move on to another key instead.
+	ReturnRateLimits bool
 }
 
 type ProxyResponse struct {
 	StatusCode int
 	Body       []byte
 	Latency    time.Duration
+	// RetryAfter is the wait the provider asked for with a Retry-After
+	// header, or zero.
+	RetryAfter time.Duration
 }
 
 type ProxyClient interface {
 	ProxyRequest(ctx context.Context, request ProxyRequest) (ProxyResponse, error)
 	ProxyRequestStream(ctx context.Context, request ProxyRequest) (io.ReadCloser, error)
 }
+
+// UpstreamError reports a failed call to a provider. StatusCode is zero when
+// the provider could not be reached at all.
+type UpstreamError struct {
+	StatusCode int
+	// RetryAfter is the wait the provider asked for, or zero.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *UpstreamError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("provider request failed: %v", e.Err)
+	}
+	return fmt.Sprintf("provider returned error status: %d", e.StatusCode)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether another provider may succeed where this one
+// failed: connection errors, rate limiting and server errors.
+func (e *UpstreamError) Retryable() bool {
+	if e.StatusCode == 0 {
+		return !errors.Is(e.Err, context.Canceled)
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// CircuitState describes the circuit breaker guarding one upstream target.
+type CircuitState struct {
+	State               string // "closed" | "open" | "half-open"
+	ConsecutiveFailures int
+	OpenedAt            time.Time
+}
+
+// CircuitInspector is implemented by proxy clients that guard upstream
+// targets with circuit breakers.
+type CircuitInspector interface {
+	CircuitState(target string) CircuitState
+}
+
+// CircuitKey returns the breaker key for a URL: its scheme and host, so that
+// every endpoint of a provider shares one breaker.
+func CircuitKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host
+}
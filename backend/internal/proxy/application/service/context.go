@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// ContextConfig configures server-side context management.
+type ContextConfig struct {
+	// SummaryModel is the logical model that summarises older turns when a
+	// request does not name one. It should be cheap; it is billed to the
+	// same account as the request being summarised.
+	SummaryModel string
+	// SummaryMaxTokens caps the length of a summary.
+	SummaryMaxTokens int
+}
+
+const DefaultSummaryMaxTokens = 512
+
+const summaryInstruction = "Summarise the conversation below for an assistant that will continue it. " +
+	"Keep facts, decisions, open questions and the results of tool calls. Reply with the summary only."
+
+// manageContext shortens a conversation that would not leave room for the
+// reply in the model's context window, using the request's strategy.
+func (s *proxyService) manageContext(ctx context.Context, request dto.Request, model dto.Model, tokenizer Tokenizer) (dto.Request, error) {
+	strategy := request.ContextManagement.Strategy
+	window := model.Limits.ContextWindow
+	if window <= 0 || strategy == "" || strategy == dto.ContextStrategyNone {
+		return request, nil
+	}
+
+	budget := window - outputReserve(request, model)
+	if countPromptTokens(tokenizer, request) <= budget {
+		return request, nil
+	}
+
+	switch strategy {
+	case dto.ContextStrategyDropOldest:
+		return truncate(request, splitTurns, budget, tokenizer), nil
+
+	case dto.ContextStrategyKeepToolPairs:
+		return truncate(request, splitToolPairs, budget, tokenizer), nil
+
+	case dto.ContextStrategySummarize:
+		return s.summarize(ctx, request, budget, tokenizer)
+
+	default:
+		return request, nil
+	}
+}
+
+// outputReserve is the room left for the reply: the model's output limit
+// capped at a quarter of the window, or the requested max_tokens if smaller.
+// A larger max_tokens is not reserved in full, since fitContextWindow trims
+// it to whatever room the prompt leaves.
+func outputReserve(request dto.Request, model dto.Model) int {
+	reserve := model.Limits.ContextWindow / 4
+	if limit := model.Limits.MaxOutputTokens; limit > 0 && limit < reserve {
+		reserve = limit
+	}
+	if maxTokens := request.Params.MaxTokens; maxTokens != nil && *maxTokens < reserve {
+		reserve = *maxTokens
+	}
+	return reserve
+}
+
+// splitter groups the conversation after the leading system messages into
+// units that can only be dropped as a whole.
+type splitter func(messages []dto.Message) [][]dto.Message
+
+// splitTurns starts a unit at every user message that is not a tool result,
+// so a unit is a question with everything the assistant did to answer it.
+func splitTurns(messages []dto.Message) [][]dto.Message {
+	var units [][]dto.Message
+	for _, msg := range messages {
+		if len(units) == 0 || (msg.Role == dto.MessageRoleUser && !isToolResult(msg)) {
+			units = append(units, nil)
+		}
+		units[len(units)-1] = append(units[len(units)-1], msg)
+	}
+	return units
+}
+
+// splitToolPairs makes every message its own unit, except that tool results
+// stay with the message that made the calls.
+func splitToolPairs(messages []dto.Message) [][]dto.Message {
+	var units [][]dto.Message
+	for _, msg := range messages {
+		if len(units) == 0 || !isToolResult(msg) {
+			units = append(units, nil)
+		}
+		units[len(units)-1] = append(units[len(units)-1], msg)
+	}
+	return units
+}
+
+func isToolResult(msg dto.Message) bool {
+	if msg.Role == dto.MessageRoleTool {
+		return true
+	}
+	for _, part := range msg.Content {
+		if part.Type == dto.PartTypeTool {
+			return true
+		}
+	}
+	return false
+}
+
+// conversation is a request's messages split into the pinned system prefix
+// and droppable units.
+type conversation struct {
+	system []dto.Message
+	units  [][]dto.Message
+}
+
+func splitConversation(messages []dto.Message, split splitter) conversation {
+	n := 0
+	for n < len(messages) && messages[n].Role == dto.MessageRoleSystem {
+		n++
+	}
+	return conversation{system: messages[:n], units: split(messages[n:])}
+}
+
+// dropCount returns how many of the oldest units must go for the request to
+// fit the budget. The latest unit is always kept.
+func (c conversation) dropCount(request dto.Request, budget int, tokenizer Tokenizer) int {
+	request.Messages = c.system
+	total := countPromptTokens(tokenizer, request)
+	for _, unit := range c.units {
+		total += countMessageTokens(tokenizer, unit)
+	}
+
+	dropped := 0
+	for dropped < len(c.units)-1 && total > budget {
+		total -= countMessageTokens(tokenizer, c.units[dropped])
+		dropped++
+	}
+	return dropped
+}
+
+func (c conversation) messages(from int, extra ...dto.Message) []dto.Message {
+	messages := make([]dto.Message, 0, len(c.system)+len(extra)+len(c.units)-from)
+	messages = append(messages, c.system...)
+	messages = append(messages, extra...)
+	for _, unit := range c.units[from:] {
+		messages = append(messages, unit...)
+	}
+	return messages
+}
+
+func truncate(request dto.Request, split splitter, budget int, tokenizer Tokenizer) dto.Request {
+	conv := splitConversation(request.Messages, split)
+	request.Messages = conv.messages(conv.dropCount(request, budget, tokenizer))
+	return request
+}
+
+// summarize replaces the oldest turns with a summary written by the summary
+// model. If the summary cannot be produced the turns are dropped instead, so
+// the request still fits.
+func (s *proxyService) summarize(ctx context.Context, request dto.Request, budget int, tokenizer Tokenizer) (dto.Request, error) {
+	model := request.ContextManagement.SummaryModel
+	if model == "" {
+		model = s.contextConfig.SummaryModel
+	}
+	if model == "" {
+		return request, fmt.Errorf("%w: no summary model is configured", ErrInvalidRequest)
+	}
+
+	conv := splitConversation(request.Messages, splitTurns)
+	summaryBudget := budget - s.contextConfig.SummaryMaxTokens - messageOverheadTokens
+	dropped := conv.dropCount(request, summaryBudget, tokenizer)
+	if dropped == 0 {
+		return request, nil
+	}
+
+	var older []dto.Message
+	for _, unit := range conv.units[:dropped] {
+		older = append(older, unit...)
+	}
+
+	maxTokens := s.contextConfig.SummaryMaxTokens
+	response, err := s.ProxyRequest(ctx, dto.Request{
+		RequestID: request.RequestID,
+		AccountID: request.AccountID,
+		Model:     model,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleSystem, Content: dto.Content{{Type: dto.PartTypeText, Body: summaryInstruction}}},
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: transcript(older)}}},
+		},
+		Params: dto.GenerationParams{MaxTokens: &maxTokens},
+	})
+
+	var summary string
+	if err == nil && len(response.Choices) > 0 {
+		summary = strings.TrimSpace(textContent(response.Choices[0].Message.Content))
+	}
+	if summary == "" {
+		request.Messages = conv.messages(dropped)
+		return request, nil
+	}
+
+	request.Messages = conv.messages(dropped, dto.Message{
+		Role:    dto.MessageRoleSystem,
+		Content: dto.Content{{Type: dto.PartTypeText, Body: "Summary of the earlier conversation:\n" + summary}},
+	})
+	return request, nil
+}
+
+// transcript renders messages as plain text for the summary model.
+func transcript(messages []dto.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		for _, part := range msg.Content {
+			switch part.Type {
+			case dto.PartTypeText:
+				fmt.Fprintf(&b, "%s: %s\n", msg.Role, part.Body)
+			case dto.PartTypeTool:
+				fmt.Fprintf(&b, "tool result %s: %s\n", part.ToolCallID, part.Body)
+			case dto.PartTypeImage, dto.PartTypeFile:
+				fmt.Fprintf(&b, "%s: [%s attachment]\n", msg.Role, part.Type)
+			}
+		}
+
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(&b, "%s called %s(%s) as %s\n", msg.Role, tc.Call.Name, tc.Call.Arg, tc.ID)
+		}
+	}
+	return b.String()
+}
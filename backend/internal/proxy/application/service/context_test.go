@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// Every message below costs 14 tokens with runeTokenizer, except the tool
+// call, which costs 9. The whole conversation with priming is 110 tokens.
+var (
+	systemMsg  = dto.Message{Role: dto.MessageRoleSystem, Content: dto.Content{{Type: dto.PartTypeText, Body: "system----"}}}
+	user1      = dto.Message{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "user-1----"}}}
+	toolCall1  = dto.Message{Role: dto.MessageRoleAssistant, ToolCalls: []dto.ToolCall{{ID: "call-1", Call: dto.FunctionCall{Name: "get", Arg: "{}"}}}}
+	toolResult = dto.Message{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeTool, Body: "result-1--", ToolCallID: "call-1"}}}
+	reply1     = dto.Message{Role: dto.MessageRoleAssistant, Content: dto.Content{{Type: dto.PartTypeText, Body: "reply-1---"}}}
+	user2      = dto.Message{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "user-2----"}}}
+	reply2     = dto.Message{Role: dto.MessageRoleAssistant, Content: dto.Content{{Type: dto.PartTypeText, Body: "reply-2---"}}}
+	user3      = dto.Message{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "user-3----"}}}
+
+	conversationMsgs = []dto.Message{systemMsg, user1, toolCall1, toolResult, reply1, user2, reply2, user3}
+)
+
+// messageNames identifies messages by their text, or by their first tool call.
+func messageNames(messages []dto.Message) []string {
+	names := make([]string, len(messages))
+	for i, msg := range messages {
+		if len(msg.ToolCalls) > 0 {
+			names[i] = string(msg.ToolCalls[0].ID)
+		} else {
+			names[i] = msg.Content[0].Body
+		}
+	}
+	return names
+}
+
+func TestSplitters(t *testing.T) {
+	history := conversationMsgs[1:]
+
+	tests := []struct {
+		name  string
+		split splitter
+		want  [][]string
+	}{
+		{"Turns", splitTurns, [][]string{
+			{"user-1----", "call-1", "result-1--", "reply-1---"},
+			{"user-2----", "reply-2---"},
+			{"user-3----"},
+		}},
+		{"Tool pairs", splitToolPairs, [][]string{
+			{"user-1----"},
+			{"call-1", "result-1--"},
+			{"reply-1---"},
+			{"user-2----"},
+			{"reply-2---"},
+			{"user-3----"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units := tt.split(history)
+			if len(units) != len(tt.want) {
+				t.Fatalf("Expected %d units, got %d", len(tt.want), len(units))
+			}
+			for i, unit := range units {
+				if got := messageNames(unit); !slices.Equal(got, tt.want[i]) {
+					t.Errorf("Expected unit %d to be %v, got %v", i, tt.want[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name   string
+		split  splitter
+		budget int
+		want   []string
+	}{
+		{"Fits", splitTurns, 110, []string{"system----", "user-1----", "call-1", "result-1--", "reply-1---", "user-2----", "reply-2---", "user-3----"}},
+		{"Drops the oldest turn", splitTurns, 80, []string{"system----", "user-2----", "reply-2---", "user-3----"}},
+		{"Keeps the latest turn", splitTurns, 20, []string{"system----", "user-3----"}},
+		{"Drops single messages", splitToolPairs, 100, []string{"system----", "call-1", "result-1--", "reply-1---", "user-2----", "reply-2---", "user-3----"}},
+		// Dropping the call alone would fit, but would orphan its result.
+		{"Drops a tool call with its result", splitToolPairs, 90, []string{"system----", "reply-1---", "user-2----", "reply-2---", "user-3----"}},
+		{"Keeps the latest message", splitToolPairs, 0, []string{"system----", "user-3----"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := truncate(dto.Request{Messages: conversationMsgs}, tt.split, tt.budget, runeTokenizer{})
+			if got := messageNames(request.Messages); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOutputReserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    dto.Limits
+		maxTokens *int
+		want      int
+	}{
+		{"Quarter of the window", dto.Limits{ContextWindow: 1000}, nil, 250},
+		{"Model output limit", dto.Limits{ContextWindow: 1000, MaxOutputTokens: 100}, nil, 100},
+		{"Smaller max_tokens", dto.Limits{ContextWindow: 1000, MaxOutputTokens: 100}, ptr(50), 50},
+		{"max_tokens beyond the output limit", dto.Limits{ContextWindow: 1000, MaxOutputTokens: 100}, ptr(400), 100},
+		{"max_tokens beyond the window", dto.Limits{ContextWindow: 1000}, ptr(5000), 250},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := dto.Request{Params: dto.GenerationParams{MaxTokens: tt.maxTokens}}
+			if got := outputReserve(request, dto.Model{Limits: tt.limits}); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestManageContextLargeMaxTokens(t *testing.T) {
+	s := &proxyService{}
+	request := dto.Request{
+		Messages:          conversationMsgs,
+		Params:            dto.GenerationParams{MaxTokens: ptr(1000)},
+		ContextManagement: dto.ContextManagement{Strategy: dto.ContextStrategyDropOldest},
+	}
+
+	// A max_tokens as large as the window must not drop turns that fit.
+	got, err := s.manageContext(context.Background(), request, dto.Model{Limits: dto.Limits{ContextWindow: 200}}, runeTokenizer{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got.Messages) != len(conversationMsgs) {
+		t.Errorf("Expected all %d messages to be kept, got %v", len(conversationMsgs), messageNames(got.Messages))
+	}
+}
+
+// failingRoutes resolves no model, so any summary request fails.
+type failingRoutes struct{ RouteService }
+
+func (failingRoutes) ResolveTargets(context.Context, string) ([]dto.Target, error) {
+	return nil, errors.New("no route")
+}
+
+func TestSummarize(t *testing.T) {
+	request := dto.Request{
+		Messages:          conversationMsgs,
+		ContextManagement: dto.ContextManagement{Strategy: dto.ContextStrategySummarize},
+	}
+
+	t.Run("Falls back to dropping turns", func(t *testing.T) {
+		s := &proxyService{
+			routeService:    failingRoutes{},
+			contextConfig:   ContextConfig{SummaryModel: "cheap-model", SummaryMaxTokens: 10},
+			usageRepository: &savedUsage{},
+		}
+
+		// The budget leaves 80 tokens once the summary is reserved.
+		got, err := s.summarize(context.Background(), request, 94, runeTokenizer{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		want := []string{"system----", "user-2----", "reply-2---", "user-3----"}
+		if names := messageNames(got.Messages); !slices.Equal(names, want) {
+			t.Errorf("Expected %v, got %v", want, names)
+		}
+	})
+
+	t.Run("Requires a summary model", func(t *testing.T) {
+		s := &proxyService{contextConfig: ContextConfig{SummaryMaxTokens: 10}}
+
+		if _, err := s.summarize(context.Background(), request, 94, runeTokenizer{}); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Expected %v, got %v", ErrInvalidRequest, err)
+		}
+	})
+}
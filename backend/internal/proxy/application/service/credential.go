@@ -0,0 +1,35 @@
+package service
+
+import (
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+// CredentialVault seals provider credentials with envelope encryption. Only
+// the proxy path opens them; everything else sees them redacted.
+type CredentialVault interface {
+	Seal(plaintext string) (provider.Credential, error)
+	Open(credential provider.Credential) (string, error)
+	// Rewrap re-encrypts the credential's data key under the current master
+	// key. Unsealed legacy credentials are sealed.
+	Rewrap(credential provider.Credential) (provider.Credential, error)
+	CurrentKeyID() string
+}
+
+// RedactedCredential replaces credentials in every read API.
+const RedactedCredential = "********"
+
+func redactCredential(credential provider.Credential) string {
+	if credential.Encrypted == "" {
+		return ""
+	}
+	return RedactedCredential
+}
+
+func credentialFromDTO(auth dto.AuthConfig) provider.Credential {
+	return provider.Credential{
+		Encrypted: auth.EncryptedCredential,
+		DataKey:   auth.DataKey,
+		KeyID:     auth.KeyID,
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"text/template"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+// endpointRoutesFromDTO returns an endpoint's default route and its optional
+// streaming variant.
+func endpointRoutesFromDTO(ep dto.Endpoint) (provider.EndpointRoute, *provider.EndpointRoute) {
+	route := provider.EndpointRoute{Method: ep.Method, Path: ep.Path, Query: ep.Query}
+	return route, optionalRouteFromDTO(ep.Stream)
+}
+
+// optionalRouteFromDTO reads a streaming or probe route. One without a path
+// is none.
+func optionalRouteFromDTO(route *dto.EndpointRoute) *provider.EndpointRoute {
+	if route == nil || route.Path == "" {
+		return nil
+	}
+
+	return &provider.EndpointRoute{
+		Method: route.Method,
+		Path:   route.Path,
+		Query:  route.Query,
+	}
+}
+
+// sameOptionalRoute reports whether two optional routes are both absent or
+// send requests to the same place.
+func sameOptionalRoute(a, b *provider.EndpointRoute) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func endpointToDTO(ep provider.Endpoint) dto.Endpoint {
+	route := ep.Route(false)
+	endpoint := dto.Endpoint{
+		Name:            ep.Name,
+		Method:          route.Method,
+		Path:            route.Path,
+		Query:           route.Query,
+		Status:          string(ep.Status),
+		Health:          string(ep.Health),
+		LastHealthCheck: ep.LastHealthCheck,
+	}
+	if ep.Stream != nil {
+		stream := ep.Route(true)
+		endpoint.Stream = &dto.EndpointRoute{Method: stream.Method, Path: stream.Path, Query: stream.Query}
+	}
+	if ep.Probe != nil {
+		endpoint.Probe = &dto.EndpointRoute{Method: ep.Probe.Method, Path: ep.Probe.Path, Query: ep.Probe.Query}
+	}
+	return endpoint
+}
+
+// sameEndpointRoutes reports whether an endpoint already sends requests
+// where the desired one does.
+func sameEndpointRoutes(ep provider.Endpoint, desired dto.Endpoint) bool {
+	route, stream := endpointRoutesFromDTO(desired)
+	if !ep.Route(false).Equal(route) || (ep.Stream == nil) != (stream == nil) {
+		return false
+	}
+	return stream == nil || ep.Route(true).Equal(*stream)
+}
+
+// validateEndpointTemplates parses the path and query templates of an
+// endpoint's routes.
+func validateEndpointTemplates(routes ...*provider.EndpointRoute) error {
+	for _, route := range routes {
+		if route == nil {
+			continue
+		}
+
+		if _, err := parseEndpointTemplate("path", route.Path); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+		}
+		for key, value := range route.Query {
+			if _, err := parseEndpointTemplate("query "+key, value); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseEndpointTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+func renderEndpointTemplate(name, text string, request dto.Request) (string, error) {
+	tmpl, err := parseEndpointTemplate(name, text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse endpoint %s template: %w", name, err)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, request); err != nil {
+		return "", fmt.Errorf("failed to execute endpoint %s template: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// endpointRoute returns the route a request takes through an endpoint:
+// the streaming variant for streaming requests when there is one.
+func endpointRoute(ep dto.Endpoint, stream bool) dto.EndpointRoute {
+	route := dto.EndpointRoute{Method: ep.Method, Path: ep.Path, Query: ep.Query}
+	if stream && ep.Stream != nil {
+		route = *ep.Stream
+	}
+	if route.Method == "" {
+		route.Method = provider.DefaultEndpointMethod
+	}
+	return route
+}
+
+// upstreamTarget renders a route's path and query against the request and
+// joins them to the provider's base URL.
+func upstreamTarget(baseURL string, route dto.EndpointRoute, request dto.Request) (string, error) {
+	path, err := renderEndpointTemplate("path", route.Path, request)
+	if err != nil {
+		return "", err
+	}
+
+	target := fmt.Sprintf("%s/%s", baseURL, path)
+	if len(route.Query) == 0 {
+		return target, nil
+	}
+
+	query := make(url.Values, len(route.Query))
+	for key, value := range route.Query {
+		rendered, err := renderEndpointTemplate("query "+key, value, request)
+		if err != nil {
+			return "", err
+		}
+		query.Set(key, rendered)
+	}
+	return target + "?" + query.Encode(), nil
+}
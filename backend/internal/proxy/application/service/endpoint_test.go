@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+func TestBuildUpstreamRequestRoute(t *testing.T) {
+	gemini := dto.Endpoint{
+		Name:   "chat",
+		Path:   "v1beta/models/{{.ModelKey}}:generateContent",
+		Stream: &dto.EndpointRoute{Path: "v1beta/models/{{.ModelKey}}:streamGenerateContent", Query: map[string]string{"alt": "sse"}},
+	}
+	azure := dto.Endpoint{
+		Name:   "chat",
+		Method: "POST",
+		Path:   "openai/deployments/{{pathEscape .ModelKey}}/chat/completions",
+		Query:  map[string]string{"api-version": "2024-06-01"},
+	}
+	models := dto.Endpoint{Name: "chat", Method: "GET", Path: "v1/models/{{.ModelKey}}"}
+
+	tests := []struct {
+		name       string
+		endpoint   dto.Endpoint
+		request    dto.Request
+		wantMethod string
+		wantTarget string
+		wantBody   bool
+	}{
+		{"Legacy path", dto.Endpoint{Name: "chat", Path: "v1/chat/completions"}, dto.Request{ModelKey: "gpt-4o"}, "POST", "https://api.example.com/v1/chat/completions", true},
+		{"Model in path", gemini, dto.Request{ModelKey: "gemini-2.0-flash"}, "POST", "https://api.example.com/v1beta/models/gemini-2.0-flash:generateContent", true},
+		{"Stream variant", gemini, dto.Request{ModelKey: "gemini-2.0-flash", Stream: true}, "POST", "https://api.example.com/v1beta/models/gemini-2.0-flash:streamGenerateContent?alt=sse", true},
+		{"Query parameters", azure, dto.Request{ModelKey: "my deployment"}, "POST", "https://api.example.com/openai/deployments/my%20deployment/chat/completions?api-version=2024-06-01", true},
+		{"Stream without variant", azure, dto.Request{ModelKey: "gpt", Stream: true}, "POST", "https://api.example.com/openai/deployments/gpt/chat/completions?api-version=2024-06-01", true},
+		{"GET without body", models, dto.Request{ModelKey: "gpt-4o"}, "GET", "https://api.example.com/v1/models/gpt-4o", false},
+	}
+
+	tmpl := template.Must(template.New("request").Parse(`{"model": "{{.ModelKey}}"}`))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := dto.Provider{
+				BaseURL:   "https://api.example.com",
+				Endpoints: map[string]dto.Endpoint{"chat": tt.endpoint},
+			}
+			tt.request.Endpoint = "chat"
+
+			req, err := buildUpstreamRequest(provider, tmpl, tt.request)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if req.Method != tt.wantMethod {
+				t.Errorf("Expected method %s, got %s", tt.wantMethod, req.Method)
+			}
+			if req.Target != tt.wantTarget {
+				t.Errorf("Expected target %s, got %s", tt.wantTarget, req.Target)
+			}
+			if (len(req.Body) > 0) != tt.wantBody {
+				t.Errorf("Expected body %v, got %q", tt.wantBody, req.Body)
+			}
+			if _, ok := req.Headers["Content-Type"]; ok != tt.wantBody {
+				t.Errorf("Expected Content-Type header %v, got %v", tt.wantBody, req.Headers)
+			}
+		})
+	}
+}
+
+func TestValidateEndpointTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint dto.Endpoint
+		wantErr  bool
+	}{
+		{"Plain path", dto.Endpoint{Path: "v1/chat/completions"}, false},
+		{"Templated path and query", dto.Endpoint{Path: "models/{{.ModelKey}}", Query: map[string]string{"model": "{{.ModelKey}}"}}, false},
+		{"Broken path", dto.Endpoint{Path: "models/{{.ModelKey"}, true},
+		{"Broken query", dto.Endpoint{Path: "v1/chat", Query: map[string]string{"alt": "{{end}}"}}, true},
+		{"Broken stream path", dto.Endpoint{Path: "v1/chat", Stream: &dto.EndpointRoute{Path: "{{unknownFunc}}"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, stream := endpointRoutesFromDTO(tt.endpoint)
+			err := validateEndpointTemplates(&route, stream)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+	"github.com/basetable/basetable/backend/internal/shared/log"
+)
+
+// HealthChecker periodically probes every active endpoint of every active
+// provider and records the resulting health on the provider.
+type HealthChecker interface {
+	// Run probes on every interval until ctx is cancelled.
+	Run(ctx context.Context)
+	CheckAll(ctx context.Context) error
+}
+
+type HealthCheckerConfig struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	// Window is the number of most recent probes an endpoint is classified on.
+	Window int
+	Policy provider.HealthPolicy
+	Logger log.Logger
+}
+
+const (
+	DefaultHealthCheckInterval = time.Minute
+	DefaultHealthCheckTimeout  = 30 * time.Second
+	DefaultHealthCheckWindow   = 5
+)
+
+var _ HealthChecker = (*healthChecker)(nil)
+
+type healthChecker struct {
+	providerService ProviderService
+	proxyClient     ProxyClient
+	uow             UnitOfWork
+	vault           CredentialVault
+	signers         SignerFactory
+	cache           *ProviderCache
+	interval        time.Duration
+	timeout         time.Duration
+	window          int
+	policy          provider.HealthPolicy
+	logger          log.Logger
+
+	mu      sync.Mutex
+	samples map[string][]provider.HealthSample
+}
+
+func NewHealthChecker(
+	providerService ProviderService,
+	proxyClient ProxyClient,
+	uow UnitOfWork,
+	vault CredentialVault,
+	signers SignerFactory,
+	cache *ProviderCache,
+	cfg HealthCheckerConfig,
+) HealthChecker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultHealthCheckInterval
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultHealthCheckTimeout
+	}
+
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultHealthCheckWindow
+	}
+
+	if cfg.Policy == (provider.HealthPolicy{}) {
+		cfg.Policy = provider.DefaultHealthPolicy
+	}
+
+	return &healthChecker{
+		providerService: providerService,
+		proxyClient:     proxyClient,
+		uow:             uow,
+		vault:           vault,
+		signers:         signers,
+		cache:           cache,
+		interval:        cfg.Interval,
+		timeout:         cfg.Timeout,
+		window:          cfg.Window,
+		policy:          cfg.Policy,
+		logger:          cfg.Logger,
+		samples:         make(map[string][]provider.HealthSample),
+	}
+}
+
+func (h *healthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := h.CheckAll(ctx); err != nil && h.logger != nil {
+			h.logger.Errorf("Health check failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthChecker) CheckAll(ctx context.Context) error {
+	providers, err := h.providerService.ListProviders(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, pvd := range providers.Providers {
+		if pvd.Status != provider.StatusActive.String() {
+			continue
+		}
+
+		if err := h.checkProvider(ctx, pvd); err != nil && h.logger != nil {
+			h.logger.Errorf("Failed to record health for provider %s: %v", pvd.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (h *healthChecker) checkProvider(ctx context.Context, pvd dto.Provider) error {
+	modelKey, hasModel := probeModel(pvd)
+
+	tmpl, err := compileTemplates(pvd)
+	if err != nil {
+		return err
+	}
+
+	signer, err := openSigner(h.vault, h.signers, pvd.ID, probeAuth(pvd))
+	if err != nil {
+		return err
+	}
+
+	prompt := provider.HealthProbe{Prompt: pvd.HealthProbe.Prompt}.PromptOrDefault()
+	results := make(map[string]provider.EndpointHealth)
+	for name, ep := range pvd.Endpoints {
+		if provider.EndpointStatus(ep.Status).IsInactive() {
+			continue
+		}
+
+		// Endpoints with a probe route need no model; the rest are sent
+		// the probe prompt.
+		if ep.Probe == nil && !hasModel {
+			continue
+		}
+
+		request := dto.Request{
+			ProviderID: pvd.ID,
+			Endpoint:   name,
+			ModelKey:   modelKey,
+			Messages: []dto.Message{{
+				Role:    dto.MessageRoleUser,
+				Content: dto.Content{{Type: dto.PartTypeText, Body: prompt}},
+			}},
+		}
+
+		upstreamReq, err := probeRequest(pvd, tmpl, ep, request)
+		if err != nil {
+			if h.logger != nil {
+				h.logger.Errorf("Failed to build health probe for endpoint %s of provider %s: %v", name, pvd.Name, err)
+			}
+			continue
+		}
+
+		sample, ok := h.probe(ctx, signer, upstreamReq)
+		if !ok {
+			continue
+		}
+		results[name] = h.policy.Classify(h.record(pvd.ID+"/"+name, sample))
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	// Endpoint health gates routing, so the proxy must not keep serving the
+	// previous classification from cache.
+	defer h.cache.Invalidate(pvd.ID)
+
+	checkedAt := time.Now()
+	return h.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		p, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, pvd.ID)
+		if err != nil {
+			return err
+		}
+
+		for name, health := range results {
+			// The endpoint may have been removed or deactivated while probing.
+			_ = p.RecordEndpointHealth(name, health, checkedAt)
+		}
+
+		return repoProvider.ProviderRepository().SaveEndpointHealth(ctx, p)
+	})
+}
+
+// probeRequest builds the request sent to an endpoint: a bodiless request
+// to its probe route when it has one, or the probe prompt otherwise.
+func probeRequest(pvd dto.Provider, tmpl *providerTemplates, ep dto.Endpoint, request dto.Request) (ProxyRequest, error) {
+	if ep.Probe == nil {
+		return buildUpstreamRequest(pvd, tmpl.request, request)
+	}
+
+	route := *ep.Probe
+	if route.Method == "" {
+		route.Method = provider.DefaultEndpointMethod
+	}
+	target, err := upstreamTarget(pvd.BaseURL, route, request)
+	if err != nil {
+		return ProxyRequest{}, err
+	}
+
+	headers := make(map[string]string, len(pvd.Headers))
+	for k, v := range pvd.Headers {
+		headers[k] = v
+	}
+	return ProxyRequest{Target: target, Method: route.Method, Headers: headers}, nil
+}
+
+// probe sends a probe and reports whether it produced a sample. Only
+// transport errors, timeouts and 5xx responses count as failures: a 4xx is
+// the provider answering, usually about the probe itself. Probes that could
+// not be signed, or were cut short by shutdown, say nothing about the
+// endpoint and are dropped.
+func (h *healthChecker) probe(ctx context.Context, signer Signer, upstreamReq ProxyRequest) (provider.HealthSample, bool) {
+	probeCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	if err := signer.Sign(probeCtx, &upstreamReq); err != nil {
+		if h.logger != nil {
+			h.logger.Errorf("Failed to sign health probe: %v", err)
+		}
+		return provider.HealthSample{}, false
+	}
+
+	start := time.Now()
+	resp, err := h.proxyClient.ProxyRequest(probeCtx, upstreamReq)
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		return provider.HealthSample{}, false
+	}
+
+	return provider.HealthSample{
+		Latency: latency,
+		Failed:  err != nil || resp.StatusCode >= http.StatusInternalServerError,
+	}, true
+}
+
+// record appends a sample to the endpoint's rolling window and returns it.
+func (h *healthChecker) record(key string, sample provider.HealthSample) []provider.HealthSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	window := append(h.samples[key], sample)
+	if len(window) > h.window {
+		window = window[len(window)-h.window:]
+	}
+	h.samples[key] = window
+
+	return append([]provider.HealthSample(nil), window...)
+}
+
+// probeModel picks the model to probe with: the configured probe model, or
+// the provider's first model by key so probes are stable between runs.
+func probeModel(pvd dto.Provider) (string, bool) {
+	if _, ok := pvd.Models[pvd.HealthProbe.ModelKey]; ok {
+		return pvd.HealthProbe.ModelKey, true
+	}
+
+	var first string
+	for key := range pvd.Models {
+		if first == "" || key < first {
+			first = key
+		}
+	}
+	return first, first != ""
+}
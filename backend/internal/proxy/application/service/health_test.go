@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// statusClient answers every request with a fixed status or error.
+type statusClient struct {
+	ProxyClient
+	status int
+	err    error
+}
+
+func (c statusClient) ProxyRequest(context.Context, ProxyRequest) (ProxyResponse, error) {
+	if c.err != nil {
+		return ProxyResponse{}, c.err
+	}
+	return ProxyResponse{StatusCode: c.status, Body: []byte(`{}`)}, nil
+}
+
+func TestHealthProbeFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		client     statusClient
+		wantFailed bool
+	}{
+		{"Success", statusClient{status: http.StatusOK}, false},
+		{"Unauthorized", statusClient{status: http.StatusUnauthorized}, false},
+		{"Rate limited", statusClient{status: http.StatusTooManyRequests}, false},
+		{"Server error", statusClient{status: http.StatusServiceUnavailable}, true},
+		{"Transport error", statusClient{err: &UpstreamError{Err: errors.New("connection refused")}}, true},
+		{"Timeout", statusClient{err: context.DeadlineExceeded}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &healthChecker{proxyClient: tt.client, timeout: time.Second}
+
+			sample, ok := h.probe(context.Background(), credentialSigner("k"), ProxyRequest{})
+			if !ok {
+				t.Fatal("Expected a sample")
+			}
+			if sample.Failed != tt.wantFailed {
+				t.Errorf("Expected failed %t, got %t", tt.wantFailed, sample.Failed)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h := &healthChecker{proxyClient: statusClient{err: context.Canceled}, timeout: time.Second}
+	if _, ok := h.probe(ctx, credentialSigner("k"), ProxyRequest{}); ok {
+		t.Error("Expected a probe cut short by shutdown to be dropped")
+	}
+}
+
+func TestProbeRequestRoute(t *testing.T) {
+	pvd := dto.Provider{
+		BaseURL: "https://api.example.com",
+		Headers: map[string]string{"X-Team": "core"},
+	}
+	ep := dto.Endpoint{
+		Name:  "embeddings",
+		Path:  "v1/embeddings",
+		Probe: &dto.EndpointRoute{Method: http.MethodGet, Path: "v1/models/{{.ModelKey}}"},
+	}
+
+	request, err := probeRequest(pvd, nil, ep, dto.Request{ModelKey: "embed-small"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if request.Method != http.MethodGet || request.Target != "https://api.example.com/v1/models/embed-small" {
+		t.Errorf("Expected GET https://api.example.com/v1/models/embed-small, got %s %s", request.Method, request.Target)
+	}
+	if len(request.Body) != 0 || request.Headers["X-Team"] != "core" {
+		t.Errorf("Expected a bodiless request with the provider headers, got %q %v", request.Body, request.Headers)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+// KeyTrackerConfig configures how rate limited keys are rested.
+type KeyTrackerConfig struct {
+	// Cooldown is how long a rate limited key rests when the provider does
+	// not send a Retry-After.
+	Cooldown time.Duration
+	Now      func() time.Time
+}
+
+const DefaultKeyCooldown = 30 * time.Second
+
+// KeyTracker spreads requests across the keys of provider pools and rests
+// keys that have been rate limited. Like the circuit breakers, its state is
+// per instance: each instance only sees the rate limits it ran into.
+type KeyTracker struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	cooldown time.Duration
+	keys     map[string]*keyState
+}
+
+type keyState struct {
+	coolingUntil time.Time
+	lastLimited  time.Time
+	lastUsed     time.Time
+	limited      int
+	// current is the key's running weight for smooth weighted round-robin.
+	current int
+}
+
+// KeyState is what this instance has seen of a key.
+type KeyState struct {
+	CoolingDownUntil time.Time
+	LastLimitedAt    time.Time
+	LastUsedAt       time.Time
+	RateLimited      int
+}
+
+func NewKeyTracker(cfg KeyTrackerConfig) *KeyTracker {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultKeyCooldown
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &KeyTracker{
+		now:      cfg.Now,
+		cooldown: cfg.Cooldown,
+		keys:     make(map[string]*keyState),
+	}
+}
+
+// State reports the key's rate limits. Cooldowns that have passed are left
+// out.
+func (t *KeyTracker) State(keyID string) KeyState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.keys[keyID]
+	if !ok {
+		return KeyState{}
+	}
+
+	snapshot := KeyState{
+		LastLimitedAt: state.lastLimited,
+		LastUsedAt:    state.lastUsed,
+		RateLimited:   state.limited,
+	}
+	if t.now().Before(state.coolingUntil) {
+		snapshot.CoolingDownUntil = state.coolingUntil
+	}
+	return snapshot
+}
+
+// Forget drops what has been seen of a key, e.g. once it is retired.
+func (t *KeyTracker) Forget(keyID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.keys, keyID)
+}
+
+// limited rests the key for retryAfter, or the default cooldown when the
+// provider did not say.
+func (t *KeyTracker) limited(keyID string, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = t.cooldown
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	state := t.state(keyID)
+	state.coolingUntil = now.Add(retryAfter)
+	state.lastLimited = now
+	state.limited++
+}
+
+// pick chooses the next active key that is not resting and not in exclude,
+// and reports whether there was one.
+func (t *KeyTracker) pick(keys []dto.ProviderKey, strategy string, exclude []string) (dto.ProviderKey, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var candidates []dto.ProviderKey
+	for _, key := range keys {
+		if key.Status != provider.KeyStatusActive.String() || slices.Contains(exclude, key.ID) {
+			continue
+		}
+		if state, ok := t.keys[key.ID]; ok && now.Before(state.coolingUntil) {
+			continue
+		}
+		candidates = append(candidates, key)
+	}
+
+	if len(candidates) == 0 {
+		return dto.ProviderKey{}, false
+	}
+
+	var chosen dto.ProviderKey
+	if provider.KeyStrategy(strategy) == provider.KeyStrategyLeastRecentlyLimited {
+		chosen = t.leastRecentlyLimited(candidates)
+	} else {
+		chosen = t.weightedRoundRobin(candidates)
+	}
+
+	t.state(chosen.ID).lastUsed = now
+	return chosen, true
+}
+
+// weightedRoundRobin is the smooth weighted round-robin used by nginx: every
+// candidate gains its weight, and the one with the most is chosen and pays
+// back the total. Keys are interleaved rather than sent in bursts.
+func (t *KeyTracker) weightedRoundRobin(candidates []dto.ProviderKey) dto.ProviderKey {
+	var (
+		total int
+		best  *keyState
+		key   dto.ProviderKey
+	)
+	for _, candidate := range candidates {
+		weight := max(candidate.Weight, 1)
+		state := t.state(candidate.ID)
+		state.current += weight
+		total += weight
+
+		if best == nil || state.current > best.current {
+			best, key = state, candidate
+		}
+	}
+
+	best.current -= total
+	return key
+}
+
+// leastRecentlyLimited chooses the key limited longest ago, keys never
+// limited first, and among those the one used longest ago.
+func (t *KeyTracker) leastRecentlyLimited(candidates []dto.ProviderKey) dto.ProviderKey {
+	var (
+		best *keyState
+		key  dto.ProviderKey
+	)
+	for _, candidate := range candidates {
+		state := t.state(candidate.ID)
+		if best == nil ||
+			state.lastLimited.Before(best.lastLimited) ||
+			state.lastLimited.Equal(best.lastLimited) && state.lastUsed.Before(best.lastUsed) {
+			best, key = state, candidate
+		}
+	}
+	return key
+}
+
+// state returns the key's state, creating it on first use. t.mu must be
+// held.
+func (t *KeyTracker) state(keyID string) *keyState {
+	state, ok := t.keys[keyID]
+	if !ok {
+		state = &keyState{}
+		t.keys[keyID] = state
+	}
+	return state
+}
+
+// hasActiveKey reports whether requests should be sent with the pool rather
+// than the auth credential.
+func hasActiveKey(keys []dto.ProviderKey) bool {
+	return slices.ContainsFunc(keys, func(key dto.ProviderKey) bool {
+		return key.Status == provider.KeyStatusActive.String()
+	})
+}
+
+// keyAuth is the provider's auth settings around one key of its pool.
+func keyAuth(auth dto.AuthConfig, key dto.ProviderKey) dto.AuthConfig {
+	auth.EncryptedCredential = key.EncryptedCredential
+	auth.DataKey = key.DataKey
+	auth.KeyID = key.MasterKeyID
+	return auth
+}
+
+// probeAuth is the auth health probes are sent with: the pool's first active
+// key, so probes leave the rotation alone, or the auth credential.
+func probeAuth(pvd dto.Provider) dto.AuthConfig {
+	for _, key := range pvd.Keys {
+		if key.Status == provider.KeyStatusActive.String() {
+			return keyAuth(pvd.AuthConfig, key)
+		}
+	}
+	return pvd.AuthConfig
+}
+
+func keyToDTO(key provider.Key, state KeyState) dto.ProviderKey {
+	return dto.ProviderKey{
+		ID:               key.ID.String(),
+		Name:             key.Name,
+		Weight:           key.Weight,
+		Status:           key.Status.String(),
+		CreatedAt:        key.CreatedAt,
+		StatusChangedAt:  key.StatusChangedAt,
+		CoolingDownUntil: state.CoolingDownUntil,
+		LastLimitedAt:    state.LastLimitedAt,
+		LastUsedAt:       state.LastUsedAt,
+		RateLimited:      state.RateLimited,
+
+		EncryptedCredential: key.Credential.Encrypted,
+		DataKey:             key.Credential.DataKey,
+		MasterKeyID:         key.Credential.KeyID,
+	}
+}
@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+	"github.com/basetable/basetable/backend/internal/shared/domain"
+	"github.com/basetable/basetable/backend/internal/shared/log"
+)
+
+func poolKey(id string, weight int, status provider.KeyStatus) dto.ProviderKey {
+	return dto.ProviderKey{ID: id, Weight: weight, Status: status.String()}
+}
+
+func TestKeyTrackerWeightedRoundRobin(t *testing.T) {
+	tracker := NewKeyTracker(KeyTrackerConfig{})
+	keys := []dto.ProviderKey{
+		poolKey("a", 3, provider.KeyStatusActive),
+		poolKey("b", 1, provider.KeyStatusActive),
+		poolKey("c", 5, provider.KeyStatusQuarantined),
+		poolKey("d", 5, provider.KeyStatusRetired),
+	}
+
+	var picked []string
+	for range 8 {
+		key, ok := tracker.pick(keys, string(provider.KeyStrategyWeightedRoundRobin), nil)
+		if !ok {
+			t.Fatal("Expected a key")
+		}
+		picked = append(picked, key.ID)
+	}
+
+	// The heavier key is interleaved with the lighter one rather than sent
+	// its whole share in a burst.
+	want := "a,a,b,a,a,a,b,a"
+	if got := strings.Join(picked, ","); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if key, ok := tracker.pick(keys, string(provider.KeyStrategyWeightedRoundRobin), []string{"a"}); !ok || key.ID != "b" {
+		t.Errorf("Expected the excluded key to be skipped, got %s", key.ID)
+	}
+}
+
+func TestKeyTrackerLeastRecentlyLimited(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewKeyTracker(KeyTrackerConfig{Now: func() time.Time { return now }})
+	keys := []dto.ProviderKey{
+		poolKey("a", 1, provider.KeyStatusActive),
+		poolKey("b", 1, provider.KeyStatusActive),
+		poolKey("c", 1, provider.KeyStatusActive),
+	}
+
+	pick := func(n int) string {
+		var picked []string
+		for range n {
+			now = now.Add(time.Second)
+			key, _ := tracker.pick(keys, string(provider.KeyStrategyLeastRecentlyLimited), nil)
+			picked = append(picked, key.ID)
+		}
+		return strings.Join(picked, ",")
+	}
+
+	// Keys never limited take turns by last use.
+	if got := pick(4); got != "a,b,c,a" {
+		t.Errorf("Expected a,b,c,a, got %s", got)
+	}
+
+	// A limited key is only used once the others have been limited too.
+	tracker.limited("a", time.Second)
+	if got := pick(3); got != "b,c,b" {
+		t.Errorf("Expected b,c,b, got %s", got)
+	}
+
+	tracker.limited("b", time.Second)
+	tracker.limited("c", time.Second)
+	if got := pick(1); got != "a" {
+		t.Errorf("Expected the key limited longest ago, got %s", got)
+	}
+}
+
+func TestKeyTrackerCooldown(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	tracker := NewKeyTracker(KeyTrackerConfig{Cooldown: time.Minute, Now: func() time.Time { return now }})
+	keys := []dto.ProviderKey{poolKey("a", 1, provider.KeyStatusActive), poolKey("b", 1, provider.KeyStatusActive)}
+
+	tracker.limited("a", 10*time.Second)
+	tracker.limited("b", 0)
+
+	tests := []struct {
+		name    string
+		after   time.Duration
+		exclude []string
+		want    string
+	}{
+		{"Both resting", 5 * time.Second, nil, ""},
+		{"Retry-After has passed", 10 * time.Second, nil, "a"},
+		{"Default cooldown has not passed", 30 * time.Second, []string{"a"}, ""},
+		{"Default cooldown has passed", time.Minute, []string{"a"}, "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = start.Add(tt.after)
+			key, ok := tracker.pick(keys, string(provider.KeyStrategyWeightedRoundRobin), tt.exclude)
+			if ok != (tt.want != "") || key.ID != tt.want {
+				t.Errorf("Expected key %q, got %q", tt.want, key.ID)
+			}
+		})
+	}
+
+	state := tracker.State("a")
+	if state.RateLimited != 1 || !state.CoolingDownUntil.IsZero() || !state.LastLimitedAt.Equal(start) {
+		t.Errorf("Expected one past rate limit, got %+v", state)
+	}
+}
+
+// plainVault opens credentials stored as plaintext.
+type plainVault struct{ CredentialVault }
+
+func (plainVault) Open(credential provider.Credential) (string, error) {
+	return credential.Encrypted, nil
+}
+
+// credentialSigners sign requests with the credential as the signer itself.
+type credentialSigners struct{}
+
+type credentialSigner string
+
+func (credentialSigners) Signer(_ string, _ dto.AuthConfig, credential string) (Signer, error) {
+	return credentialSigner(credential), nil
+}
+
+func (credentialSigners) Forget(string) {}
+
+func (s credentialSigner) Sign(_ context.Context, request *ProxyRequest) error {
+	request.Headers = map[string]string{"Authorization": string(s)}
+	return nil
+}
+
+type quarantineRecorder struct {
+	ProviderService
+	quarantined []string
+	err         error
+}
+
+func (r *quarantineRecorder) QuarantineKey(_ context.Context, request dto.KeyRequest) error {
+	r.quarantined = append(r.quarantined, request.KeyID)
+	return r.err
+}
+
+// errorLog records the errors logged.
+type errorLog struct {
+	log.Logger
+	errors []string
+}
+
+func (l *errorLog) Errorf(tmp string, args ...any) {
+	l.errors = append(l.errors, fmt.Sprintf(tmp, args...))
+}
+
+func TestSendWithKeys(t *testing.T) {
+	key := func(id string) dto.ProviderKey {
+		k := poolKey(id, 1, provider.KeyStatusActive)
+		k.EncryptedCredential = "secret-" + id
+		return k
+	}
+
+	tests := []struct {
+		name            string
+		keys            []dto.ProviderKey
+		statuses        map[string]int
+		wantSent        string
+		wantStatus      int
+		wantQuarantined string
+		wantLimited     string
+	}{
+		{"Auth credential without a pool", nil, map[string]int{"auth": http.StatusTooManyRequests}, "auth", http.StatusTooManyRequests, "", ""},
+		{"Rate limited key rests", []dto.ProviderKey{key("a"), key("b")}, map[string]int{"secret-a": http.StatusTooManyRequests}, "secret-a,secret-b", 0, "", "a"},
+		{"Rejected key is quarantined", []dto.ProviderKey{key("a"), key("b")}, map[string]int{"secret-a": http.StatusUnauthorized}, "secret-a,secret-b", 0, "a", ""},
+		{"Other failures are returned", []dto.ProviderKey{key("a"), key("b")}, map[string]int{"secret-a": http.StatusInternalServerError}, "secret-a", http.StatusInternalServerError, "", ""},
+		{"Every key limited", []dto.ProviderKey{key("a"), key("b")}, map[string]int{"secret-a": http.StatusTooManyRequests, "secret-b": http.StatusTooManyRequests}, "secret-a,secret-b", http.StatusTooManyRequests, "", "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &quarantineRecorder{}
+			s := &proxyService{
+				providerService: recorder,
+				vault:           plainVault{},
+				signers:         credentialSigners{},
+				keys:            NewKeyTracker(KeyTrackerConfig{}),
+			}
+
+			tgt := &target{provider: dto.Provider{
+				ID:          "p",
+				Keys:        tt.keys,
+				KeyStrategy: string(provider.KeyStrategyWeightedRoundRobin),
+				AuthConfig:  dto.AuthConfig{EncryptedCredential: "auth"},
+			}}
+			if err := s.useKey(tgt); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var sent []string
+			err := s.sendWithKeys(context.Background(), tgt, ProxyRequest{}, func(signer Signer, request ProxyRequest) error {
+				signer.Sign(context.Background(), &request)
+				credential := request.Headers["Authorization"]
+				sent = append(sent, credential)
+				if status := tt.statuses[credential]; status != 0 {
+					return &UpstreamError{StatusCode: status, Err: errors.New("failed")}
+				}
+				return nil
+			})
+
+			if got := strings.Join(sent, ","); got != tt.wantSent {
+				t.Errorf("Expected requests with %s, got %s", tt.wantSent, got)
+			}
+
+			var upstreamErr *UpstreamError
+			status := 0
+			if errors.As(err, &upstreamErr) {
+				status = upstreamErr.StatusCode
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d (%v)", tt.wantStatus, status, err)
+			}
+
+			if got := strings.Join(recorder.quarantined, ","); got != tt.wantQuarantined {
+				t.Errorf("Expected quarantined %q, got %q", tt.wantQuarantined, got)
+			}
+			if tt.wantLimited != "" && s.keys.State(tt.wantLimited).CoolingDownUntil.IsZero() {
+				t.Errorf("Expected key %s to be cooling down", tt.wantLimited)
+			}
+		})
+	}
+}
+
+// tokenSigners sign requests with a token per credential, numbered by how
+// often the credential's token was invalidated.
+type tokenSigners struct {
+	invalidated map[string]int
+}
+
+type tokenSigner struct {
+	credential string
+	signers    tokenSigners
+}
+
+func (s tokenSigners) Signer(_ string, _ dto.AuthConfig, credential string) (Signer, error) {
+	return &tokenSigner{credential: credential, signers: s}, nil
+}
+
+func (tokenSigners) Forget(string) {}
+
+func (s *tokenSigner) Sign(_ context.Context, request *ProxyRequest) error {
+	request.Headers = map[string]string{"Authorization": fmt.Sprintf("%s#%d", s.credential, s.signers.invalidated[s.credential]+1)}
+	return nil
+}
+
+func (s *tokenSigner) Invalidate() {
+	s.signers.invalidated[s.credential]++
+}
+
+func TestSendWithKeysRefreshesTokens(t *testing.T) {
+	key := func(id string) dto.ProviderKey {
+		k := poolKey(id, 1, provider.KeyStatusActive)
+		k.EncryptedCredential = "secret-" + id
+		return k
+	}
+
+	tests := []struct {
+		name            string
+		keys            []dto.ProviderKey
+		rejected        map[string]bool
+		wantSent        string
+		wantStatus      int
+		wantQuarantined string
+	}{
+		{"Fresh token is accepted", []dto.ProviderKey{key("a"), key("b")}, map[string]bool{"secret-a#1": true}, "secret-a#1,secret-a#2", 0, ""},
+		{"Fresh token is rejected", []dto.ProviderKey{key("a"), key("b")}, map[string]bool{"secret-a#1": true, "secret-a#2": true}, "secret-a#1,secret-a#2,secret-b#1", 0, "a"},
+		{"Next key is refreshed too", []dto.ProviderKey{key("a"), key("b")}, map[string]bool{"secret-a#1": true, "secret-a#2": true, "secret-b#1": true}, "secret-a#1,secret-a#2,secret-b#1,secret-b#2", 0, "a"},
+		{"Auth credential without a pool", nil, map[string]bool{"auth#1": true}, "auth#1,auth#2", 0, ""},
+		{"Auth credential refreshed once", nil, map[string]bool{"auth#1": true, "auth#2": true}, "auth#1,auth#2", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &quarantineRecorder{}
+			s := &proxyService{
+				providerService: recorder,
+				vault:           plainVault{},
+				signers:         tokenSigners{invalidated: make(map[string]int)},
+				keys:            NewKeyTracker(KeyTrackerConfig{}),
+			}
+
+			tgt := &target{provider: dto.Provider{
+				ID:          "p",
+				Keys:        tt.keys,
+				KeyStrategy: string(provider.KeyStrategyWeightedRoundRobin),
+				AuthConfig:  dto.AuthConfig{EncryptedCredential: "auth"},
+			}}
+			if err := s.useKey(tgt); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var sent []string
+			err := s.sendWithKeys(context.Background(), tgt, ProxyRequest{}, func(signer Signer, request ProxyRequest) error {
+				signer.Sign(context.Background(), &request)
+				token := request.Headers["Authorization"]
+				sent = append(sent, token)
+				if tt.rejected[token] {
+					return &UpstreamError{StatusCode: http.StatusUnauthorized, Err: errors.New("failed")}
+				}
+				return nil
+			})
+
+			if got := strings.Join(sent, ","); got != tt.wantSent {
+				t.Errorf("Expected requests with %s, got %s", tt.wantSent, got)
+			}
+
+			var upstreamErr *UpstreamError
+			status := 0
+			if errors.As(err, &upstreamErr) {
+				status = upstreamErr.StatusCode
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d (%v)", tt.wantStatus, status, err)
+			}
+
+			if got := strings.Join(recorder.quarantined, ","); got != tt.wantQuarantined {
+				t.Errorf("Expected quarantined %q, got %q", tt.wantQuarantined, got)
+			}
+		})
+	}
+}
+
+// clientAttempts is how often rateLimitedClient tries a request.
+const clientAttempts = 3
+
+// rateLimitedClient answers 429 for the credentials in limited, counting the
+// requests each credential sends. Like the HTTP client, it retries a 429
+// unless asked to return it.
+type rateLimitedClient struct {
+	ProxyClient
+	limited map[string]bool
+	hits    map[string]int
+}
+
+func (c *rateLimitedClient) ProxyRequest(_ context.Context, request ProxyRequest) (ProxyResponse, error) {
+	credential := request.Headers["Authorization"]
+	for range clientAttempts {
+		c.hits[credential]++
+		if !c.limited[credential] {
+			return ProxyResponse{StatusCode: http.StatusOK, Body: []byte(`{}`)}, nil
+		}
+		if request.ReturnRateLimits {
+			break
+		}
+	}
+	return ProxyResponse{StatusCode: http.StatusTooManyRequests, Body: []byte(`rate limited`)}, nil
+}
+
+func TestSendWithKeysRateLimitHits(t *testing.T) {
+	key := func(id string) dto.ProviderKey {
+		k := poolKey(id, 1, provider.KeyStatusActive)
+		k.EncryptedCredential = "secret-" + id
+		return k
+	}
+
+	tests := []struct {
+		name     string
+		keys     []dto.ProviderKey
+		limited  map[string]bool
+		wantHits map[string]int
+	}{
+		{"Pool key rotates at once", []dto.ProviderKey{key("a"), key("b")}, map[string]bool{"secret-a": true}, map[string]int{"secret-a": 1, "secret-b": 1}},
+		{"Every pool key limited", []dto.ProviderKey{key("a"), key("b")}, map[string]bool{"secret-a": true, "secret-b": true}, map[string]int{"secret-a": 1, "secret-b": 1}},
+		{"Auth credential is retried", nil, map[string]bool{"auth": true}, map[string]int{"auth": clientAttempts}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &rateLimitedClient{limited: tt.limited, hits: make(map[string]int)}
+			s := &proxyService{
+				proxyClient: client,
+				vault:       plainVault{},
+				signers:     credentialSigners{},
+				keys:        NewKeyTracker(KeyTrackerConfig{}),
+			}
+
+			tgt := &target{provider: dto.Provider{
+				ID:          "p",
+				Keys:        tt.keys,
+				KeyStrategy: string(provider.KeyStrategyWeightedRoundRobin),
+				AuthConfig:  dto.AuthConfig{EncryptedCredential: "auth"},
+			}}
+			if err := s.useKey(tgt); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			responseTmpl := template.Must(template.New("response").Parse(`{}`))
+			attempt := newUsageAttempt(dto.Request{})
+			s.sendWithKeys(context.Background(), tgt, ProxyRequest{}, func(signer Signer, request ProxyRequest) error {
+				_, err := s.doProxyRequest(context.Background(), signer, request, responseTmpl, attempt)
+				return err
+			})
+
+			if !maps.Equal(client.hits, tt.wantHits) {
+				t.Errorf("Expected hits %v, got %v", tt.wantHits, client.hits)
+			}
+			for credential := range tt.limited {
+				id := strings.TrimPrefix(credential, "secret-")
+				if credential != "auth" && s.keys.State(id).CoolingDownUntil.IsZero() {
+					t.Errorf("Expected key %s to be cooling down", id)
+				}
+			}
+		})
+	}
+}
+
+func TestSendWithKeysQuarantineFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantLogged bool
+	}{
+		{"Quarantined", nil, false},
+		{"Already quarantined or retired", fmt.Errorf("%w: %w", ErrInvalidRequest, &domain.Error{Type: provider.ErrorTypeKeyStatus, Message: "key is retired"}), false},
+		{"Write failed", errors.New("database is down"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &errorLog{}
+			s := &proxyService{
+				providerService: &quarantineRecorder{err: tt.err},
+				vault:           plainVault{},
+				signers:         credentialSigners{},
+				keys:            NewKeyTracker(KeyTrackerConfig{}),
+				logger:          logger,
+			}
+
+			tgt := &target{provider: dto.Provider{
+				ID:          "p",
+				Keys:        []dto.ProviderKey{poolKey("a", 1, provider.KeyStatusActive)},
+				KeyStrategy: string(provider.KeyStrategyWeightedRoundRobin),
+			}}
+			if err := s.useKey(tgt); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			s.sendWithKeys(context.Background(), tgt, ProxyRequest{}, func(Signer, ProxyRequest) error {
+				return &UpstreamError{StatusCode: http.StatusUnauthorized, Err: errors.New("invalid key")}
+			})
+
+			if logged := len(logger.errors) > 0; logged != tt.wantLogged {
+				t.Errorf("Expected logged %t, got %v", tt.wantLogged, logger.errors)
+			}
+		})
+	}
+}
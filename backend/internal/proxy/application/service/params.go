@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// ErrInvalidRequest is returned for requests that no provider could accept.
+var ErrInvalidRequest = errors.New("invalid request")
+
+func validateGenerationParams(params dto.GenerationParams) error {
+	if t := params.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("%w: temperature must be between 0 and 2", ErrInvalidRequest)
+	}
+
+	if p := params.TopP; p != nil && (*p <= 0 || *p > 1) {
+		return fmt.Errorf("%w: top_p must be greater than 0 and at most 1", ErrInvalidRequest)
+	}
+
+	if m := params.MaxTokens; m != nil && *m < 1 {
+		return fmt.Errorf("%w: max_tokens must be at least 1", ErrInvalidRequest)
+	}
+
+	for _, stop := range params.Stop {
+		if stop == "" {
+			return fmt.Errorf("%w: stop sequences must not be empty", ErrInvalidRequest)
+		}
+	}
+
+	if p := params.PresencePenalty; p != nil && (*p < -2 || *p > 2) {
+		return fmt.Errorf("%w: presence_penalty must be between -2 and 2", ErrInvalidRequest)
+	}
+
+	if p := params.FrequencyPenalty; p != nil && (*p < -2 || *p > 2) {
+		return fmt.Errorf("%w: frequency_penalty must be between -2 and 2", ErrInvalidRequest)
+	}
+
+	return nil
+}
+
+// checkModelLimits rejects parameters the target model does not accept.
+// Another target may accept them, so the rejection fails over.
+func checkModelLimits(params dto.GenerationParams, model dto.Model) error {
+	if limit := model.Limits.MaxStopSequences; limit > 0 && len(params.Stop) > limit {
+		return fmt.Errorf("%w: %w: model %s accepts at most %d stop sequences",
+			ErrTargetUnavailable, ErrInvalidRequest, model.Key, limit)
+	}
+	return nil
+}
+
+// clampGenerationParams caps max_tokens at the target model's output limit.
+// The request may be tried against several targets, so the pointer is never
+// written through.
+func clampGenerationParams(params dto.GenerationParams, limits dto.Limits) dto.GenerationParams {
+	if params.MaxTokens != nil && limits.MaxOutputTokens > 0 && *params.MaxTokens > limits.MaxOutputTokens {
+		maxTokens := limits.MaxOutputTokens
+		params.MaxTokens = &maxTokens
+	}
+	return params
+}
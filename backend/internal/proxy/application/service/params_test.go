@@ -0,0 +1,40 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+func TestCheckModelLimits(t *testing.T) {
+	stops := []string{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		name    string
+		limit   int
+		stop    []string
+		wantErr bool
+	}{
+		{"No declared limit", 0, stops, false},
+		{"Within the limit", 4, stops[:4], false},
+		{"Beyond the limit", 4, stops, true},
+		{"Larger limit", 8, stops, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := dto.Model{Key: "sample-model", Limits: dto.Limits{MaxStopSequences: tt.limit}}
+			err := checkModelLimits(dto.GenerationParams{Stop: tt.stop}, model)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidRequest) || !canFailover(err) {
+				t.Errorf("Expected an invalid request that fails over, got %v", err)
+			}
+		})
+	}
+}
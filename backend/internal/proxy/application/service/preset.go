@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// ErrUnknownPreset is returned when a provider names a preset that does not
+// exist.
+var ErrUnknownPreset = errors.New("unknown provider preset")
+
+//go:embed presets/*.tmpl
+var presetFiles embed.FS
+
+// preset is a tested starting point for a well-known provider API. Fields
+// the caller sets explicitly take precedence.
+type preset struct {
+	baseURL          string
+	authHeader       string
+	authPrefix       string
+	headers          map[string]string
+	endpoints        []dto.Endpoint
+	requestTemplate  string
+	responseTemplate string
+}
+
+// openAIDialect describes how an OpenAI-compatible API departs from
+// OpenAI's own request format.
+type openAIDialect struct {
+	MaxTokensField string
+	SeedField      string
+	// Penalties is whether presence and frequency penalties are accepted.
+	Penalties bool
+	// StreamUsage asks for usage on the final chunk of a stream, for APIs
+	// that only report it when asked.
+	StreamUsage bool
+	// Files is whether PDF attachments are accepted.
+	Files bool
+	// ImageURLs is whether images may be passed by URL for the API to fetch.
+	ImageURLs bool
+}
+
+func chatEndpoint(path string) []dto.Endpoint {
+	return []dto.Endpoint{{Name: DefaultChatEndpoint, Path: path}}
+}
+
+// presets are keyed by the name callers pass when creating a provider.
+// Gemini is reached through its OpenAI-compatible endpoint, so it shares the
+// OpenAI templates.
+var presets = map[string]preset{
+	"openai": openAIPreset("https://api.openai.com", "v1/chat/completions", openAIDialect{
+		MaxTokensField: "max_completion_tokens",
+		SeedField:      "seed",
+		Penalties:      true,
+		StreamUsage:    true,
+		Files:          true,
+		ImageURLs:      true,
+	}),
+	"anthropic": {
+		baseURL:          "https://api.anthropic.com",
+		authHeader:       "x-api-key",
+		headers:          map[string]string{"anthropic-version": "2023-06-01"},
+		endpoints:        chatEndpoint("v1/messages"),
+		requestTemplate:  presetFile("anthropic_request.tmpl"),
+		responseTemplate: presetFile("anthropic_response.tmpl"),
+	},
+	"gemini": openAIPreset("https://generativelanguage.googleapis.com", "v1beta/openai/chat/completions", openAIDialect{
+		MaxTokensField: "max_tokens",
+		SeedField:      "seed",
+		Penalties:      true,
+		StreamUsage:    true,
+	}),
+	"mistral": openAIPreset("https://api.mistral.ai", "v1/chat/completions", openAIDialect{
+		MaxTokensField: "max_tokens",
+		SeedField:      "random_seed",
+		Penalties:      true,
+		ImageURLs:      true,
+	}),
+	"groq": openAIPreset("https://api.groq.com/openai", "v1/chat/completions", openAIDialect{
+		MaxTokensField: "max_completion_tokens",
+		SeedField:      "seed",
+		StreamUsage:    true,
+		ImageURLs:      true,
+	}),
+	"openrouter": openAIPreset("https://openrouter.ai/api", "v1/chat/completions", openAIDialect{
+		MaxTokensField: "max_tokens",
+		SeedField:      "seed",
+		Penalties:      true,
+		StreamUsage:    true,
+		Files:          true,
+		ImageURLs:      true,
+	}),
+	// Ollama ignores the credential, but one is still required; any value
+	// will do.
+	"ollama": openAIPreset("http://localhost:11434", "v1/chat/completions", openAIDialect{
+		MaxTokensField: "max_tokens",
+		SeedField:      "seed",
+		Penalties:      true,
+		StreamUsage:    true,
+	}),
+}
+
+func openAIPreset(baseURL, path string, dialect openAIDialect) preset {
+	// The request template is itself rendered once per dialect, with
+	// delimiters that leave the provider template's own actions alone.
+	meta := template.Must(template.New("openai_request.tmpl").
+		Delims("[[", "]]").
+		Parse(presetFile("openai_request.tmpl")))
+
+	var request bytes.Buffer
+	if err := meta.Execute(&request, dialect); err != nil {
+		panic(err)
+	}
+
+	return preset{
+		baseURL:          baseURL,
+		authHeader:       "Authorization",
+		authPrefix:       "Bearer",
+		endpoints:        chatEndpoint(path),
+		requestTemplate:  request.String(),
+		responseTemplate: presetFile("openai_response.tmpl"),
+	}
+}
+
+func presetFile(name string) string {
+	b, err := presetFiles.ReadFile("presets/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// applyPreset fills in whatever the request leaves unset from the named
+// preset. It returns the endpoints the provider should be created with.
+func applyPreset(request dto.CreateProviderRequest) (dto.CreateProviderRequest, []dto.Endpoint, error) {
+	if request.Preset == "" {
+		return request, nil, nil
+	}
+
+	p, ok := presets[request.Preset]
+	if !ok {
+		return request, nil, fmt.Errorf("%w %q, expected one of %s",
+			ErrUnknownPreset, request.Preset, strings.Join(slices.Sorted(maps.Keys(presets)), ", "))
+	}
+
+	if request.BaseURL == "" {
+		request.BaseURL = p.baseURL
+	}
+	if request.RequestTemplate == "" {
+		request.RequestTemplate = p.requestTemplate
+	}
+	if request.ResponseTemplate == "" {
+		request.ResponseTemplate = p.responseTemplate
+	}
+	if request.Auth.Header == "" {
+		request.Auth.Header = p.authHeader
+		request.Auth.Prefix = p.authPrefix
+	}
+
+	headers := maps.Clone(p.headers)
+	if headers == nil {
+		headers = make(map[string]string, len(request.Headers))
+	}
+	maps.Copy(headers, request.Headers)
+	request.Headers = headers
+
+	return request, p.endpoints, nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// TestPresetTemplates checks every preset against the canonical request
+// suite and the built-in sample responses.
+func TestPresetTemplates(t *testing.T) {
+	for _, name := range slices.Sorted(maps.Keys(presets)) {
+		t.Run(name, func(t *testing.T) {
+			p := presets[name]
+			if err := validateTemplates(p.requestTemplate, p.responseTemplate, nil); err != nil {
+				t.Errorf("Expected preset templates to validate, got %v", err)
+			}
+		})
+	}
+}
+
+// TestPresetRequests renders the canonical request suite with each preset
+// and compares the bodies to testdata/presets/<preset>/requests.golden.json.
+func TestPresetRequests(t *testing.T) {
+	for _, name := range slices.Sorted(maps.Keys(presets)) {
+		t.Run(name, func(t *testing.T) {
+			templates, err := compileTemplates(dto.Provider{
+				RequestTemplate:  presets[name].requestTemplate,
+				ResponseTemplate: presets[name].responseTemplate,
+			})
+			if err != nil {
+				t.Fatalf("Expected templates to compile, got %v", err)
+			}
+
+			bodies := make(map[string]json.RawMessage, len(sampleRequests))
+			for sample, request := range sampleRequests {
+				var body bytes.Buffer
+				if err := templates.request.Execute(&body, request); err != nil {
+					t.Fatalf("Expected %s request to render, got %v", sample, err)
+				}
+				if !json.Valid(body.Bytes()) {
+					t.Fatalf("Expected %s request to be valid JSON, got %s", sample, body.String())
+				}
+				bodies[sample] = body.Bytes()
+			}
+
+			compareGolden(t, filepath.Join("testdata", "presets", name, "requests.golden.json"), bodies)
+		})
+	}
+}
+
+// TestPresetResponses maps the provider responses under
+// testdata/presets/<preset>/responses with the preset's response template.
+// A .json file holds one response body; a .sse file holds a stream, whose
+// data lines are mapped one chunk at a time.
+func TestPresetResponses(t *testing.T) {
+	for _, name := range slices.Sorted(maps.Keys(presets)) {
+		files, err := filepath.Glob(filepath.Join("testdata", "presets", name, "responses", "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, file := range files {
+			if strings.HasSuffix(file, ".golden.json") {
+				continue
+			}
+
+			t.Run(name+"/"+filepath.Base(file), func(t *testing.T) {
+				templates, err := compileTemplates(dto.Provider{
+					RequestTemplate:  presets[name].requestTemplate,
+					ResponseTemplate: presets[name].responseTemplate,
+				})
+				if err != nil {
+					t.Fatalf("Expected templates to compile, got %v", err)
+				}
+
+				raw, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var bodies [][]byte
+				if filepath.Ext(file) == ".sse" {
+					scanner := bufio.NewScanner(bytes.NewReader(raw))
+					for scanner.Scan() {
+						data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+						if ok && data != "[DONE]" {
+							bodies = append(bodies, []byte(data))
+						}
+					}
+				} else {
+					bodies = append(bodies, raw)
+				}
+
+				var responses []*dto.Response
+				for _, body := range bodies {
+					response, err := renderResponse(templates.response, body)
+					if err != nil {
+						t.Fatalf("Expected %s to map, got %v", body, err)
+					}
+					responses = append(responses, response)
+				}
+
+				golden := strings.TrimSuffix(file, filepath.Ext(file)) + ".golden.json"
+				compareGolden(t, golden, responses)
+			})
+		}
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   dto.CreateProviderRequest
+		wantURL   string
+		wantAuth  string
+		wantPaths []string
+		wantErr   bool
+	}{
+		{
+			name:    "no preset",
+			request: dto.CreateProviderRequest{BaseURL: "https://example.com"},
+			wantURL: "https://example.com",
+		},
+		{
+			name:      "fills in unset fields",
+			request:   dto.CreateProviderRequest{Preset: "anthropic"},
+			wantURL:   "https://api.anthropic.com",
+			wantAuth:  "x-api-key",
+			wantPaths: []string{"v1/messages"},
+		},
+		{
+			name:      "explicit fields win",
+			request:   dto.CreateProviderRequest{Preset: "ollama", BaseURL: "http://gpu-box:11434", Auth: dto.AuthConfig{Header: "X-Key"}},
+			wantURL:   "http://gpu-box:11434",
+			wantAuth:  "X-Key",
+			wantPaths: []string{"v1/chat/completions"},
+		},
+		{
+			name:    "unknown preset",
+			request: dto.CreateProviderRequest{Preset: "nope"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, endpoints, err := applyPreset(tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if request.BaseURL != tt.wantURL {
+				t.Errorf("Expected base URL %q, got %q", tt.wantURL, request.BaseURL)
+			}
+			if request.Auth.Header != tt.wantAuth {
+				t.Errorf("Expected auth header %q, got %q", tt.wantAuth, request.Auth.Header)
+			}
+
+			var paths []string
+			for _, ep := range endpoints {
+				paths = append(paths, ep.Path)
+			}
+			if !slices.Equal(paths, tt.wantPaths) {
+				t.Errorf("Expected endpoint paths %v, got %v", tt.wantPaths, paths)
+			}
+		})
+	}
+}
+
+func TestApplyPresetKeepsHeaders(t *testing.T) {
+	request, _, err := applyPreset(dto.CreateProviderRequest{
+		Preset:  "anthropic",
+		Headers: map[string]string{"anthropic-beta": "tools-2024-05-16"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if request.Headers["anthropic-version"] != "2023-06-01" {
+		t.Errorf("Expected preset header to be kept, got %v", request.Headers)
+	}
+	if request.Headers["anthropic-beta"] != "tools-2024-05-16" {
+		t.Errorf("Expected caller header to be kept, got %v", request.Headers)
+	}
+	if _, ok := presets["anthropic"].headers["anthropic-beta"]; ok {
+		t.Errorf("Expected preset headers to be left unchanged")
+	}
+}
+
+// compareGolden compares v, as indented JSON, to the golden file, or
+// rewrites the file when -update is given.
+func compareGolden(t *testing.T, path string, v any) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected golden file %s, got %v (run with -update to create it)", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected output to match %s, got:\n%s", path, got)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider/model"
+)
+
+// pricingFromDTO parses a price sheet. The prompt and completion shorthands
+// become base rules unless Rules already prices those dimensions.
+func pricingFromDTO(p dto.Pricing) (model.Pricing, error) {
+	pricing := model.Pricing{
+		Unit:     model.PricingUnit(p.Unit),
+		Currency: p.Currency,
+		Rules:    make([]model.PriceRule, 0, len(p.Rules)+2),
+	}
+	if pricing.Unit == "" {
+		pricing.Unit = model.UnitPer1000Tokens
+	}
+
+	for _, r := range p.Rules {
+		price, err := model.ParseDecimal(r.Price)
+		if err != nil {
+			return model.Pricing{}, fmt.Errorf("%s price: %w", r.Dimension, err)
+		}
+		pricing.Rules = append(pricing.Rules, model.PriceRule{
+			Dimension:         model.Dimension(r.Dimension),
+			Price:             price,
+			AbovePromptTokens: r.AbovePromptTokens,
+		})
+	}
+
+	shorthands := []struct {
+		dimension model.Dimension
+		price     string
+	}{
+		{model.DimensionPromptTokens, p.PromptTokenPrice},
+		{model.DimensionCompletionTokens, p.CompletionTokenPrice},
+	}
+	for _, sh := range shorthands {
+		if sh.price == "" {
+			continue
+		}
+		if _, ok := pricing.Price(sh.dimension, 0); ok {
+			continue
+		}
+
+		price, err := model.ParseDecimal(sh.price)
+		if err != nil {
+			return model.Pricing{}, fmt.Errorf("%s price: %w", sh.dimension, err)
+		}
+		pricing.Rules = append(pricing.Rules, model.PriceRule{Dimension: sh.dimension, Price: price})
+	}
+
+	return pricing, nil
+}
+
+// pricingToDTO formats a price sheet, filling the shorthands from the base
+// prompt and completion rules.
+func pricingToDTO(pricing model.Pricing) dto.Pricing {
+	p := dto.Pricing{
+		Currency: pricing.Currency,
+		Unit:     pricing.Unit.String(),
+		Rules:    make([]dto.PriceRule, len(pricing.Rules)),
+	}
+
+	for i, r := range pricing.Rules {
+		p.Rules[i] = dto.PriceRule{
+			Dimension:         r.Dimension.String(),
+			Price:             r.Price.String(),
+			AbovePromptTokens: r.AbovePromptTokens,
+		}
+	}
+
+	if price, ok := pricing.Price(model.DimensionPromptTokens, 0); ok {
+		p.PromptTokenPrice = price.String()
+	}
+	if price, ok := pricing.Price(model.DimensionCompletionTokens, 0); ok {
+		p.CompletionTokenPrice = price.String()
+	}
+
+	return p
+}
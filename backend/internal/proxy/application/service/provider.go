@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
 	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
@@ -21,29 +24,72 @@ type ProviderService interface {
 	ListProviders(ctx context.Context) (*dto.ListProvidersResponse, error)
 	CreateProvider(ctx context.Context, request dto.CreateProviderRequest) (*dto.CreateProviderResponse, error)
 	UpdateProviderTemplate(ctx context.Context, request dto.UpdateProviderTemplateRequest) error
+	PreviewTemplate(ctx context.Context, request dto.PreviewTemplateRequest) (*dto.PreviewTemplateResponse, error)
+	UpdateHealthProbe(ctx context.Context, request dto.UpdateHealthProbeRequest) error
+	RotateCredentialKeys(ctx context.Context) (*dto.RotateCredentialKeysResponse, error)
 	RemoveProvider(ctx context.Context, id string) error
+	UpdateProvider(ctx context.Context, request dto.UpdateProviderRequest) error
+	// ActivateProvider and DeactivateProvider also activate or deactivate
+	// every endpoint of the provider.
+	ActivateProvider(ctx context.Context, id string) error
+	DeactivateProvider(ctx context.Context, id string) error
 	AddModels(ctx context.Context, request dto.AddModelsRequest) error
+	UpdateModel(ctx context.Context, request dto.UpdateModelRequest) error
 	RemoveModel(ctx context.Context, request dto.RemoveModelRequest) error
 	AddEndpoints(ctx context.Context, request dto.AddEndpointsRequest) error
+	UpdateEndpoint(ctx context.Context, request dto.UpdateEndpointRequest) error
 	RemoveEndpoint(ctx context.Context, request dto.RemoveEndpointRequest) error
 	ActivateEndpoint(ctx context.Context, request dto.ActivateEndpointRequest) error
 	DeactivateEndpoint(ctx context.Context, request dto.DeactivateEndpointRequest) error
+	ResolveModel(ctx context.Context, request dto.ResolveModelRequest) (*dto.ResolveModelResponse, error)
+	ListKeys(ctx context.Context, providerID string) (*dto.ListKeysResponse, error)
+	AddKey(ctx context.Context, request dto.AddKeyRequest) (*dto.AddKeyResponse, error)
+	UpdateKey(ctx context.Context, request dto.UpdateKeyRequest) error
+	// RetireKey takes a key out of rotation for good; ReinstateKey returns a
+	// quarantined key to rotation.
+	RetireKey(ctx context.Context, request dto.KeyRequest) error
+	ReinstateKey(ctx context.Context, request dto.KeyRequest) error
+	QuarantineKey(ctx context.Context, request dto.KeyRequest) error
 }
 
+// DefaultChatEndpoint is the endpoint name used when a client addresses a
+// model without naming an endpoint.
+const DefaultChatEndpoint = "chat"
+
+var ErrModelNotFound = errors.New("model not found")
+
 var _ ProviderService = (*providerService)(nil)
 
 type providerService struct {
 	providerRepository ProviderRepository
 	uow                UnitOfWork
+	vault              CredentialVault
+	signers            SignerFactory
+	circuitInspector   CircuitInspector
+	cache              *ProviderCache
+	keys               *KeyTracker
 }
 
+// NewProviderService creates a provider service. circuitInspector may be nil
+// when the proxy client does not use circuit breakers. Every mutation
+// invalidates the provider in cache.
 func NewProviderService(
 	providerRepository ProviderRepository,
 	uow UnitOfWork,
+	vault CredentialVault,
+	signers SignerFactory,
+	circuitInspector CircuitInspector,
+	cache *ProviderCache,
+	keys *KeyTracker,
 ) ProviderService {
 	return &providerService{
 		providerRepository: providerRepository,
 		uow:                uow,
+		vault:              vault,
+		signers:            signers,
+		circuitInspector:   circuitInspector,
+		cache:              cache,
+		keys:               keys,
 	}
 }
 
@@ -75,17 +121,30 @@ func (s *providerService) ListProviders(ctx context.Context) (*dto.ListProviders
 }
 
 func (s *providerService) CreateProvider(ctx context.Context, request dto.CreateProviderRequest) (*dto.CreateProviderResponse, error) {
+	request, endpoints, err := applyPreset(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateTemplates(request.RequestTemplate, request.ResponseTemplate, request.SampleResponse); err != nil {
+		return nil, err
+	}
+
+	auth := authConfigFromDTO(request.Auth, provider.Credential{})
+	if err := auth.Type.ValidateSecret(request.Auth.Credential); err != nil {
+		return nil, err
+	}
+
+	credential, err := s.vault.Seal(request.Auth.Credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt credential: %w", err)
+	}
+	auth.Credential = credential
+
 	provider, err := provider.New(provider.Config{
 		Name:    request.Name,
 		BaseURL: request.BaseURL,
-		Auth: provider.AuthConfig{
-			Type:   provider.AuthTypeAPIKey, // just api key for now
-			Header: request.Auth.Header,
-			Prefix: request.Auth.Prefix,
-			Credential: provider.Credential{
-				Encrypted: request.Auth.Credential,
-			},
-		},
+		Auth:    auth,
 		Headers: request.Headers,
 		RequestTmpl: provider.Template{
 			Content: request.RequestTemplate,
@@ -93,40 +152,347 @@ func (s *providerService) CreateProvider(ctx context.Context, request dto.Create
 		ResponseTmpl: provider.Template{
 			Content: request.ResponseTemplate,
 		},
+		HealthProbe: provider.HealthProbe{
+			ModelKey: request.HealthProbe.ModelKey,
+			Prompt:   request.HealthProbe.Prompt,
+		},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.providerRepository.Save(ctx, provider)
+	for _, ep := range endpoints {
+		route, stream := endpointRoutesFromDTO(ep)
+		probe := optionalRouteFromDTO(ep.Probe)
+		if err := validateEndpointTemplates(&route, stream, probe); err != nil {
+			return nil, err
+		}
+		if err := provider.AddEndpoint(ep.Name, route, stream); err != nil {
+			return nil, err
+		}
+		if err := provider.UpdateEndpointProbe(ep.Name, probe); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		if err := recordInitialTemplateVersion(ctx, repoProvider.TemplateVersionRepository(), provider, request.Author); err != nil {
+			return err
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, provider)
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.cache.Invalidate(provider.ID().String())
 
 	return &dto.CreateProviderResponse{
 		Provider: s.mapDomainToDTO(provider),
 	}, nil
 }
 
+// UpdateProviderTemplate records the templates as a new version and makes it
+// serve all traffic.
 func (s *providerService) UpdateProviderTemplate(ctx context.Context, request dto.UpdateProviderTemplateRequest) error {
+	defer s.cache.Invalidate(request.ProviderID)
+
+	if err := validateTemplates(request.RequestTemplate, request.ResponseTemplate, request.SampleResponse); err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		version, err := recordTemplateVersion(ctx, repoProvider.TemplateVersionRepository(), pvd,
+			provider.Template{Content: request.RequestTemplate},
+			provider.Template{Content: request.ResponseTemplate},
+			request.Author)
+		if err != nil {
+			return err
+		}
+
+		if err := pvd.ApplyTemplateVersion(version); err != nil {
+			return err
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+}
+
+func (s *providerService) PreviewTemplate(ctx context.Context, request dto.PreviewTemplateRequest) (*dto.PreviewTemplateResponse, error) {
+	pvd, err := s.providerRepository.GetByID(ctx, request.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	providerDTO := s.mapDomainToDTO(pvd)
+	if request.RequestTemplate != "" {
+		providerDTO.RequestTemplate = request.RequestTemplate
+	}
+	if request.ResponseTemplate != "" {
+		providerDTO.ResponseTemplate = request.ResponseTemplate
+	}
+
+	templates, err := compileTemplates(providerDTO)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	response := &dto.PreviewTemplateResponse{}
+	if request.Request != nil {
+		req := *request.Request
+		if req.Endpoint == "" {
+			req.Endpoint = DefaultChatEndpoint
+		}
+
+		upstreamReq, err := buildUpstreamRequest(providerDTO, templates.request, req)
+		if err == nil {
+			err = s.signPreview(ctx, providerDTO.ID, providerDTO.AuthConfig, &upstreamReq)
+		}
+		if err != nil {
+			response.RequestError = err.Error()
+		} else {
+			response.Method = upstreamReq.Method
+			response.Target = upstreamReq.Target
+			response.Headers = upstreamReq.Headers
+			response.RequestBody = upstreamReq.Body
+			if upstreamReq.Body != nil && !json.Valid(upstreamReq.Body) {
+				response.RequestError = "request template renders invalid JSON"
+			}
+		}
+	}
+
+	if len(request.ProviderResponse) > 0 {
+		rendered, err := renderResponse(templates.response, request.ProviderResponse)
+		if err != nil {
+			response.ResponseError = err.Error()
+		} else {
+			response.Response = rendered
+		}
+	}
+
+	return response, nil
+}
+
+// signPreview applies header and query auth with the credential redacted,
+// because the preview is shown to the caller. Token and signature auth is
+// only computed at dispatch and is left out.
+func (s *providerService) signPreview(ctx context.Context, providerID string, auth dto.AuthConfig, request *ProxyRequest) error {
+	if !provider.AuthType(auth.Type).IsStatic() {
+		return nil
+	}
+
+	signer, err := s.signers.Signer(providerID, auth, RedactedCredential)
+	if err != nil {
+		return err
+	}
+	return signer.Sign(ctx, request)
+}
+
+func (s *providerService) UpdateHealthProbe(ctx context.Context, request dto.UpdateHealthProbeRequest) error {
+	defer s.cache.Invalidate(request.ProviderID)
+
 	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
 		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
 		if err != nil {
 			return err
 		}
 
-		pvd.UpdateRequestTemplate(provider.Template{Content: request.RequestTemplate})
-		pvd.UpdateResponseTemplate(provider.Template{Content: request.ResponseTemplate})
+		if err := pvd.UpdateHealthProbe(provider.HealthProbe{
+			ModelKey: request.ModelKey,
+			Prompt:   request.Prompt,
+		}); err != nil {
+			return err
+		}
 
 		return repoProvider.ProviderRepository().Save(ctx, pvd)
 	})
 }
 
+// RotateCredentialKeys rewraps every credential that is not sealed under the
+// current master key, including legacy plaintext credentials and the keys
+// of credential pools.
+func (s *providerService) RotateCredentialKeys(ctx context.Context) (*dto.RotateCredentialKeysResponse, error) {
+	providers, err := s.providerRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.RotateCredentialKeysResponse{KeyID: s.vault.CurrentKeyID()}
+	var errs []error
+	for _, p := range providers {
+		if !needsRewrap(p, response.KeyID) {
+			continue
+		}
+
+		err := s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+			pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, p.ID().String())
+			if err != nil {
+				return err
+			}
+
+			if pvd.Auth().Credential.KeyID != response.KeyID {
+				credential, err := s.vault.Rewrap(pvd.Auth().Credential)
+				if err != nil {
+					return err
+				}
+
+				if err := pvd.UpdateCredential(credential); err != nil {
+					return err
+				}
+			}
+
+			for _, key := range pvd.Keys() {
+				if key.Credential.KeyID == response.KeyID {
+					continue
+				}
+
+				credential, err := s.vault.Rewrap(key.Credential)
+				if err != nil {
+					return fmt.Errorf("key %s: %w", key.Name, err)
+				}
+
+				if err := pvd.UpdateKeyCredential(key.ID, credential); err != nil {
+					return err
+				}
+			}
+
+			return repoProvider.ProviderRepository().Save(ctx, pvd)
+		})
+		s.cache.Invalidate(p.ID().String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("provider %s: %w", p.Name(), err))
+			continue
+		}
+		response.Rotated++
+	}
+
+	return response, errors.Join(errs...)
+}
+
+// needsRewrap reports whether any of the provider's credentials is sealed
+// under a master key other than keyID.
+func needsRewrap(p *provider.Provider, keyID string) bool {
+	if p.Auth().Credential.KeyID != keyID {
+		return true
+	}
+
+	for _, key := range p.Keys() {
+		if key.Credential.KeyID != keyID {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *providerService) RemoveProvider(ctx context.Context, provider_id string) error {
+	defer s.cache.Invalidate(provider_id)
+	defer s.signers.Forget(provider_id)
 	return s.providerRepository.Delete(ctx, provider_id)
 }
 
+// UpdateProvider applies a partial update. A new credential is sealed
+// before the transaction starts.
+func (s *providerService) UpdateProvider(ctx context.Context, request dto.UpdateProviderRequest) error {
+	defer s.cache.Invalidate(request.ProviderID)
+	if request.Auth != nil {
+		defer s.signers.Forget(request.ProviderID)
+	}
+
+	var credential *provider.Credential
+	if request.Auth != nil && request.Auth.Credential != "" {
+		authType := provider.AuthType(request.Auth.Type)
+		if authType == "" {
+			authType = provider.AuthTypeAPIKey
+		}
+		if err := authType.ValidateSecret(request.Auth.Credential); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		sealed, err := s.vault.Seal(request.Auth.Credential)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt credential: %w", err)
+		}
+		credential = &sealed
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		if request.BaseURL != nil || request.Headers != nil {
+			baseURL, headers := pvd.BaseURL(), pvd.Headers()
+			if request.BaseURL != nil {
+				baseURL = *request.BaseURL
+			}
+			if request.Headers != nil {
+				headers = request.Headers
+			}
+
+			if err := pvd.UpdateConnection(baseURL, headers); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+			}
+		}
+
+		if request.Auth != nil {
+			settings := *request.Auth
+			if settings.Type == "" {
+				settings.Type = string(pvd.Auth().Type)
+			}
+			auth := authConfigFromDTO(settings, pvd.Auth().Credential)
+			if credential != nil {
+				auth.Credential = *credential
+			}
+
+			if err := pvd.UpdateAuth(auth); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+			}
+		}
+
+		if request.KeyStrategy != nil {
+			if err := pvd.UpdateKeyStrategy(provider.KeyStrategy(*request.KeyStrategy)); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+			}
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+}
+
+func (s *providerService) ActivateProvider(ctx context.Context, id string) error {
+	return s.updateStatus(ctx, id, (*provider.Provider).Activate)
+}
+
+func (s *providerService) DeactivateProvider(ctx context.Context, id string) error {
+	return s.updateStatus(ctx, id, (*provider.Provider).Deactivate)
+}
+
+func (s *providerService) updateStatus(ctx context.Context, id string, update func(*provider.Provider) error) error {
+	defer s.cache.Invalidate(id)
+
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := update(pvd); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+}
+
 func (s *providerService) AddModels(ctx context.Context, request dto.AddModelsRequest) error {
+	defer s.cache.Invalidate(request.ProviderID)
+
 	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
 		provider, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
 		if err != nil {
@@ -135,24 +501,27 @@ func (s *providerService) AddModels(ctx context.Context, request dto.AddModelsRe
 
 		var errs []error
 		for _, mod := range request.Models {
+			pricing, err := pricingFromDTO(mod.Pricing)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("model %s: %w", mod.Key, err))
+				continue
+			}
+
 			if _, err := provider.AddModel(
 				mod.Name,
 				mod.Key,
 				mod.Description,
 				model.Capabilities{
-					FunctionCalling: mod.Capabilities.FunctionCalling,
-					Streaming:       mod.Capabilities.Streaming,
+					FunctionCalling:  mod.Capabilities.FunctionCalling,
+					Streaming:        mod.Capabilities.Streaming,
+					StructuredOutput: mod.Capabilities.StructuredOutput,
 				},
 				model.Limits{
-					ContextWindow:   mod.Limits.ContextWindow,
-					MaxOutputTokens: mod.Limits.MaxOutputTokens,
-				},
-				model.TokenPricing{
-					PromptTokenPrice:     mod.Pricing.PromptTokenPrice,
-					CompletionTokenPrice: mod.Pricing.CompletionTokenPrice,
-					Currency:             mod.Pricing.Currency,
-					Unit:                 model.UnitPer1000Tokens, // just 1000 for now
+					ContextWindow:    mod.Limits.ContextWindow,
+					MaxOutputTokens:  mod.Limits.MaxOutputTokens,
+					MaxStopSequences: mod.Limits.MaxStopSequences,
 				},
+				pricing,
 			); err != nil {
 				errs = append(errs, err)
 			}
@@ -166,7 +535,66 @@ func (s *providerService) AddModels(ctx context.Context, request dto.AddModelsRe
 	})
 }
 
+func (s *providerService) UpdateModel(ctx context.Context, request dto.UpdateModelRequest) error {
+	defer s.cache.Invalidate(request.ProviderID)
+
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		var current *model.Model
+		for _, m := range pvd.Models() {
+			if m.ID().String() == request.Model || m.Key() == request.Model {
+				current = m
+				break
+			}
+		}
+		if current == nil {
+			return fmt.Errorf("%w: %s", ErrModelNotFound, request.Model)
+		}
+
+		name, description := current.Name(), current.Description()
+		capabilities, limits, pricing := current.Capabilities(), current.Limits(), current.Pricing()
+		if request.Name != nil {
+			name = *request.Name
+		}
+		if request.Description != nil {
+			description = *request.Description
+		}
+		if c := request.Capabilities; c != nil {
+			capabilities = model.Capabilities{
+				FunctionCalling:  c.FunctionCalling,
+				Streaming:        c.Streaming,
+				StructuredOutput: c.StructuredOutput,
+			}
+		}
+		if l := request.Limits; l != nil {
+			limits = model.Limits{
+				ContextWindow:    l.ContextWindow,
+				MaxOutputTokens:  l.MaxOutputTokens,
+				MaxStopSequences: l.MaxStopSequences,
+			}
+		}
+		if p := request.Pricing; p != nil {
+			var err error
+			if pricing, err = pricingFromDTO(*p); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+			}
+		}
+
+		if err := pvd.UpdateModel(current.Key(), name, description, capabilities, limits, pricing); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+}
+
 func (s *providerService) RemoveModel(ctx context.Context, request dto.RemoveModelRequest) error {
+	defer s.cache.Invalidate(request.ProviderID)
+
 	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
 		provider, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
 		if err != nil {
@@ -182,6 +610,8 @@ func (s *providerService) RemoveModel(ctx context.Context, request dto.RemoveMod
 }
 
 func (s *providerService) AddEndpoints(ctx context.Context, req dto.AddEndpointsRequest) error {
+	defer s.cache.Invalidate(req.ProviderID)
+
 	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
 		provider, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, req.ProviderID)
 		if err != nil {
@@ -190,8 +620,18 @@ func (s *providerService) AddEndpoints(ctx context.Context, req dto.AddEndpoints
 
 		var errs []error
 		for _, ep := range req.Endpoints {
-			if err := provider.AddEndpoint(ep.Name, ep.Path); err != nil {
+			route, stream := endpointRoutesFromDTO(ep)
+			probe := optionalRouteFromDTO(ep.Probe)
+			if err := validateEndpointTemplates(&route, stream, probe); err != nil {
 				errs = append(errs, err)
+				continue
+			}
+			if err := provider.AddEndpoint(ep.Name, route, stream); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidRequest, err))
+				continue
+			}
+			if err := provider.UpdateEndpointProbe(ep.Name, probe); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidRequest, err))
 			}
 		}
 
@@ -204,7 +644,58 @@ func (s *providerService) AddEndpoints(ctx context.Context, req dto.AddEndpoints
 
 }
 
+func (s *providerService) UpdateEndpoint(ctx context.Context, req dto.UpdateEndpointRequest) error {
+	defer s.cache.Invalidate(req.ProviderID)
+
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		provider, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, req.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		if !provider.HasEndpoint(req.EndpointURL) {
+			return fmt.Errorf("%w: endpoint not found", ErrInvalidRequest)
+		}
+		current := findEndpoint(provider, req.EndpointURL)
+
+		route := current.Route(false)
+		if req.Method != nil {
+			route.Method = *req.Method
+		}
+		if req.Path != nil {
+			route.Path = *req.Path
+		}
+		if req.Query != nil {
+			route.Query = req.Query
+		}
+
+		stream := current.Stream
+		if req.Stream != nil {
+			stream = optionalRouteFromDTO(req.Stream)
+		}
+
+		probe := current.Probe
+		if req.Probe != nil {
+			probe = optionalRouteFromDTO(req.Probe)
+		}
+
+		if err := validateEndpointTemplates(&route, stream, probe); err != nil {
+			return err
+		}
+		if err := provider.UpdateEndpointRoute(req.EndpointURL, route, stream); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		if err := provider.UpdateEndpointProbe(req.EndpointURL, probe); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, provider)
+	})
+}
+
 func (s *providerService) RemoveEndpoint(ctx context.Context, req dto.RemoveEndpointRequest) error {
+	defer s.cache.Invalidate(req.ProviderID)
+
 	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
 		provider, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, req.ProviderID)
 		if err != nil {
@@ -220,6 +711,8 @@ func (s *providerService) RemoveEndpoint(ctx context.Context, req dto.RemoveEndp
 }
 
 func (s *providerService) ActivateEndpoint(ctx context.Context, req dto.ActivateEndpointRequest) error {
+	defer s.cache.Invalidate(req.ProviderID)
+
 	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
 		provider, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, req.ProviderID)
 		if err != nil {
@@ -235,6 +728,8 @@ func (s *providerService) ActivateEndpoint(ctx context.Context, req dto.Activate
 }
 
 func (s *providerService) DeactivateEndpoint(ctx context.Context, req dto.DeactivateEndpointRequest) error {
+	defer s.cache.Invalidate(req.ProviderID)
+
 	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
 		provider, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, req.ProviderID)
 		if err != nil {
@@ -249,6 +744,142 @@ func (s *providerService) DeactivateEndpoint(ctx context.Context, req dto.Deacti
 	})
 }
 
+func (s *providerService) ListKeys(ctx context.Context, providerID string) (*dto.ListKeysResponse, error) {
+	pvd, err := s.providerRepository.GetByID(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ListKeysResponse{
+		KeyStrategy: string(pvd.KeyStrategy()),
+		Keys:        s.keysToDTO(pvd),
+	}, nil
+}
+
+// AddKey seals the credential and adds it to the pool. The key serves
+// requests as soon as the provider is reloaded from cache.
+func (s *providerService) AddKey(ctx context.Context, request dto.AddKeyRequest) (*dto.AddKeyResponse, error) {
+	defer s.cache.Invalidate(request.ProviderID)
+
+	if request.Weight == 0 {
+		request.Weight = 1
+	}
+
+	var added provider.Key
+	err := s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		if err := pvd.Auth().Type.ValidateSecret(request.Credential); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		credential, err := s.vault.Seal(request.Credential)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt credential: %w", err)
+		}
+
+		id, err := pvd.AddKey(request.Name, credential, request.Weight)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		for _, key := range pvd.Keys() {
+			if key.ID == id {
+				added = key
+			}
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AddKeyResponse{ProviderKey: keyToDTO(added, KeyState{})}, nil
+}
+
+func (s *providerService) UpdateKey(ctx context.Context, request dto.UpdateKeyRequest) error {
+	return s.updateStatus(ctx, request.ProviderID, func(pvd *provider.Provider) error {
+		return pvd.UpdateKeyWeight(provider.HydrateKeyID(request.KeyID), request.Weight)
+	})
+}
+
+func (s *providerService) RetireKey(ctx context.Context, request dto.KeyRequest) error {
+	err := s.updateStatus(ctx, request.ProviderID, func(pvd *provider.Provider) error {
+		return pvd.RetireKey(provider.HydrateKeyID(request.KeyID))
+	})
+	if err == nil {
+		s.keys.Forget(request.KeyID)
+		s.signers.Forget(request.ProviderID)
+	}
+	return err
+}
+
+func (s *providerService) ReinstateKey(ctx context.Context, request dto.KeyRequest) error {
+	return s.updateStatus(ctx, request.ProviderID, func(pvd *provider.Provider) error {
+		return pvd.ReinstateKey(provider.HydrateKeyID(request.KeyID))
+	})
+}
+
+func (s *providerService) QuarantineKey(ctx context.Context, request dto.KeyRequest) error {
+	err := s.updateStatus(ctx, request.ProviderID, func(pvd *provider.Provider) error {
+		return pvd.QuarantineKey(provider.HydrateKeyID(request.KeyID))
+	})
+	if err == nil {
+		s.signers.Forget(request.ProviderID)
+	}
+	return err
+}
+
+// ResolveModel finds the provider serving a model among the cached
+// providers. A name qualified with a provider, such as "openai/gpt-4o", goes
+// to that provider first; otherwise providers are tried by name, so the
+// choice does not depend on storage order.
+func (s *providerService) ResolveModel(ctx context.Context, request dto.ResolveModelRequest) (*dto.ResolveModelResponse, error) {
+	providers, err := s.cache.providers(ctx, s.ListProviders)
+	if err != nil {
+		return nil, err
+	}
+
+	if providerName, modelKey, ok := strings.Cut(request.Model, "/"); ok {
+		for _, pvd := range providers {
+			if strings.EqualFold(pvd.Name, providerName) {
+				if resolved, ok := resolveModel(pvd, modelKey); ok {
+					return resolved, nil
+				}
+			}
+		}
+	}
+
+	for _, pvd := range providers {
+		if resolved, ok := resolveModel(pvd, request.Model); ok {
+			return resolved, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrModelNotFound, request.Model)
+}
+
+func resolveModel(pvd dto.Provider, modelKey string) (*dto.ResolveModelResponse, bool) {
+	if pvd.Status != provider.StatusActive.String() {
+		return nil, false
+	}
+
+	if _, ok := pvd.Models[modelKey]; !ok {
+		return nil, false
+	}
+
+	return &dto.ResolveModelResponse{
+		ProviderID:   pvd.ID,
+		ProviderName: pvd.Name,
+		ModelKey:     modelKey,
+		Endpoint:     DefaultChatEndpoint,
+	}, true
+}
+
 func (s *providerService) mapDomainToDTO(provider *provider.Provider) dto.Provider {
 	dtoModels := make(map[string]dto.Model, len(provider.Models()))
 	dtoEndpoints := make(map[string]dto.Endpoint, len(provider.Endpoints()))
@@ -258,31 +889,32 @@ func (s *providerService) mapDomainToDTO(provider *provider.Provider) dto.Provid
 			Key:         model.Key(),
 			Description: model.Description(),
 			Capabilities: dto.Capabilities{
-				FunctionCalling: model.Capabilities().FunctionCalling,
-				Streaming:       model.Capabilities().Streaming,
+				FunctionCalling:  model.Capabilities().FunctionCalling,
+				Streaming:        model.Capabilities().Streaming,
+				StructuredOutput: model.Capabilities().StructuredOutput,
 			},
 			Limits: dto.Limits{
-				ContextWindow:   model.Limits().ContextWindow,
-				MaxOutputTokens: model.Limits().MaxOutputTokens,
-			},
-			Pricing: dto.Pricing{
-				PromptTokenPrice:     model.Pricing().PromptTokenPrice,
-				CompletionTokenPrice: model.Pricing().CompletionTokenPrice,
-				Currency:             model.Pricing().Currency,
-				Unit:                 model.Pricing().Unit.String(),
+				ContextWindow:    model.Limits().ContextWindow,
+				MaxOutputTokens:  model.Limits().MaxOutputTokens,
+				MaxStopSequences: model.Limits().MaxStopSequences,
 			},
+			Pricing: pricingToDTO(model.Pricing()),
 		}
 	}
 
 	for _, ep := range provider.Endpoints() {
-		dtoEndpoints[ep.Name] = dto.Endpoint{
-			Name:            ep.Name,
-			Path:            ep.Path,
-			Status:          string(ep.Status),
-			Health:          string(ep.Health),
-			LastHealthCheck: ep.LastHealthCheck,
+		dtoEndpoints[ep.Name] = endpointToDTO(ep)
+	}
+	var circuit dto.CircuitState
+	if s.circuitInspector != nil {
+		state := s.circuitInspector.CircuitState(provider.BaseURL())
+		circuit = dto.CircuitState{
+			State:               state.State,
+			ConsecutiveFailures: state.ConsecutiveFailures,
+			OpenedAt:            state.OpenedAt,
 		}
 	}
+
 	return dto.Provider{
 		ID:               provider.ID().String(),
 		Name:             provider.Name(),
@@ -292,12 +924,25 @@ func (s *providerService) mapDomainToDTO(provider *provider.Provider) dto.Provid
 		Endpoints:        dtoEndpoints,
 		RequestTemplate:  provider.RequestTemplate().Content,
 		ResponseTemplate: provider.ResponseTemplate().Content,
+		TemplateVersion:  provider.TemplateVersion(),
+		StagedTemplate:   mapStagedTemplateToDTO(provider),
 		Headers:          provider.Headers(),
-		AuthConfig: dto.AuthConfig{
-			Type:       string(provider.Auth().Type),
-			Header:     provider.Auth().Header,
-			Prefix:     provider.Auth().Prefix,
-			Credential: provider.Auth().Credential.Encrypted,
+		HealthProbe: dto.HealthProbe{
+			ModelKey: provider.HealthProbe().ModelKey,
+			Prompt:   provider.HealthProbe().Prompt,
 		},
+		Circuit:     circuit,
+		Keys:        s.keysToDTO(provider),
+		KeyStrategy: string(provider.KeyStrategy()),
+		UpdatedAt:   provider.UpdatedAt(),
+		AuthConfig:  authConfigToDTO(provider.Auth()),
+	}
+}
+
+func (s *providerService) keysToDTO(provider *provider.Provider) []dto.ProviderKey {
+	keys := make([]dto.ProviderKey, len(provider.Keys()))
+	for i, key := range provider.Keys() {
+		keys[i] = keyToDTO(key, s.keys.State(key.ID.String()))
 	}
+	return keys
 }
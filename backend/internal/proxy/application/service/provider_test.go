@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider/model"
+)
+
+// listedProviders serves a fixed provider list and counts the loads.
+type listedProviders struct {
+	ProviderRepository
+	providers []*provider.Provider
+	loads     int
+}
+
+func (r *listedProviders) GetAll(context.Context) ([]*provider.Provider, error) {
+	r.loads++
+	return r.providers, nil
+}
+
+func testProvider(name string, status provider.Status, modelKeys ...string) *provider.Provider {
+	models := make([]*model.Model, len(modelKeys))
+	for i, key := range modelKeys {
+		models[i] = model.Hydrate(model.HydrateData{ID: model.NewID(), Name: key, Key: key})
+	}
+	return provider.Hydrate(provider.HydrateData{ID: provider.NewID(), Name: name, Status: status, Models: models})
+}
+
+func TestResolveModel(t *testing.T) {
+	// Stored out of name order, as rows may come back in any order.
+	repo := &listedProviders{providers: []*provider.Provider{
+		testProvider("openrouter", provider.StatusActive, "gpt-4o", "openai/gpt-4o"),
+		testProvider("disabled", provider.StatusInactive, "gpt-4o", "llama-3"),
+		testProvider("azure", provider.StatusActive, "gpt-4o"),
+		testProvider("openai", provider.StatusActive, "gpt-4o"),
+	}}
+	s := &providerService{
+		providerRepository: repo,
+		cache:              NewProviderCache(ProviderCacheConfig{}),
+		keys:               NewKeyTracker(KeyTrackerConfig{}),
+	}
+
+	tests := []struct {
+		name         string
+		model        string
+		wantProvider string
+		wantModelKey string
+	}{
+		{"First provider by name", "gpt-4o", "azure", "gpt-4o"},
+		{"Qualified with a provider", "openai/gpt-4o", "openai", "gpt-4o"},
+		{"Qualified name as a model key", "OpenRouter/openai/gpt-4o", "openrouter", "openai/gpt-4o"},
+		{"Inactive providers are skipped", "llama-3", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := s.ResolveModel(context.Background(), dto.ResolveModelRequest{Model: tt.model})
+			if tt.wantProvider == "" {
+				if !errors.Is(err, ErrModelNotFound) {
+					t.Errorf("Expected %v, got %v", ErrModelNotFound, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if resolved.ProviderName != tt.wantProvider || resolved.ModelKey != tt.wantModelKey {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.wantProvider, tt.wantModelKey, resolved.ProviderName, resolved.ModelKey)
+			}
+		})
+	}
+
+	if repo.loads != 1 {
+		t.Errorf("Expected providers to be loaded once, got %d", repo.loads)
+	}
+
+	s.cache.Invalidate(repo.providers[0].ID().String())
+	if _, err := s.ResolveModel(context.Background(), dto.ResolveModelRequest{Model: "gpt-4o"}); err != nil || repo.loads != 2 {
+		t.Errorf("Expected an invalidation to reload providers, got %d loads (%v)", repo.loads, err)
+	}
+}
@@ -2,231 +2,545 @@ package service
 
 import (
 	"bufio"
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
 	"strings"
 	"text/template"
 
 	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider/model"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/usage"
+	"github.com/basetable/basetable/backend/internal/shared/log"
 )
 
 type ProxyService interface {
 	ProxyRequest(ctx context.Context, request dto.Request) (*dto.Response, error)
 	ProxyRequestStream(ctx context.Context, request dto.Request) (<-chan *dto.Response, error)
+	Tokenize(ctx context.Context, request dto.Request) (*dto.TokenizeResponse, error)
 }
 
 type proxyService struct {
 	providerService ProviderService
+	routeService    RouteService
 	proxyClient     ProxyClient
+	billingGateway  BillingGateway
+	vault           CredentialVault
+	signers         SignerFactory
+	cache           *ProviderCache
+	keys            *KeyTracker
+	tokenizers      Tokenizers
+	contextConfig   ContextConfig
+	usageRepository repository.UsageRepository
+	logger          log.Logger
 }
 
-func NewProxyService(providerService ProviderService, proxyClient ProxyClient) ProxyService {
+func NewProxyService(
+	providerService ProviderService,
+	routeService RouteService,
+	proxyClient ProxyClient,
+	billingGateway BillingGateway,
+	vault CredentialVault,
+	signers SignerFactory,
+	cache *ProviderCache,
+	keys *KeyTracker,
+	tokenizers Tokenizers,
+	contextConfig ContextConfig,
+	usageRepository repository.UsageRepository,
+	logger log.Logger,
+) ProxyService {
+	if contextConfig.SummaryMaxTokens <= 0 {
+		contextConfig.SummaryMaxTokens = DefaultSummaryMaxTokens
+	}
+
 	return &proxyService{
 		providerService: providerService,
+		routeService:    routeService,
 		proxyClient:     proxyClient,
+		billingGateway:  billingGateway,
+		vault:           vault,
+		signers:         signers,
+		cache:           cache,
+		keys:            keys,
+		tokenizers:      tokenizers,
+		contextConfig:   contextConfig,
+		usageRepository: usageRepository,
+		logger:          logger,
 	}
 }
 
-func (s *proxyService) ProxyRequest(ctx context.Context, request dto.Request) (*dto.Response, error) {
-	providerDTO, err := s.providerService.GetProvider(ctx, request.ProviderID)
+// ErrTargetUnavailable is returned when a provider cannot serve a request as
+// configured, so the next routing target should be tried.
+var ErrTargetUnavailable = errors.New("target unavailable")
+
+// target is a request resolved against its provider configuration.
+type target struct {
+	provider dto.Provider
+	// key is the pool key the request is signed with, or empty for the
+	// provider's auth credential. tried holds every pool key used so far.
+	key       string
+	tried     []string
+	signer    Signer
+	model     dto.Model
+	pricing   model.Pricing
+	templates *providerTemplates
+}
+
+func (s *proxyService) resolveTarget(ctx context.Context, request dto.Request) (*target, error) {
+	providerDTO, templates, err := s.cache.provider(ctx, request.ProviderID, s.providerService.GetProvider)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrTargetUnavailable, err)
 	}
 
 	if providerDTO.Status != "active" {
-		return nil, fmt.Errorf("provider is not active")
+		return nil, fmt.Errorf("%w: provider is not active", ErrTargetUnavailable)
 	}
 
 	// if model is not supported, return error
-	if _, ok := providerDTO.Models[request.ModelKey]; !ok {
-		return nil, fmt.Errorf("model %s is not supported by provider", request.ModelKey)
+	model, ok := providerDTO.Models[request.ModelKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: model %s is not supported by provider", ErrTargetUnavailable, request.ModelKey)
 	}
 
-	// if endpoint is not supported or inactive, return error
-	if ep, ok := providerDTO.Endpoints[request.Endpoint]; !ok || ep.Status == "inactive" {
-		return nil, fmt.Errorf("endpoint %s is not available", request.Endpoint)
+	if request.Stream && !model.Capabilities.Streaming {
+		return nil, fmt.Errorf("%w: streaming is not supported by model %s", ErrTargetUnavailable, request.ModelKey)
 	}
 
-	funcMap := template.FuncMap{
-		"json": func(v interface{}) string {
-			b, _ := json.Marshal(v)
-			return string(b)
-		},
-	}
-	// for now just convert template directly
-	requestTmpl, err := template.
-		New("request").
-		Funcs(funcMap).
-		Parse(providerDTO.RequestTemplate)
-	if err != nil {
+	if err := checkModelLimits(request.Params, model); err != nil {
 		return nil, err
 	}
 
-	responseTmpl, err := template.
-		New("response").
-		Funcs(funcMap).
-		Parse(providerDTO.ResponseTemplate)
+	// if endpoint is not supported, inactive or failing its health checks, return error
+	ep, ok := providerDTO.Endpoints[request.Endpoint]
+	if !ok || ep.Status == "inactive" {
+		return nil, fmt.Errorf("%w: endpoint %s is not available", ErrTargetUnavailable, request.Endpoint)
+	}
+	if ep.Health == "unhealthy" {
+		return nil, fmt.Errorf("%w: endpoint %s is unhealthy", ErrTargetUnavailable, request.Endpoint)
+	}
+
+	pricing, err := pricingFromDTO(model.Pricing)
 	if err != nil {
+		return nil, fmt.Errorf("%w: model %s has invalid pricing: %w", ErrTargetUnavailable, request.ModelKey, err)
+	}
+
+	tgt := &target{
+		provider:  providerDTO,
+		model:     model,
+		pricing:   pricing,
+		templates: templates.pick(),
+	}
+	if err := s.useKey(tgt); err != nil {
 		return nil, err
 	}
 
-	var requestBody bytes.Buffer
-	err = requestTmpl.Execute(&requestBody, request)
+	return tgt, nil
+}
+
+// useKey signs the target's requests with the next key of the provider's
+// pool, or with the auth credential while the pool has no active keys.
+func (s *proxyService) useKey(tgt *target) error {
+	auth := tgt.provider.AuthConfig
+	if hasActiveKey(tgt.provider.Keys) {
+		key, ok := s.keys.pick(tgt.provider.Keys, tgt.provider.KeyStrategy, tgt.tried)
+		if !ok {
+			return fmt.Errorf("%w: no key of provider %s is available", ErrTargetUnavailable, tgt.provider.Name)
+		}
+
+		auth = keyAuth(auth, key)
+		tgt.key = key.ID
+		tgt.tried = append(tgt.tried, key.ID)
+	}
+
+	signer, err := openSigner(s.vault, s.signers, tgt.provider.ID, auth)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	tgt.signer = signer
+	return nil
+}
+
+// sendWithKeys sends a request, moving on to another key of the pool when
+// the provider rate limits or rejects the current one. Rate limited keys
+// rest for the provider's Retry-After and rejected keys are quarantined,
+// unless a fresh token for the key is accepted. Once no key is left the last
+// failure is returned.
+func (s *proxyService) sendWithKeys(ctx context.Context, tgt *target, request ProxyRequest, send func(signer Signer, request ProxyRequest) error) error {
+	refreshed := false
+	for {
+		// A pool key that is rate limited should cool down rather than be
+		// retried by the client.
+		request.ReturnRateLimits = tgt.key != ""
+		err := send(tgt.signer, request)
+
+		var upstreamErr *UpstreamError
+		if !errors.As(err, &upstreamErr) {
+			return err
+		}
+
+		// A token the provider revoked before it expired is refetched once
+		// before the key is given up on.
+		if refreshable, ok := tgt.signer.(RefreshableSigner); ok && upstreamErr.StatusCode == http.StatusUnauthorized && !refreshed {
+			refreshable.Invalidate()
+			refreshed = true
+			continue
+		}
+
+		if tgt.key == "" {
+			return err
+		}
 
-	target := fmt.Sprintf("%s/%s", providerDTO.BaseURL, providerDTO.Endpoints[request.Endpoint].Path)
+		switch upstreamErr.StatusCode {
+		case http.StatusTooManyRequests:
+			s.keys.limited(tgt.key, upstreamErr.RetryAfter)
+		case http.StatusUnauthorized:
+			// The key may already have been quarantined or retired by a
+			// concurrent request; any other failure leaves it in rotation.
+			quarantineErr := s.providerService.QuarantineKey(context.WithoutCancel(ctx), dto.KeyRequest{
+				ProviderID: tgt.provider.ID,
+				KeyID:      tgt.key,
+			})
+			if quarantineErr != nil && !provider.IsKeyStatusError(quarantineErr) && s.logger != nil {
+				s.logger.Errorf("Failed to quarantine key %s of provider %s: %v", tgt.key, tgt.provider.Name, quarantineErr)
+			}
+		default:
+			return err
+		}
 
-	// Build auth header value with optional prefix
-	authValue := providerDTO.AuthConfig.Credential
-	if providerDTO.AuthConfig.Prefix != "" {
-		authValue = fmt.Sprintf("%s %s", providerDTO.AuthConfig.Prefix, providerDTO.AuthConfig.Credential)
+		if s.useKey(tgt) != nil {
+			return err
+		}
+		refreshed = false
 	}
+}
 
-	// Build headers map starting with defaults
-	headers := map[string]string{
-		"Content-Type": "application/json",
+// prepareRequest validates the parts of a request that do not depend on the
+// target it is sent to.
+func prepareRequest(request dto.Request) (dto.Request, *responseFormat, error) {
+	if err := validateGenerationParams(request.Params); err != nil {
+		return dto.Request{}, nil, err
 	}
 
-	// Add extra headers from provider config
-	for k, v := range providerDTO.Headers {
-		headers[k] = v
+	tools, err := prepareTools(request.Tools)
+	if err != nil {
+		return dto.Request{}, nil, err
 	}
+	request.Tools = tools
 
-	// Add auth header
-	headers[providerDTO.AuthConfig.Header] = authValue
+	format, err := newResponseFormat(request.ResponseFormat)
+	if err != nil {
+		return dto.Request{}, nil, err
+	}
 
-	resp, err := s.proxyClient.ProxyRequest(ctx, ProxyRequest{
-		Target:  target,
-		Method:  "POST",
-		Headers: headers,
-		Body:    requestBody.Bytes(),
-	})
+	if strategy := request.ContextManagement.Strategy; strategy != "" && !strategy.IsValid() {
+		return dto.Request{}, nil, fmt.Errorf("%w: unknown context strategy %q", ErrInvalidRequest, strategy)
+	}
+
+	return request, format, nil
+}
+
+// candidates expands a request into one request per routing target. Requests
+// that already name a provider are sent as is.
+func (s *proxyService) candidates(ctx context.Context, request dto.Request) ([]dto.Request, error) {
+	if request.ProviderID != "" {
+		return []dto.Request{request}, nil
+	}
+
+	targets, err := s.routeService.ResolveTargets(ctx, request.Model)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if the response status code indicates an error
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("provider returned error status: %d", resp.StatusCode)
+	candidates := make([]dto.Request, 0, len(targets))
+	for _, t := range targets {
+		candidate := request
+		candidate.ProviderID = t.ProviderID
+		candidate.ModelKey = t.ModelKey
+		candidate.Endpoint = t.Endpoint
+		candidates = append(candidates, candidate)
 	}
 
-	// First decode the provider response JSON into a structured format
-	var providerResponse any
-	if err := json.Unmarshal(resp.Body, &providerResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse provider response JSON: %w", err)
+	return candidates, nil
+}
+
+// canFailover reports whether a failed attempt should fall through to the
+// next routing target.
+func canFailover(err error) bool {
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return upstreamErr.Retryable()
 	}
+	return errors.Is(err, ErrTargetUnavailable)
+}
 
-	// Convert the provider response back to canonical format using the response template
-	var responseBody bytes.Buffer
-	err = responseTmpl.Execute(&responseBody, providerResponse)
+func (s *proxyService) ProxyRequest(ctx context.Context, request dto.Request) (*dto.Response, error) {
+	request = ensureRequestID(request)
+	prepared, format, err := prepareRequest(request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute response template: %w", err)
+		return nil, s.rejected(ctx, request, err)
 	}
+	request = prepared
 
-	// Parse the template output into dto.Response
-	var response dto.Response
-	err = json.Unmarshal(responseBody.Bytes(), &response)
+	candidates, err := s.candidates(ctx, request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response template output: %w", err)
+		return nil, s.rejected(ctx, request, err)
 	}
 
-	return &response, nil
+	var errs []error
+	for _, candidate := range candidates {
+		response, err := s.proxyTo(ctx, candidate, format)
+		if err == nil {
+			return response, nil
+		}
+
+		errs = append(errs, err)
+		if !canFailover(err) {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
 }
 
-func (s *proxyService) ProxyRequestStream(ctx context.Context, request dto.Request) (<-chan *dto.Response, error) {
-	providerDTO, err := s.providerService.GetProvider(ctx, request.ProviderID)
+func (s *proxyService) proxyTo(ctx context.Context, request dto.Request, format *responseFormat) (_ *dto.Response, err error) {
+	attempt := newUsageAttempt(request)
+	defer func() { s.recordUsage(ctx, attempt, err) }()
+
+	tgt, err := s.resolveTarget(ctx, request)
 	if err != nil {
 		return nil, err
 	}
+	request.Params = clampGenerationParams(request.Params, tgt.model.Limits)
 
-	if providerDTO.Status != "active" {
-		return nil, fmt.Errorf("provider is not active")
+	request, fallbackTool, err := format.adapt(request, tgt.model)
+	if err != nil {
+		return nil, err
 	}
 
-	// if model is not supported or streaming is not supported, return error
-	if model, ok := providerDTO.Models[request.ModelKey]; !ok || !model.Capabilities.Streaming {
-		return nil, fmt.Errorf("model %s is not supported by provider or streaming is not supported", request.ModelKey)
+	tokenizer := s.tokenizers.ForModel(tgt.model.Key)
+	request, err = s.manageContext(ctx, request, tgt.model, tokenizer)
+	if err != nil {
+		return nil, err
 	}
 
-	// if endpoint is not supported or inactive, return error
-	if ep, ok := providerDTO.Endpoints[request.Endpoint]; !ok || ep.Status == "inactive" {
-		return nil, fmt.Errorf("endpoint %s is not available", request.Endpoint)
+	promptTokens := countPromptTokens(tokenizer, request)
+	request, err = fitContextWindow(request, tgt.model, promptTokens)
+	if err != nil {
+		return nil, err
 	}
 
-	funcMap := template.FuncMap{
-		"json": func(v interface{}) string {
-			b, _ := json.Marshal(v)
-			return string(b)
-		},
+	upstreamReq, err := buildUpstreamRequest(tgt.provider, tgt.templates.request, request)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := estimateReservation(tgt.model, tgt.pricing, request, promptTokens)
+	reservationID, err := s.billingGateway.ReserveCredits(ctx, request.AccountID, reserved)
+	if err != nil {
+		attempt.errorCode = usage.ErrorCodeBillingFailed
+		return nil, fmt.Errorf("failed to reserve credits: %w", err)
 	}
 
-	// Parse request template
-	requestTmpl, err := template.
-		New("request").
-		Funcs(funcMap).
-		Parse(providerDTO.RequestTemplate)
+	var response *dto.Response
+	err = s.sendWithKeys(ctx, tgt, upstreamReq, func(signer Signer, request ProxyRequest) error {
+		response, err = s.doProxyRequest(ctx, signer, request, tgt.templates.response, attempt)
+		return err
+	})
 	if err != nil {
-		fmt.Println("requestTmpl")
+		// The reservation must not outlive a failed call, even if the client has gone away.
+		if releaseErr := s.billingGateway.ReleaseReservation(context.WithoutCancel(ctx), reservationID); releaseErr != nil {
+			return nil, errors.Join(err, releaseErr)
+		}
+		return nil, err
+	}
+
+	billed := billedUsage(response.Usage, promptTokens, responseLength(response))
+	actual := actualCost(tgt.pricing, request, billed)
+	attempt.billed(billed, actual)
+	attempt.finished(response)
+	if err := s.settleReservation(context.WithoutCancel(ctx), reservationID, reserved, actual); err != nil {
+		attempt.errorCode = usage.ErrorCodeBillingFailed
+		return nil, fmt.Errorf("failed to commit credit reservation: %w", err)
+	}
+
+	response.Provider = tgt.provider.Name
+	if fallbackTool != "" {
+		unwrapFallback(response, fallbackTool)
+	}
+
+	if err := format.check(response); err != nil {
 		return nil, err
 	}
 
-	// Use the same response template as non-streaming
-	responseTmpl, err := template.
-		New("response").
-		Funcs(funcMap).
-		Parse(providerDTO.ResponseTemplate)
+	return response, nil
+}
+
+func (s *proxyService) doProxyRequest(ctx context.Context, signer Signer, request ProxyRequest, responseTmpl *template.Template, attempt *usageAttempt) (*dto.Response, error) {
+	request.Headers = maps.Clone(request.Headers)
+	if err := signer.Sign(ctx, &request); err != nil {
+		return nil, &UpstreamError{Err: fmt.Errorf("failed to sign request: %w", err)}
+	}
+
+	resp, err := s.proxyClient.ProxyRequest(ctx, request)
+	if err != nil {
+		return nil, &UpstreamError{Err: err}
+	}
+	attempt.upstreamStatus = resp.StatusCode
+	attempt.latency = resp.Latency
+
+	// Check if the response status code indicates an error
+	if resp.StatusCode >= 400 {
+		return nil, &UpstreamError{StatusCode: resp.StatusCode, RetryAfter: resp.RetryAfter, Err: errors.New(string(resp.Body))}
+	}
+
+	return renderResponse(responseTmpl, resp.Body)
+}
+
+// dispatchStream signs the request and opens the stream.
+func (s *proxyService) dispatchStream(ctx context.Context, signer Signer, request ProxyRequest) (io.ReadCloser, error) {
+	request.Headers = maps.Clone(request.Headers)
+	if err := signer.Sign(ctx, &request); err != nil {
+		return nil, &UpstreamError{Err: fmt.Errorf("failed to sign request: %w", err)}
+	}
+	return s.proxyClient.ProxyRequestStream(ctx, request)
+}
+
+// upstreamStream is an open stream from the target that accepted it.
+type upstreamStream struct {
+	target        *target
+	reader        io.ReadCloser
+	reservationID string
+	reserved      int64
+	promptTokens  int
+	attempt       *usageAttempt
+	// fallbackTool names the tool standing in for the response format, if any.
+	fallbackTool string
+}
+
+// openStream opens a stream to the first target that accepts it. Once a
+// stream is open there is no failing over, since chunks may already have
+// reached the client.
+func (s *proxyService) openStream(ctx context.Context, request dto.Request, format *responseFormat) (*upstreamStream, error) {
+	candidates, err := s.candidates(ctx, request)
+	if err != nil {
+		return nil, s.rejected(ctx, request, err)
+	}
+
+	var errs []error
+	for _, candidate := range candidates {
+		stream, err := s.openStreamTo(ctx, candidate, format)
+		if err == nil {
+			return stream, nil
+		}
+
+		errs = append(errs, err)
+		if !canFailover(err) {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (s *proxyService) openStreamTo(ctx context.Context, request dto.Request, format *responseFormat) (_ *upstreamStream, err error) {
+	// Streams that open are recorded once they have been drained.
+	attempt := newUsageAttempt(request)
+	defer func() {
+		if err != nil {
+			s.recordUsage(ctx, attempt, err)
+		}
+	}()
+
+	tgt, err := s.resolveTarget(ctx, request)
 	if err != nil {
-		fmt.Println("responseTmpl")
-		fmt.Println(err)
 		return nil, err
 	}
+	request.Params = clampGenerationParams(request.Params, tgt.model.Limits)
 
-	// Force streaming by setting stream: true in the request
-	request.Stream = true
+	request, fallbackTool, err := format.adapt(request, tgt.model)
+	if err != nil {
+		return nil, err
+	}
 
-	var requestBody bytes.Buffer
-	err = requestTmpl.Execute(&requestBody, request)
+	tokenizer := s.tokenizers.ForModel(tgt.model.Key)
+	request, err = s.manageContext(ctx, request, tgt.model, tokenizer)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Println(requestBody.String())
+	promptTokens := countPromptTokens(tokenizer, request)
+	request, err = fitContextWindow(request, tgt.model, promptTokens)
+	if err != nil {
+		return nil, err
+	}
 
-	target := fmt.Sprintf("%s/%s", providerDTO.BaseURL, providerDTO.Endpoints[request.Endpoint].Path)
+	upstreamReq, err := buildUpstreamRequest(tgt.provider, tgt.templates.request, request)
+	if err != nil {
+		return nil, err
+	}
 
-	// Build auth header value with optional prefix
-	authValue := providerDTO.AuthConfig.Credential
-	if providerDTO.AuthConfig.Prefix != "" {
-		authValue = fmt.Sprintf("%s %s", providerDTO.AuthConfig.Prefix, providerDTO.AuthConfig.Credential)
+	reserved := estimateReservation(tgt.model, tgt.pricing, request, promptTokens)
+	reservationID, err := s.billingGateway.ReserveCredits(ctx, request.AccountID, reserved)
+	if err != nil {
+		attempt.errorCode = usage.ErrorCodeBillingFailed
+		return nil, fmt.Errorf("failed to reserve credits: %w", err)
 	}
 
-	// Build headers map starting with defaults
-	headers := map[string]string{
-		"Content-Type": "application/json",
-		"Accept":       "text/event-stream",
+	var reader io.ReadCloser
+	err = s.sendWithKeys(ctx, tgt, upstreamReq, func(signer Signer, request ProxyRequest) error {
+		reader, err = s.dispatchStream(ctx, signer, request)
+		return err
+	})
+	if err != nil {
+		var upstreamErr *UpstreamError
+		if !errors.As(err, &upstreamErr) {
+			upstreamErr = &UpstreamError{Err: err}
+			err = upstreamErr
+		}
+		attempt.upstreamStatus = upstreamErr.StatusCode
+		if releaseErr := s.billingGateway.ReleaseReservation(context.WithoutCancel(ctx), reservationID); releaseErr != nil {
+			return nil, errors.Join(err, releaseErr)
+		}
+		return nil, err
 	}
+	attempt.upstreamStatus = http.StatusOK
+
+	return &upstreamStream{
+		target:        tgt,
+		reader:        reader,
+		reservationID: reservationID,
+		reserved:      reserved,
+		promptTokens:  promptTokens,
+		attempt:       attempt,
+		fallbackTool:  fallbackTool,
+	}, nil
+}
 
-	// Add extra headers from provider config
-	for k, v := range providerDTO.Headers {
-		headers[k] = v
+func (s *proxyService) ProxyRequestStream(ctx context.Context, request dto.Request) (<-chan *dto.Response, error) {
+	// Force streaming by setting stream: true in the request
+	request.Stream = true
+	request = ensureRequestID(request)
+
+	if request.ResponseFormat.Validate {
+		return nil, s.rejected(ctx, request, fmt.Errorf("%w: response format validation is not supported for streaming requests", ErrInvalidRequest))
 	}
 
-	// Add auth header
-	headers[providerDTO.AuthConfig.Header] = authValue
+	prepared, format, err := prepareRequest(request)
+	if err != nil {
+		return nil, s.rejected(ctx, request, err)
+	}
+	request = prepared
 
-	streamReader, err := s.proxyClient.ProxyRequestStream(ctx, ProxyRequest{
-		Target:  target,
-		Method:  "POST",
-		Headers: headers,
-		Body:    requestBody.Bytes(),
-	})
+	stream, err := s.openStream(ctx, request, format)
 	if err != nil {
 		return nil, err
 	}
+	tgt := stream.target
 
 	// Create output channel
 	responseChan := make(chan *dto.Response, 100)
@@ -234,9 +548,40 @@ func (s *proxyService) ProxyRequestStream(ctx context.Context, request dto.Reque
 	// Start goroutine to process stream
 	go func() {
 		defer close(responseChan)
-		defer streamReader.Close()
+		defer stream.reader.Close()
+
+		// Usage is usually reported on the final chunk only, but some
+		// providers split it across chunks, so merge what each chunk reports
+		// and settle once the stream is drained.
+		var (
+			reported        dto.Usage
+			completionChars int
+			streamErr       error
+		)
+		defer func() {
+			billed := billedUsage(reported, stream.promptTokens, completionChars)
+			actual := actualCost(tgt.pricing, request, billed)
+			stream.attempt.billed(billed, actual)
+			if err := s.settleReservation(context.WithoutCancel(ctx), stream.reservationID, stream.reserved, actual); err != nil {
+				stream.attempt.errorCode = usage.ErrorCodeBillingFailed
+				if s.logger != nil {
+					s.logger.Errorf("Failed to settle credit reservation %s of request %s: %v", stream.reservationID, request.RequestID, err)
+				}
+			}
+			s.recordUsage(ctx, stream.attempt, streamErr)
+		}()
+
+		// fail ends the stream with an error chunk.
+		fail := func(err error) {
+			streamErr = err
+			select {
+			case responseChan <- &dto.Response{Err: err}:
+			case <-ctx.Done():
+			}
+		}
 
-		scanner := bufio.NewScanner(streamReader)
+		fallback := &fallbackStream{name: stream.fallbackTool}
+		scanner := bufio.NewScanner(stream.reader)
 
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
@@ -258,43 +603,32 @@ func (s *proxyService) ProxyRequestStream(ctx context.Context, request dto.Reque
 					return
 				}
 
-				// Parse the provider response chunk (same format as non-streaming)
-				var providerChunk any
-				if err := json.Unmarshal([]byte(data), &providerChunk); err != nil {
-					fmt.Println(err)
-					continue
-				}
-
-				// b, _ := json.Marshal(providerChunk)
-				// fmt.Println(string(b))
-
-				// Transform using same response template
-				var responseBody bytes.Buffer
-				if err := responseTmpl.Execute(&responseBody, providerChunk); err != nil {
-					fmt.Println("ResponseTmpl error")
-					fmt.Println(err)
-					continue
+				// Transform using same response template as non-streaming
+				response, err := renderResponse(tgt.templates.response, []byte(data))
+				if err != nil {
+					fail(fmt.Errorf("failed to map stream chunk: %w", err))
+					return
 				}
 
-				// fmt.Printf("Response chunk: %s\n", responseBody.String())
-
-				// Parse the transformed chunk into canonical Response format
-				var response dto.Response
-				if err := json.Unmarshal(responseBody.Bytes(), &response); err != nil {
-					fmt.Print(err)
-					continue
+				reported = mergeUsage(reported, response.Usage)
+				completionChars += responseLength(response)
+				stream.attempt.finished(response)
+				response.Provider = tgt.provider.Name
+				if stream.fallbackTool != "" {
+					fallback.unwrap(response)
 				}
 
 				// Send the response chunk
 				select {
-				case responseChan <- &response:
+				case responseChan <- response:
 				case <-ctx.Done():
+					streamErr = ctx.Err()
 					return
 				}
 			}
 		}
-
 		if err := scanner.Err(); err != nil {
+			fail(&UpstreamError{Err: fmt.Errorf("stream interrupted: %w", err)})
 		}
 	}()
 
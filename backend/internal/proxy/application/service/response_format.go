@@ -0,0 +1,179 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/schema"
+)
+
+// ErrResponseFormatMismatch is returned when validation was requested and
+// the model's reply does not match the response format.
+var ErrResponseFormatMismatch = errors.New("response does not match the requested format")
+
+const defaultResponseFormatName = "response"
+
+var jsonObjectSchema = schema.MustParse(`{"type":"object"}`)
+
+// responseFormat is a validated dto.ResponseFormat with its schema compiled.
+type responseFormat struct {
+	dto.ResponseFormat
+	schema *schema.Schema
+}
+
+func newResponseFormat(format dto.ResponseFormat) (*responseFormat, error) {
+	if format.Type == "" {
+		format.Type = dto.ResponseFormatText
+	}
+
+	if !format.Type.IsValid() {
+		return nil, fmt.Errorf("%w: unknown response format %q", ErrInvalidRequest, format.Type)
+	}
+
+	f := &responseFormat{ResponseFormat: format}
+	switch format.Type {
+	case dto.ResponseFormatJSONObject:
+		f.schema = jsonObjectSchema
+
+	case dto.ResponseFormatJSONSchema:
+		if len(format.Schema) == 0 {
+			return nil, fmt.Errorf("%w: json_schema response format requires a schema", ErrInvalidRequest)
+		}
+
+		s, err := schema.Parse(format.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		f.schema = s
+
+		if f.Name == "" {
+			f.Name = defaultResponseFormatName
+		}
+	}
+
+	return f, nil
+}
+
+func (f *responseFormat) structured() bool {
+	return f.Type != dto.ResponseFormatText
+}
+
+// adapt prepares the request for the target model. Models without native
+// structured output are asked to call a tool whose parameters are the
+// schema instead; the returned name identifies that tool, or is empty when
+// the request is sent as is.
+func (f *responseFormat) adapt(request dto.Request, model dto.Model) (dto.Request, string, error) {
+	request.ResponseFormat = f.ResponseFormat
+	if !f.structured() || model.Capabilities.StructuredOutput {
+		return request, "", nil
+	}
+
+	if !model.Capabilities.FunctionCalling {
+		return request, "", fmt.Errorf("%w: model %s supports neither structured output nor function calling", ErrTargetUnavailable, model.Key)
+	}
+
+	name := f.Name
+	if name == "" {
+		name = defaultResponseFormatName
+	}
+
+	tools := make([]dto.Tool, 0, len(request.Tools)+1)
+	tools = append(tools, request.Tools...)
+	request.Tools = append(tools, dto.Tool{
+		ToolType: dto.ToolTypeFunction,
+		ToolDefinition: dto.ToolDefinition{
+			Name:        name,
+			Description: "Respond to the user with output matching this schema.",
+			Parameters:  newParameterSchema(f.schema),
+		},
+	})
+
+	// Only force the call when it cannot stop the model from using the
+	// caller's own tools.
+	if len(tools) == 0 {
+		request.ToolChoice = dto.ToolChoice{Type: dto.ToolChoiceFunction, FunctionName: &name}
+	}
+
+	request.ResponseFormat = dto.ResponseFormat{Type: dto.ResponseFormatText}
+	return request, name, nil
+}
+
+// check validates every choice's text content against the format.
+func (f *responseFormat) check(response *dto.Response) error {
+	if !f.Validate || !f.structured() {
+		return nil
+	}
+
+	for _, choice := range response.Choices {
+		if err := f.schema.ValidateJSON([]byte(textContent(choice.Message.Content))); err != nil {
+			return fmt.Errorf("%w: choice %d: %w", ErrResponseFormatMismatch, choice.Index, err)
+		}
+	}
+
+	return nil
+}
+
+// unwrapFallback turns calls to the fallback tool back into message content.
+func unwrapFallback(response *dto.Response, name string) {
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+
+		remaining := choice.Message.ToolCalls[:0]
+		for _, call := range choice.Message.ToolCalls {
+			if call.Call.Name != name {
+				remaining = append(remaining, call)
+				continue
+			}
+			choice.Message.Content = append(choice.Message.Content, dto.Part{Type: dto.PartTypeText, Body: call.Call.Arg})
+		}
+		choice.Message.ToolCalls = remaining
+
+		if len(remaining) == 0 && choice.FinishReason == dto.FinishReasonToolCalls {
+			choice.FinishReason = dto.FinishReasonStop
+		}
+	}
+}
+
+// fallbackStream does what unwrapFallback does for streamed deltas, where
+// only the first delta of a tool call carries its name.
+type fallbackStream struct {
+	name    string
+	current bool
+}
+
+func (s *fallbackStream) unwrap(response *dto.Response) {
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+
+		var remaining []dto.ToolCall
+		for _, call := range choice.Delta.ToolCalls {
+			if call.Call.Name != "" {
+				s.current = call.Call.Name == s.name
+			}
+			if !s.current {
+				remaining = append(remaining, call)
+				continue
+			}
+			if call.Call.Arg != "" {
+				choice.Delta.Content = append(choice.Delta.Content, dto.Part{Type: dto.PartTypeText, Body: call.Call.Arg})
+			}
+		}
+		choice.Delta.ToolCalls = remaining
+
+		if len(remaining) == 0 && choice.FinishReason == dto.FinishReasonToolCalls {
+			choice.FinishReason = dto.FinishReasonStop
+		}
+	}
+}
+
+func textContent(content dto.Content) string {
+	var b strings.Builder
+	for _, part := range content {
+		if part.Type == dto.PartTypeText {
+			b.WriteString(part.Body)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/route"
+)
+
+type RouteRepository = repository.RouteRepository
+
+type RouteService interface {
+	GetRoute(ctx context.Context, id string) (*dto.GetRouteResponse, error)
+	ListRoutes(ctx context.Context) (*dto.ListRoutesResponse, error)
+	CreateRoute(ctx context.Context, request dto.CreateRouteRequest) (*dto.CreateRouteResponse, error)
+	UpdateRouteTargets(ctx context.Context, request dto.UpdateRouteTargetsRequest) error
+	RemoveRoute(ctx context.Context, id string) error
+	// ResolveTargets returns the targets for a logical model name in order of
+	// preference. Models without a route resolve to the single provider that
+	// serves them.
+	ResolveTargets(ctx context.Context, model string) ([]dto.Target, error)
+}
+
+var _ RouteService = (*routeService)(nil)
+
+type routeService struct {
+	routeRepository    RouteRepository
+	providerRepository ProviderRepository
+	providerService    ProviderService
+	uow                UnitOfWork
+}
+
+func NewRouteService(
+	routeRepository RouteRepository,
+	providerRepository ProviderRepository,
+	providerService ProviderService,
+	uow UnitOfWork,
+) RouteService {
+	return &routeService{
+		routeRepository:    routeRepository,
+		providerRepository: providerRepository,
+		providerService:    providerService,
+		uow:                uow,
+	}
+}
+
+func (s *routeService) GetRoute(ctx context.Context, id string) (*dto.GetRouteResponse, error) {
+	rt, err := s.routeRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.GetRouteResponse{Route: mapRouteToDTO(rt)}, nil
+}
+
+func (s *routeService) ListRoutes(ctx context.Context) (*dto.ListRoutesResponse, error) {
+	routes, err := s.routeRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dtoRoutes := make([]dto.Route, 0, len(routes))
+	for _, rt := range routes {
+		dtoRoutes = append(dtoRoutes, mapRouteToDTO(rt))
+	}
+
+	return &dto.ListRoutesResponse{Routes: dtoRoutes}, nil
+}
+
+func (s *routeService) CreateRoute(ctx context.Context, request dto.CreateRouteRequest) (*dto.CreateRouteResponse, error) {
+	targets, err := s.mapTargetsToDomain(ctx, s.providerRepository, request.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := route.New(request.Model, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.routeRepository.Save(ctx, rt); err != nil {
+		return nil, err
+	}
+
+	return &dto.CreateRouteResponse{Route: mapRouteToDTO(rt)}, nil
+}
+
+func (s *routeService) UpdateRouteTargets(ctx context.Context, request dto.UpdateRouteTargetsRequest) error {
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		rt, err := repoProvider.RouteRepository().GetByIDForUpdate(ctx, request.RouteID)
+		if err != nil {
+			return err
+		}
+
+		targets, err := s.mapTargetsToDomain(ctx, repoProvider.ProviderRepository(), request.Targets)
+		if err != nil {
+			return err
+		}
+
+		if err := rt.UpdateTargets(targets); err != nil {
+			return err
+		}
+
+		return repoProvider.RouteRepository().Save(ctx, rt)
+	})
+}
+
+func (s *routeService) RemoveRoute(ctx context.Context, id string) error {
+	return s.routeRepository.Delete(ctx, id)
+}
+
+func (s *routeService) ResolveTargets(ctx context.Context, model string) ([]dto.Target, error) {
+	rt, err := s.routeRepository.GetByModel(ctx, model)
+	if err == nil {
+		return mapRouteToDTO(rt).Targets, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	resolved, err := s.providerService.ResolveModel(ctx, dto.ResolveModelRequest{Model: model})
+	if err != nil {
+		return nil, err
+	}
+
+	return []dto.Target{{
+		ProviderID: resolved.ProviderID,
+		ModelKey:   resolved.ModelKey,
+		Endpoint:   resolved.Endpoint,
+	}}, nil
+}
+
+// mapTargetsToDomain checks that every target names a model and endpoint its
+// provider actually has, so a route cannot silently point nowhere.
+func (s *routeService) mapTargetsToDomain(ctx context.Context, providers ProviderRepository, targets []dto.Target) ([]route.Target, error) {
+	domainTargets := make([]route.Target, 0, len(targets))
+	for _, t := range targets {
+		if t.Endpoint == "" {
+			t.Endpoint = DefaultChatEndpoint
+		}
+
+		pvd, err := providers.GetByID(ctx, t.ProviderID)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", t.ProviderID, err)
+		}
+
+		if !pvd.HasModel(t.ModelKey) {
+			return nil, fmt.Errorf("model %s is not supported by provider %s", t.ModelKey, pvd.Name())
+		}
+
+		if !pvd.HasEndpoint(t.Endpoint) {
+			return nil, fmt.Errorf("endpoint %s is not configured for provider %s", t.Endpoint, pvd.Name())
+		}
+
+		domainTargets = append(domainTargets, route.Target{
+			ProviderID: t.ProviderID,
+			ModelKey:   t.ModelKey,
+			Endpoint:   t.Endpoint,
+		})
+	}
+
+	return domainTargets, nil
+}
+
+func mapRouteToDTO(rt *route.Route) dto.Route {
+	targets := make([]dto.Target, len(rt.Targets()))
+	for i, t := range rt.Targets() {
+		targets[i] = dto.Target{
+			ProviderID: t.ProviderID,
+			ModelKey:   t.ModelKey,
+			Endpoint:   t.Endpoint,
+		}
+	}
+
+	return dto.Route{
+		ID:        rt.ID().String(),
+		Model:     rt.Model(),
+		Targets:   targets,
+		UpdatedAt: rt.UpdatedAt(),
+	}
+}
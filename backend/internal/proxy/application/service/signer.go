@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+// Signer authenticates an upstream request. Signers run just before the
+// request is dispatched, once its target, headers and body are final,
+// because some of them sign all three.
+type Signer interface {
+	Sign(ctx context.Context, request *ProxyRequest) error
+}
+
+// RefreshableSigner is a Signer with a cached token the provider may reject
+// before it expires.
+type RefreshableSigner interface {
+	Signer
+	// Invalidate drops the token the signer last signed with, so the next
+	// Sign fetches a new one.
+	Invalidate()
+}
+
+// SignerFactory builds the signer for a provider's auth settings and opened
+// credential. Signers that fetch tokens share them across calls until the
+// provider is forgotten.
+type SignerFactory interface {
+	Signer(providerID string, auth dto.AuthConfig, credential string) (Signer, error)
+	// Forget drops the tokens cached for a provider, after its credentials
+	// change or it is removed.
+	Forget(providerID string)
+}
+
+// authConfigFromDTO reads auth settings around an already sealed credential.
+// An empty type is an API key header, and OAuth2 tokens are sent as bearer
+// tokens unless told otherwise.
+func authConfigFromDTO(auth dto.AuthConfig, credential provider.Credential) provider.AuthConfig {
+	cfg := provider.AuthConfig{
+		Type:       provider.AuthType(auth.Type),
+		Header:     auth.Header,
+		Prefix:     auth.Prefix,
+		QueryParam: auth.QueryParam,
+		OAuth2: provider.OAuth2Config{
+			TokenURL: auth.OAuth2.TokenURL,
+			ClientID: auth.OAuth2.ClientID,
+			Scopes:   auth.OAuth2.Scopes,
+		},
+		SigV4: provider.SigV4Config{
+			AccessKeyID: auth.SigV4.AccessKeyID,
+			Region:      auth.SigV4.Region,
+			Service:     auth.SigV4.Service,
+		},
+		Credential: credential,
+	}
+
+	if cfg.Type == "" {
+		cfg.Type = provider.AuthTypeAPIKey
+	}
+
+	if cfg.Type == provider.AuthTypeOAuth2 && cfg.Header == "" {
+		cfg.Header, cfg.Prefix = "Authorization", "Bearer"
+	}
+
+	return cfg
+}
+
+// authConfigToDTO maps auth settings with the credential redacted. The
+// sealed credential is carried for the proxy path only.
+func authConfigToDTO(auth provider.AuthConfig) dto.AuthConfig {
+	return dto.AuthConfig{
+		Type:       string(auth.Type),
+		Header:     auth.Header,
+		Prefix:     auth.Prefix,
+		QueryParam: auth.QueryParam,
+		OAuth2: dto.OAuth2Config{
+			TokenURL: auth.OAuth2.TokenURL,
+			ClientID: auth.OAuth2.ClientID,
+			Scopes:   auth.OAuth2.Scopes,
+		},
+		SigV4: dto.SigV4Config{
+			AccessKeyID: auth.SigV4.AccessKeyID,
+			Region:      auth.SigV4.Region,
+			Service:     auth.SigV4.Service,
+		},
+		Credential: redactCredential(auth.Credential),
+
+		EncryptedCredential: auth.Credential.Encrypted,
+		DataKey:             auth.Credential.DataKey,
+		KeyID:               auth.Credential.KeyID,
+	}
+}
+
+// openSigner opens a provider's credential and builds its signer.
+func openSigner(vault CredentialVault, signers SignerFactory, providerID string, auth dto.AuthConfig) (Signer, error) {
+	credential, err := vault.Open(credentialFromDTO(auth))
+	if err != nil {
+		return nil, err
+	}
+	return signers.Signer(providerID, auth, credential)
+}
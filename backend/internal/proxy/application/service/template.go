@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/schema"
+)
+
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// sampleRequests is the canonical request suite every request template must
+// render to valid JSON.
+var sampleRequests = map[string]dto.Request{
+	"text": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "Hello"}}},
+		},
+	},
+	"conversation": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleSystem, Content: dto.Content{{Type: dto.PartTypeText, Body: "You are \"helpful\".\nBe brief."}}},
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "Hi"}}},
+			{Role: dto.MessageRoleAssistant, Content: dto.Content{{Type: dto.PartTypeText, Body: "Hello! How can I help?"}}},
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "Tell me a joke"}}},
+		},
+	},
+	"tools": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "What is the weather in Paris?"}}},
+			{
+				Role: dto.MessageRoleAssistant,
+				ToolCalls: []dto.ToolCall{{
+					ID:       "call_1",
+					ToolType: dto.ToolTypeFunction,
+					Call:     dto.FunctionCall{Name: "get_weather", Arg: `{"city":"Paris"}`},
+				}},
+			},
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeTool, Body: `{"temperature":21}`, ToolCallID: "call_1"}}},
+		},
+		Tools: []dto.Tool{{
+			ToolType: dto.ToolTypeFunction,
+			ToolDefinition: dto.ToolDefinition{
+				Name:        "get_weather",
+				Description: "Get the current weather",
+				Parameters: newParameterSchema(schema.MustParse(`{
+					"type": "object",
+					"properties": {
+						"city": {"type": "string", "description": "City name"},
+						"unit": {"type": ["string", "null"], "enum": ["celsius", "fahrenheit", null]},
+						"days": {"type": "array", "items": {"$ref": "#/$defs/day"}, "maxItems": 7}
+					},
+					"required": ["city"],
+					"additionalProperties": false,
+					"$defs": {"day": {"type": "integer", "minimum": 0, "maximum": 6}}
+				}`)),
+			},
+		}},
+		ToolChoice: dto.ToolChoice{Type: dto.ToolChoiceAUto},
+	},
+	"image": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleUser, Content: dto.Content{
+				{Type: dto.PartTypeText, Body: "Describe this image"},
+				{Type: dto.PartTypeImage, Body: "iVBORw0KGgo=", MediaType: "png"},
+			}},
+		},
+	},
+	"image_url": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleUser, Content: dto.Content{
+				{Type: dto.PartTypeText, Body: "Describe this image"},
+				{Type: dto.PartTypeImage, URL: "https://example.com/cat.png"},
+			}},
+		},
+	},
+	"params": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "Hello"}}},
+		},
+		Params: dto.GenerationParams{
+			Temperature:      ptr(0.7),
+			TopP:             ptr(0.9),
+			MaxTokens:        ptr(256),
+			Stop:             []string{"\n\n", "END"},
+			Seed:             ptr(int64(42)),
+			PresencePenalty:  ptr(0.5),
+			FrequencyPenalty: ptr(-0.5),
+		},
+	},
+	"response_format": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "List three colors"}}},
+		},
+		ResponseFormat: dto.ResponseFormat{
+			Type:   dto.ResponseFormatJSONSchema,
+			Name:   "colors",
+			Schema: []byte(`{"type":"object","properties":{"colors":{"type":"array","items":{"type":"string"}}},"required":["colors"]}`),
+			Strict: true,
+		},
+	},
+	"stream": {
+		ModelKey: "sample-model",
+		Endpoint: DefaultChatEndpoint,
+		Stream:   true,
+		Messages: []dto.Message{
+			{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: "Hello"}}},
+		},
+	},
+}
+
+// sampleResponses are common provider response shapes. A response template
+// is expected to handle at least one of them unless the caller supplies a
+// sample of their own.
+var sampleResponses = map[string]string{
+	"openai":        `{"id":"chatcmpl-1","object":"chat.completion","model":"sample-model","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+	"openai-stream": `{"id":"chatcmpl-1","object":"chat.completion.chunk","model":"sample-model","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"},"finish_reason":null}]}`,
+	"anthropic":     `{"id":"msg_1","type":"message","role":"assistant","model":"sample-model","content":[{"type":"text","text":"Hello!"}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":2}}`,
+	"gemini":        `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello!"}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":2,"totalTokenCount":7},"modelVersion":"sample-model"}`,
+}
+
+// validateTemplates parses both templates and dry-runs them: the request
+// template against every sample request, the response template against the
+// supplied sample response or, without one, the built-in samples.
+func validateTemplates(requestTemplate, responseTemplate string, sampleResponse []byte) error {
+	templates, err := compileTemplates(dto.Provider{
+		RequestTemplate:  requestTemplate,
+		ResponseTemplate: responseTemplate,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(sampleRequests)) {
+		var body bytes.Buffer
+		if err := templates.request.Execute(&body, sampleRequests[name]); err != nil {
+			return fmt.Errorf("%w: request template fails on %q sample: %w", ErrInvalidTemplate, name, err)
+		}
+		if !json.Valid(body.Bytes()) {
+			return fmt.Errorf("%w: request template renders invalid JSON for %q sample", ErrInvalidTemplate, name)
+		}
+	}
+
+	if len(sampleResponse) > 0 {
+		if _, err := renderResponse(templates.response, sampleResponse); err != nil {
+			return fmt.Errorf("%w: response template fails on the supplied sample: %w", ErrInvalidTemplate, err)
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(sampleResponses)) {
+		if _, err := renderResponse(templates.response, []byte(sampleResponses[name])); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: response template handles none of the sample responses: %w", ErrInvalidTemplate, errors.Join(errs...))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
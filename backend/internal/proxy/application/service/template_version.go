@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+type TemplateVersionRepository = repository.TemplateVersionRepository
+
+var ErrTemplateVersionNotFound = errors.New("template version not found")
+
+// TemplateVersionService exposes the history of a provider's templates.
+// Every template change is recorded as an immutable version by
+// ProviderService.UpdateProviderTemplate; this service lists, compares,
+// rolls back and stages those versions.
+type TemplateVersionService interface {
+	ListTemplateVersions(ctx context.Context, providerID string) (*dto.ListTemplateVersionsResponse, error)
+	GetTemplateVersion(ctx context.Context, request dto.GetTemplateVersionRequest) (*dto.TemplateVersion, error)
+	DiffTemplateVersions(ctx context.Context, request dto.DiffTemplateVersionsRequest) (*dto.DiffTemplateVersionsResponse, error)
+	// RollbackTemplate makes an earlier version serve all traffic and
+	// discards any staged version.
+	RollbackTemplate(ctx context.Context, request dto.RollbackTemplateRequest) error
+	// StageTemplate sends a percentage of traffic to a version until it is
+	// promoted or discarded.
+	StageTemplate(ctx context.Context, request dto.StageTemplateRequest) (*dto.StageTemplateResponse, error)
+	PromoteStagedTemplate(ctx context.Context, providerID string) error
+	DiscardStagedTemplate(ctx context.Context, providerID string) error
+}
+
+var _ TemplateVersionService = (*templateVersionService)(nil)
+
+type templateVersionService struct {
+	providerRepository ProviderRepository
+	versionRepository  TemplateVersionRepository
+	uow                UnitOfWork
+	cache              *ProviderCache
+}
+
+func NewTemplateVersionService(
+	providerRepository ProviderRepository,
+	versionRepository TemplateVersionRepository,
+	uow UnitOfWork,
+	cache *ProviderCache,
+) TemplateVersionService {
+	return &templateVersionService{
+		providerRepository: providerRepository,
+		versionRepository:  versionRepository,
+		uow:                uow,
+		cache:              cache,
+	}
+}
+
+func (s *templateVersionService) ListTemplateVersions(ctx context.Context, providerID string) (*dto.ListTemplateVersionsResponse, error) {
+	pvd, err := s.providerRepository.GetByID(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.versionRepository.ListByProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.ListTemplateVersionsResponse{
+		Versions: make([]dto.TemplateVersion, len(versions)),
+		Serving:  pvd.TemplateVersion(),
+		Staged:   mapStagedTemplateToDTO(pvd),
+	}
+	for i, v := range versions {
+		response.Versions[i] = mapTemplateVersionToDTO(v)
+	}
+
+	return response, nil
+}
+
+func (s *templateVersionService) GetTemplateVersion(ctx context.Context, request dto.GetTemplateVersionRequest) (*dto.TemplateVersion, error) {
+	version, err := s.getVersion(ctx, s.versionRepository, request.ProviderID, request.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	v := mapTemplateVersionToDTO(version)
+	return &v, nil
+}
+
+func (s *templateVersionService) DiffTemplateVersions(ctx context.Context, request dto.DiffTemplateVersionsRequest) (*dto.DiffTemplateVersionsResponse, error) {
+	if request.To == 0 {
+		pvd, err := s.providerRepository.GetByID(ctx, request.ProviderID)
+		if err != nil {
+			return nil, err
+		}
+		request.To = pvd.TemplateVersion()
+	}
+
+	from, err := s.getVersion(ctx, s.versionRepository, request.ProviderID, request.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.getVersion(ctx, s.versionRepository, request.ProviderID, request.To)
+	if err != nil {
+		return nil, err
+	}
+
+	fromName, toName := fmt.Sprintf("v%d", from.Number), fmt.Sprintf("v%d", to.Number)
+	return &dto.DiffTemplateVersionsResponse{
+		From:         from.Number,
+		To:           to.Number,
+		RequestDiff:  unifiedDiff(fromName, toName, from.RequestTemplate.Content, to.RequestTemplate.Content),
+		ResponseDiff: unifiedDiff(fromName, toName, from.ResponseTemplate.Content, to.ResponseTemplate.Content),
+	}, nil
+}
+
+func (s *templateVersionService) RollbackTemplate(ctx context.Context, request dto.RollbackTemplateRequest) error {
+	defer s.cache.Invalidate(request.ProviderID)
+
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		version, err := s.getVersion(ctx, repoProvider.TemplateVersionRepository(), request.ProviderID, request.Version)
+		if err != nil {
+			return err
+		}
+
+		if err := pvd.ApplyTemplateVersion(version); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+}
+
+func (s *templateVersionService) StageTemplate(ctx context.Context, request dto.StageTemplateRequest) (*dto.StageTemplateResponse, error) {
+	defer s.cache.Invalidate(request.ProviderID)
+
+	if request.Version == 0 {
+		if err := validateTemplates(request.RequestTemplate, request.ResponseTemplate, request.SampleResponse); err != nil {
+			return nil, err
+		}
+	}
+
+	var staged provider.TemplateVersion
+	err := s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, request.ProviderID)
+		if err != nil {
+			return err
+		}
+
+		versions := repoProvider.TemplateVersionRepository()
+		if request.Version > 0 {
+			staged, err = s.getVersion(ctx, versions, request.ProviderID, request.Version)
+		} else {
+			staged, err = recordTemplateVersion(ctx, versions, pvd,
+				provider.Template{Content: request.RequestTemplate},
+				provider.Template{Content: request.ResponseTemplate},
+				request.Author)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := pvd.StageTemplateVersion(staged, request.Percent); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.StageTemplateResponse{Version: staged.Number}, nil
+}
+
+func (s *templateVersionService) PromoteStagedTemplate(ctx context.Context, providerID string) error {
+	return s.updateStaged(ctx, providerID, (*provider.Provider).PromoteStagedTemplate)
+}
+
+func (s *templateVersionService) DiscardStagedTemplate(ctx context.Context, providerID string) error {
+	return s.updateStaged(ctx, providerID, (*provider.Provider).DiscardStagedTemplate)
+}
+
+func (s *templateVersionService) updateStaged(ctx context.Context, providerID string, update func(*provider.Provider) error) error {
+	defer s.cache.Invalidate(providerID)
+
+	return s.uow.Do(ctx, func(ctx context.Context, repoProvider repository.RepositoryProvider) error {
+		pvd, err := repoProvider.ProviderRepository().GetByIDForUpdate(ctx, providerID)
+		if err != nil {
+			return err
+		}
+
+		if err := update(pvd); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+
+		return repoProvider.ProviderRepository().Save(ctx, pvd)
+	})
+}
+
+func (s *templateVersionService) getVersion(ctx context.Context, versions TemplateVersionRepository, providerID string, number int) (provider.TemplateVersion, error) {
+	version, err := versions.GetByNumber(ctx, providerID, number)
+	if errors.Is(err, repository.ErrNotFound) {
+		return provider.TemplateVersion{}, fmt.Errorf("%w: %d", ErrTemplateVersionNotFound, number)
+	}
+	return version, err
+}
+
+// recordTemplateVersion saves the templates as the provider's next version
+// without applying it. A provider whose templates predate versioning first
+// has them recorded as a baseline, so the change can be rolled back.
+func recordTemplateVersion(
+	ctx context.Context,
+	versions TemplateVersionRepository,
+	pvd *provider.Provider,
+	request, response provider.Template,
+	author string,
+) (provider.TemplateVersion, error) {
+	latest, err := versions.LatestNumber(ctx, pvd.ID().String())
+	if err != nil {
+		return provider.TemplateVersion{}, err
+	}
+
+	if latest == 0 {
+		if _, err := saveTemplateVersion(ctx, versions, pvd.ID(), 1, pvd.RequestTemplate(), pvd.ResponseTemplate(), ""); err != nil {
+			return provider.TemplateVersion{}, err
+		}
+		latest = 1
+	}
+
+	return saveTemplateVersion(ctx, versions, pvd.ID(), latest+1, request, response, author)
+}
+
+// recordInitialTemplateVersion records a new provider's templates as its
+// first version.
+func recordInitialTemplateVersion(ctx context.Context, versions TemplateVersionRepository, pvd *provider.Provider, author string) error {
+	version, err := saveTemplateVersion(ctx, versions, pvd.ID(), 1, pvd.RequestTemplate(), pvd.ResponseTemplate(), author)
+	if err != nil {
+		return err
+	}
+
+	return pvd.ApplyTemplateVersion(version)
+}
+
+func saveTemplateVersion(
+	ctx context.Context,
+	versions TemplateVersionRepository,
+	providerID provider.ID,
+	number int,
+	request, response provider.Template,
+	author string,
+) (provider.TemplateVersion, error) {
+	version, err := provider.NewTemplateVersion(providerID, number, request, response, author)
+	if err != nil {
+		return provider.TemplateVersion{}, err
+	}
+
+	if err := versions.Save(ctx, version); err != nil {
+		return provider.TemplateVersion{}, err
+	}
+
+	return version, nil
+}
+
+func mapTemplateVersionToDTO(v provider.TemplateVersion) dto.TemplateVersion {
+	return dto.TemplateVersion{
+		Number:           v.Number,
+		RequestTemplate:  v.RequestTemplate.Content,
+		ResponseTemplate: v.ResponseTemplate.Content,
+		Author:           v.Author,
+		CreatedAt:        v.CreatedAt,
+	}
+}
+
+func mapStagedTemplateToDTO(pvd *provider.Provider) *dto.StagedTemplate {
+	staged, ok := pvd.StagedTemplate()
+	if !ok {
+		return nil
+	}
+
+	return &dto.StagedTemplate{
+		Version:          staged.Version,
+		RequestTemplate:  staged.RequestTemplate.Content,
+		ResponseTemplate: staged.ResponseTemplate.Content,
+		Percent:          staged.Percent,
+	}
+}
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// unifiedDiff returns a unified diff of two templates, or an empty string
+// when they are equal.
+func unifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	// fromLine[i] and toLine[i] count the lines of each side before lines[i].
+	fromLine := make([]int, len(lines)+1)
+	toLine := make([]int, len(lines)+1)
+	for i, l := range lines {
+		fromLine[i+1], toLine[i+1] = fromLine[i], toLine[i]
+		if l.op != '+' {
+			fromLine[i+1]++
+		}
+		if l.op != '-' {
+			toLine[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+
+		// Changes separated by no more than twice the context share a hunk.
+		last := first
+		for i := first + 1; i < len(lines); i++ {
+			if lines[i].op == ' ' {
+				continue
+			}
+			if i-last-1 > 2*diffContext {
+				break
+			}
+			last = i
+		}
+
+		begin := max(first-diffContext, 0)
+		end := min(last+diffContext+1, len(lines))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(fromLine[begin], fromLine[end]-fromLine[begin]),
+			hunkRange(toLine[begin], toLine[end]-toLine[begin]))
+		for _, l := range lines[begin:end] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+
+		start = end
+	}
+
+	return out.String()
+}
+
+// hunkRange formats a hunk's start line and length, where before is the
+// number of lines preceding the hunk.
+func hunkRange(before, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, length)
+}
+
+// diffLines returns the shortest edit script between a and b, from their
+// longest common subsequence. Templates are small enough for the quadratic
+// table.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
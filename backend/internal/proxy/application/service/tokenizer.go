@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// ErrContextWindowExceeded is returned when a prompt does not fit the
+// target model's context window.
+var ErrContextWindowExceeded = errors.New("context window exceeded")
+
+// Tokenizer counts the tokens a model family would see for a piece of text.
+type Tokenizer interface {
+	Name() string
+	CountTokens(text string) int
+}
+
+// Tokenizers picks the tokenizer for a model.
+type Tokenizers interface {
+	ForModel(modelKey string) Tokenizer
+}
+
+// Fixed costs the chat formats add around the text itself, and rough flat
+// costs for attachments whose size in tokens cannot be read off the body.
+const (
+	messageOverheadTokens = 4
+	toolOverheadTokens    = 8
+	replyPrimingTokens    = 3
+	imagePartTokens       = 765
+	filePartTokens        = 1500
+)
+
+// countPromptTokens estimates the prompt size of a request: every message,
+// tool call and tool definition.
+func countPromptTokens(tokenizer Tokenizer, request dto.Request) int {
+	tokens := replyPrimingTokens + countMessageTokens(tokenizer, request.Messages)
+	for _, tool := range request.Tools {
+		def := tool.ToolDefinition
+		tokens += toolOverheadTokens +
+			tokenizer.CountTokens(def.Name) +
+			tokenizer.CountTokens(def.Description) +
+			tokenizer.CountTokens(string(def.Parameters.Schema))
+	}
+
+	return tokens
+}
+
+func countMessageTokens(tokenizer Tokenizer, messages []dto.Message) int {
+	tokens := 0
+	for _, msg := range messages {
+		tokens += messageOverheadTokens
+		for _, part := range msg.Content {
+			switch part.Type {
+			case dto.PartTypeImage:
+				tokens += imagePartTokens
+			case dto.PartTypeFile:
+				tokens += filePartTokens
+			default:
+				tokens += tokenizer.CountTokens(part.Body)
+			}
+		}
+
+		for _, tc := range msg.ToolCalls {
+			tokens += tokenizer.CountTokens(tc.Call.Name) + tokenizer.CountTokens(tc.Call.Arg)
+		}
+	}
+
+	return tokens
+}
+
+// fitContextWindow rejects prompts that leave no room for output in the
+// model's context window and trims max_tokens so prompt and output fit.
+// Another target may have a larger window, so the rejection fails over.
+func fitContextWindow(request dto.Request, model dto.Model, promptTokens int) (dto.Request, error) {
+	window := model.Limits.ContextWindow
+	if window <= 0 {
+		return request, nil
+	}
+
+	if promptTokens >= window {
+		return request, fmt.Errorf("%w: %w: prompt is about %d tokens, model %s allows %d",
+			ErrTargetUnavailable, ErrContextWindowExceeded, promptTokens, model.Key, window)
+	}
+
+	if maxTokens := request.Params.MaxTokens; maxTokens != nil && promptTokens+*maxTokens > window {
+		remaining := window - promptTokens
+		request.Params.MaxTokens = &remaining
+	}
+
+	return request, nil
+}
+
+// Tokenize estimates the prompt size of a request for the first routing
+// target whose provider serves the model, without sending it.
+func (s *proxyService) Tokenize(ctx context.Context, request dto.Request) (*dto.TokenizeResponse, error) {
+	request, _, err := prepareRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.candidates(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		providerDTO, _, err := s.cache.provider(ctx, candidate.ProviderID, s.providerService.GetProvider)
+		if err != nil {
+			continue
+		}
+
+		model, ok := providerDTO.Models[candidate.ModelKey]
+		if !ok {
+			continue
+		}
+
+		tokenizer := s.tokenizers.ForModel(model.Key)
+		promptTokens := countPromptTokens(tokenizer, candidate)
+		window := model.Limits.ContextWindow
+
+		return &dto.TokenizeResponse{
+			ProviderID:      candidate.ProviderID,
+			ModelKey:        model.Key,
+			Tokenizer:       tokenizer.Name(),
+			PromptTokens:    promptTokens,
+			ContextWindow:   window,
+			MaxOutputTokens: model.Limits.MaxOutputTokens,
+			Fits:            window <= 0 || promptTokens < window,
+		}, nil
+	}
+
+	model := request.Model
+	if request.ProviderID != "" {
+		model = request.ModelKey
+	}
+	return nil, fmt.Errorf("%w: %s", ErrModelNotFound, model)
+}
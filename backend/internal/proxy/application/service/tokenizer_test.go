@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// runeTokenizer counts one token per character.
+type runeTokenizer struct{}
+
+func (runeTokenizer) Name() string { return "rune" }
+
+func (runeTokenizer) CountTokens(text string) int { return utf8.RuneCountInString(text) }
+
+func TestCountPromptTokens(t *testing.T) {
+	text := func(body string) dto.Message {
+		return dto.Message{Role: dto.MessageRoleUser, Content: dto.Content{{Type: dto.PartTypeText, Body: body}}}
+	}
+
+	tests := []struct {
+		name    string
+		request dto.Request
+		want    int
+	}{
+		{"Empty", dto.Request{}, replyPrimingTokens},
+		{"Text", dto.Request{Messages: []dto.Message{text("hello"), text("你好")}}, replyPrimingTokens + 2*messageOverheadTokens + 7},
+		{"Attachments", dto.Request{Messages: []dto.Message{{Role: dto.MessageRoleUser, Content: dto.Content{
+			{Type: dto.PartTypeImage, Body: "aGVsbG8="},
+			{Type: dto.PartTypeFile, Body: "aGVsbG8="},
+		}}}}, replyPrimingTokens + messageOverheadTokens + imagePartTokens + filePartTokens},
+		{"Tool calls", dto.Request{Messages: []dto.Message{{Role: dto.MessageRoleAssistant, ToolCalls: []dto.ToolCall{
+			{ID: "call_1", Call: dto.FunctionCall{Name: "get", Arg: "{}"}},
+		}}}}, replyPrimingTokens + messageOverheadTokens + 5},
+		{"Tool definitions", dto.Request{Tools: []dto.Tool{{ToolDefinition: dto.ToolDefinition{
+			Name:        "get",
+			Description: "Get it",
+			Parameters:  dto.ParameterSchema{Schema: []byte(`{}`)},
+		}}}}, replyPrimingTokens + toolOverheadTokens + 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countPromptTokens(runeTokenizer{}, tt.request); got != tt.want {
+				t.Errorf("Expected %d tokens, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFitContextWindow(t *testing.T) {
+	model := dto.Model{Key: "sample-model", Limits: dto.Limits{ContextWindow: 1000}}
+
+	tests := []struct {
+		name          string
+		model         dto.Model
+		maxTokens     *int
+		promptTokens  int
+		wantMaxTokens *int
+		wantErr       bool
+	}{
+		{"No window", dto.Model{Key: "sample-model"}, ptr(5000), 5000, ptr(5000), false},
+		{"Fits", model, ptr(200), 800, ptr(200), false},
+		{"Trims max_tokens to the remaining room", model, ptr(500), 800, ptr(200), false},
+		{"No max_tokens", model, nil, 800, nil, false},
+		{"Prompt fills the window", model, nil, 1000, nil, true},
+		{"Prompt exceeds the window", model, ptr(10), 1200, ptr(10), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := dto.Request{Params: dto.GenerationParams{MaxTokens: tt.maxTokens}}
+
+			got, err := fitContextWindow(request, tt.model, tt.promptTokens)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrContextWindowExceeded) || !canFailover(err) {
+					t.Errorf("Expected a context window error that fails over, got %v", err)
+				}
+				return
+			}
+
+			switch {
+			case tt.wantMaxTokens == nil && got.Params.MaxTokens != nil:
+				t.Errorf("Expected no max_tokens, got %d", *got.Params.MaxTokens)
+			case tt.wantMaxTokens != nil && (got.Params.MaxTokens == nil || *got.Params.MaxTokens != *tt.wantMaxTokens):
+				t.Errorf("Expected max_tokens %d, got %v", *tt.wantMaxTokens, got.Params.MaxTokens)
+			}
+		})
+	}
+}
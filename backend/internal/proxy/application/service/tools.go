@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/schema"
+)
+
+// emptyParameters is used for tools that take no arguments.
+var emptyParameters = schema.MustParse(`{"type":"object","properties":{}}`)
+
+// prepareTools validates every tool's parameter schema and derives the flat
+// properties templates can range over.
+func prepareTools(tools []dto.Tool) ([]dto.Tool, error) {
+	prepared := make([]dto.Tool, len(tools))
+	for i, tool := range tools {
+		if !tool.ToolType.IsValid() {
+			return nil, fmt.Errorf("%w: unsupported tool type %q", ErrInvalidRequest, tool.ToolType)
+		}
+
+		if tool.ToolDefinition.Name == "" {
+			return nil, fmt.Errorf("%w: tool %d has no name", ErrInvalidRequest, i)
+		}
+
+		params, err := parameterSchema(tool.ToolDefinition.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid parameters for tool %s: %w", ErrInvalidRequest, tool.ToolDefinition.Name, err)
+		}
+
+		prepared[i] = tool
+		prepared[i].ToolDefinition.Parameters = newParameterSchema(params)
+	}
+
+	return prepared, nil
+}
+
+// parameterSchema compiles the schema a tool was given. Tools built in code
+// may only set the flat properties, which marshal to an equivalent schema.
+func parameterSchema(params dto.ParameterSchema) (*schema.Schema, error) {
+	if len(params.Schema) == 0 && len(params.Properties) == 0 {
+		return emptyParameters, nil
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := schema.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := s.Document().(map[string]any); !ok {
+		return nil, fmt.Errorf("parameters must be an object schema")
+	}
+
+	return s, nil
+}
+
+func newParameterSchema(s *schema.Schema) dto.ParameterSchema {
+	var doc struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	_ = json.Unmarshal(s.Raw(), &doc)
+
+	params := dto.ParameterSchema{
+		Schema:     s.Raw(),
+		Properties: make(map[string]dto.ParameterProperty, len(doc.Properties)),
+		Required:   doc.Required,
+	}
+	for name, raw := range doc.Properties {
+		var prop struct {
+			Type        any    `json:"type"`
+			Description string `json:"description"`
+			Enum        []any  `json:"enum"`
+			Default     any    `json:"default"`
+		}
+		_ = json.Unmarshal(raw, &prop)
+
+		// JSON Schema allows a list of types, e.g. ["string", "null"]
+		propType, _ := prop.Type.(string)
+		if types, ok := prop.Type.([]any); ok && len(types) > 0 {
+			propType, _ = types[0].(string)
+		}
+
+		var enum []string
+		for _, v := range prop.Enum {
+			enum = append(enum, fmt.Sprint(v))
+		}
+
+		params.Properties[name] = dto.ParameterProperty{
+			Type:        propType,
+			Description: prop.Description,
+			Enum:        enum,
+			Default:     prop.Default,
+			Schema:      raw,
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"text/template"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/schema"
+)
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	},
+	// The schema helpers take tool parameters, a single property or a
+	// response format schema and emit it in a provider's native dialect.
+	"jsonSchema": func(v interface{}) (string, error) {
+		return renderSchema(v, func(s *schema.Schema) any { return s.Raw() })
+	},
+	"strictSchema": func(v interface{}) (string, error) {
+		return renderSchema(v, (*schema.Schema).Strict)
+	},
+	"inlineSchema": func(v interface{}) (string, error) {
+		return renderSchema(v, (*schema.Schema).Inline)
+	},
+	"openAPISchema": func(v interface{}) (string, error) {
+		return renderSchema(v, (*schema.Schema).OpenAPI)
+	},
+	// jsonObject passes tool arguments through as a raw JSON object, for
+	// providers that take them as an object instead of a string.
+	"jsonObject": func(s string) string {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(s), &obj); err != nil || obj == nil {
+			return "{}"
+		}
+		return s
+	},
+	// pathEscape escapes a value, such as a model key, for use as a path
+	// segment in an endpoint path template.
+	"pathEscape": url.PathEscape,
+	// add sums token counts, treating missing ones as zero.
+	"add": func(values ...interface{}) int {
+		sum := 0
+		for _, v := range values {
+			switch n := v.(type) {
+			case float64:
+				sum += int(n)
+			case int:
+				sum += n
+			}
+		}
+		return sum
+	},
+}
+
+func renderSchema(v interface{}, transform func(*schema.Schema) any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	s, err := schema.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(transform(s))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+type providerTemplates struct {
+	request  *template.Template
+	response *template.Template
+	// staged, when set, serves stagedPercent of requests.
+	staged        *providerTemplates
+	stagedPercent int
+}
+
+// pick returns the templates to serve one request with, sending the staged
+// version its share of traffic.
+func (t *providerTemplates) pick() *providerTemplates {
+	if t.staged != nil && rand.IntN(100) < t.stagedPercent {
+		return t.staged
+	}
+	return t
+}
+
+func compileTemplates(provider dto.Provider) (*providerTemplates, error) {
+	requestTmpl, err := template.
+		New("request").
+		Funcs(templateFuncs).
+		Parse(provider.RequestTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request template: %w", err)
+	}
+
+	responseTmpl, err := template.
+		New("response").
+		Funcs(templateFuncs).
+		Parse(provider.ResponseTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse response template: %w", err)
+	}
+
+	templates := &providerTemplates{request: requestTmpl, response: responseTmpl}
+	if staged := provider.StagedTemplate; staged != nil {
+		templates.staged, err = compileTemplates(dto.Provider{
+			RequestTemplate:  staged.RequestTemplate,
+			ResponseTemplate: staged.ResponseTemplate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("staged template version %d: %w", staged.Version, err)
+		}
+		templates.stagedPercent = staged.Percent
+	}
+
+	return templates, nil
+}
+
+// buildUpstreamRequest renders a canonical request into the provider's wire
+// format and attaches the provider headers. GET requests carry no body. The
+// request is unauthenticated until it is signed.
+func buildUpstreamRequest(provider dto.Provider, tmpl *template.Template, request dto.Request) (ProxyRequest, error) {
+	route := endpointRoute(provider.Endpoints[request.Endpoint], request.Stream)
+	target, err := upstreamTarget(provider.BaseURL, route, request)
+	if err != nil {
+		return ProxyRequest{}, err
+	}
+
+	var requestBody bytes.Buffer
+	if route.Method != http.MethodGet {
+		if err := tmpl.Execute(&requestBody, request); err != nil {
+			return ProxyRequest{}, fmt.Errorf("failed to execute request template: %w", err)
+		}
+	}
+
+	// Build headers map starting with defaults
+	headers := map[string]string{}
+	if requestBody.Len() > 0 {
+		headers["Content-Type"] = "application/json"
+	}
+	if request.Stream {
+		headers["Accept"] = "text/event-stream"
+	}
+
+	// Add extra headers from provider config
+	for k, v := range provider.Headers {
+		headers[k] = v
+	}
+
+	return ProxyRequest{
+		Target:  target,
+		Method:  route.Method,
+		Headers: headers,
+		Body:    requestBody.Bytes(),
+	}, nil
+}
+
+// renderResponse maps a raw provider JSON body back into the canonical
+// response using the provider's response template.
+func renderResponse(tmpl *template.Template, body []byte) (*dto.Response, error) {
+	// First decode the provider response JSON into a structured format
+	var providerResponse any
+	if err := json.Unmarshal(body, &providerResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse provider response JSON: %w", err)
+	}
+
+	// Convert the provider response back to canonical format using the response template
+	var responseBody bytes.Buffer
+	if err := tmpl.Execute(&responseBody, providerResponse); err != nil {
+		return nil, fmt.Errorf("failed to execute response template: %w", err)
+	}
+
+	// Parse the template output into dto.Response
+	var response dto.Response
+	if err := json.Unmarshal(responseBody.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response template output: %w", err)
+	}
+
+	return &response, nil
+}
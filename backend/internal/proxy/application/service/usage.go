@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/usage"
+	"github.com/basetable/basetable/backend/internal/shared/domain"
+)
+
+// usageAttempt collects what happens while one target serves a request, for
+// the usage record written once the attempt is over.
+type usageAttempt struct {
+	request        dto.Request
+	start          time.Time
+	latency        time.Duration
+	upstreamStatus int
+	usage          dto.Usage
+	cost           int64
+	finishReason   string
+	// errorCode overrides the code derived from the attempt's error.
+	errorCode usage.ErrorCode
+}
+
+func newUsageAttempt(request dto.Request) *usageAttempt {
+	return &usageAttempt{request: request, start: time.Now()}
+}
+
+// ensureRequestID gives requests that arrive without an ID one of their own.
+func ensureRequestID(request dto.Request) dto.Request {
+	if request.RequestID == "" {
+		request.RequestID = domain.GenerateID()
+	}
+	return request
+}
+
+// billed records the usage and cost an attempt is charged for.
+func (a *usageAttempt) billed(u dto.Usage, cost int64) {
+	a.usage = u
+	a.cost = cost
+}
+
+// finished records the finish reason of the first choice that has one.
+func (a *usageAttempt) finished(response *dto.Response) {
+	for _, choice := range response.Choices {
+		if choice.FinishReason != "" {
+			a.finishReason = string(choice.FinishReason)
+			return
+		}
+	}
+}
+
+// recordUsage writes the usage record for an attempt. Recording is best
+// effort: a failed write never fails the request it describes.
+func (s *proxyService) recordUsage(ctx context.Context, attempt *usageAttempt, err error) {
+	latency := attempt.latency
+	if latency == 0 {
+		latency = time.Since(attempt.start)
+	}
+
+	code := attempt.errorCode
+	if code == usage.ErrorCodeNone && err != nil {
+		code = errorCode(err)
+	}
+
+	request := attempt.request
+	record, recErr := usage.New(usage.Attempt{
+		RequestID:        request.RequestID,
+		AccountID:        request.AccountID,
+		ProviderID:       request.ProviderID,
+		ModelKey:         request.ModelKey,
+		Endpoint:         request.Endpoint,
+		Stream:           request.Stream,
+		PromptTokens:     attempt.usage.PromptTokens,
+		CompletionTokens: attempt.usage.CompletionTokens,
+		Cost:             attempt.cost,
+		Latency:          latency,
+		UpstreamStatus:   attempt.upstreamStatus,
+		FinishReason:     attempt.finishReason,
+		ErrorCode:        code,
+	})
+	if recErr == nil {
+		recErr = s.usageRepository.Save(context.WithoutCancel(ctx), record)
+	}
+	if recErr != nil && s.logger != nil {
+		s.logger.Errorf("Failed to record usage for request %s: %v", request.RequestID, recErr)
+	}
+}
+
+// rejected records a request turned away before any target was tried and
+// returns the error it was turned away with.
+func (s *proxyService) rejected(ctx context.Context, request dto.Request, err error) error {
+	s.recordUsage(ctx, newUsageAttempt(request), err)
+	return err
+}
+
+// errorCode maps an attempt's error onto the canonical usage error codes.
+func errorCode(err error) usage.ErrorCode {
+	var upstreamErr *UpstreamError
+	switch {
+	case err == nil:
+		return usage.ErrorCodeNone
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return usage.ErrorCodeCanceled
+	case errors.Is(err, ErrContextWindowExceeded):
+		return usage.ErrorCodeContextWindowExceeded
+	case errors.Is(err, ErrInvalidRequest):
+		return usage.ErrorCodeInvalidRequest
+	case errors.Is(err, ErrModelNotFound):
+		return usage.ErrorCodeModelNotFound
+	case errors.Is(err, ErrResponseFormatMismatch):
+		return usage.ErrorCodeResponseFormatMismatch
+	case errors.Is(err, ErrTargetUnavailable):
+		return usage.ErrorCodeTargetUnavailable
+	case errors.As(err, &upstreamErr):
+		switch {
+		case upstreamErr.StatusCode == 0:
+			return usage.ErrorCodeUpstreamUnreachable
+		case upstreamErr.StatusCode == http.StatusTooManyRequests:
+			return usage.ErrorCodeUpstreamRateLimited
+		case upstreamErr.StatusCode < 500:
+			return usage.ErrorCodeUpstreamRejected
+		default:
+			return usage.ErrorCodeUpstreamError
+		}
+	default:
+		return usage.ErrorCodeInternal
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/usage"
+)
+
+// savedUsage keeps the usage records written to it.
+type savedUsage struct {
+	repository.UsageRepository
+	records []*usage.Record
+}
+
+func (r *savedUsage) Save(_ context.Context, record *usage.Record) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+// unknownModels resolves no logical model.
+type unknownModels struct{ RouteService }
+
+func (unknownModels) ResolveTargets(_ context.Context, model string) ([]dto.Target, error) {
+	return nil, fmt.Errorf("%w: %s", ErrModelNotFound, model)
+}
+
+func TestRejectedRequestUsage(t *testing.T) {
+	temperature := 3.0
+	tests := []struct {
+		name     string
+		request  dto.Request
+		stream   bool
+		wantCode usage.ErrorCode
+	}{
+		{"Invalid parameters", dto.Request{Model: "gpt-4o", Params: dto.GenerationParams{Temperature: &temperature}}, false, usage.ErrorCodeInvalidRequest},
+		{"Unknown model", dto.Request{Model: "missing"}, false, usage.ErrorCodeModelNotFound},
+		{"Unknown model when streaming", dto.Request{Model: "missing"}, true, usage.ErrorCodeModelNotFound},
+		{"Validated response format when streaming", dto.Request{Model: "gpt-4o", ResponseFormat: dto.ResponseFormat{Validate: true}}, true, usage.ErrorCodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := &savedUsage{}
+			s := &proxyService{routeService: unknownModels{}, usageRepository: records}
+
+			request := tt.request
+			request.AccountID = "account-1"
+			var err error
+			if tt.stream {
+				_, err = s.ProxyRequestStream(context.Background(), request)
+			} else {
+				_, err = s.ProxyRequest(context.Background(), request)
+			}
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+
+			if len(records.records) != 1 {
+				t.Fatalf("Expected 1 usage record, got %d", len(records.records))
+			}
+			record := records.records[0]
+			if record.ErrorCode() != tt.wantCode {
+				t.Errorf("Expected error code %s, got %s", tt.wantCode, record.ErrorCode())
+			}
+			if record.RequestID() == "" || record.AccountID() != "account-1" || record.Stream() != tt.stream {
+				t.Errorf("Expected the record to describe the request, got %+v", record)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+)
+
+// Version is the catalog file format this package reads and writes.
+const Version = 1
+
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+func (f Format) IsValid() bool {
+	return f == FormatYAML || f == FormatJSON
+}
+
+// file is the on-disk shape of a catalog. JSON is valid YAML, so one decoder
+// reads both.
+type file struct {
+	Version   int        `yaml:"version" json:"version"`
+	Providers []provider `yaml:"providers" json:"providers"`
+}
+
+type provider struct {
+	Name        string            `yaml:"name" json:"name"`
+	BaseURL     string            `yaml:"base_url" json:"base_url"`
+	Status      string            `yaml:"status,omitempty" json:"status,omitempty"`
+	Auth        auth              `yaml:"auth" json:"auth"`
+	Headers     map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	HealthProbe *healthProbe      `yaml:"health_probe,omitempty" json:"health_probe,omitempty"`
+	Endpoints   []endpoint        `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
+	Models      []model           `yaml:"models,omitempty" json:"models,omitempty"`
+	Templates   templates         `yaml:"templates" json:"templates"`
+}
+
+type auth struct {
+	Type       string        `yaml:"type,omitempty" json:"type,omitempty"`
+	Header     string        `yaml:"header,omitempty" json:"header,omitempty"`
+	Prefix     string        `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	QueryParam string        `yaml:"query_param,omitempty" json:"query_param,omitempty"`
+	OAuth2     *oauth2Config `yaml:"oauth2,omitempty" json:"oauth2,omitempty"`
+	SigV4      *sigV4Config  `yaml:"sigv4,omitempty" json:"sigv4,omitempty"`
+	Credential string        `yaml:"credential,omitempty" json:"credential,omitempty"`
+	// CredentialEnv names an environment variable holding the credential.
+	// It is only honoured by Load, so catalogs posted to the API cannot read
+	// the server's environment.
+	CredentialEnv string `yaml:"credential_env,omitempty" json:"credential_env,omitempty"`
+}
+
+type oauth2Config struct {
+	TokenURL string   `yaml:"token_url" json:"token_url"`
+	ClientID string   `yaml:"client_id" json:"client_id"`
+	Scopes   []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+type sigV4Config struct {
+	AccessKeyID string `yaml:"access_key_id" json:"access_key_id"`
+	Region      string `yaml:"region" json:"region"`
+	Service     string `yaml:"service" json:"service"`
+}
+
+type healthProbe struct {
+	ModelKey string `yaml:"model_key,omitempty" json:"model_key,omitempty"`
+	Prompt   string `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+}
+
+// endpoint is where requests to one of a provider's endpoints go. Path and
+// query values are templates rendered against the request; stream, when
+// set, is the route taken by streaming requests, and probe the route the
+// health checker requests instead of sending the probe prompt.
+type endpoint struct {
+	Name   string            `yaml:"name" json:"name"`
+	Method string            `yaml:"method,omitempty" json:"method,omitempty"`
+	Path   string            `yaml:"path" json:"path"`
+	Query  map[string]string `yaml:"query,omitempty" json:"query,omitempty"`
+	Stream *endpointRoute    `yaml:"stream,omitempty" json:"stream,omitempty"`
+	Probe  *endpointRoute    `yaml:"probe,omitempty" json:"probe,omitempty"`
+	Status string            `yaml:"status,omitempty" json:"status,omitempty"`
+}
+
+type endpointRoute struct {
+	Method string            `yaml:"method,omitempty" json:"method,omitempty"`
+	Path   string            `yaml:"path" json:"path"`
+	Query  map[string]string `yaml:"query,omitempty" json:"query,omitempty"`
+}
+
+type model struct {
+	Key          string       `yaml:"key" json:"key"`
+	Name         string       `yaml:"name" json:"name"`
+	Description  string       `yaml:"description,omitempty" json:"description,omitempty"`
+	Capabilities capabilities `yaml:"capabilities" json:"capabilities"`
+	Limits       limits       `yaml:"limits,omitempty" json:"limits,omitempty"`
+	Pricing      pricing      `yaml:"pricing" json:"pricing"`
+}
+
+type capabilities struct {
+	FunctionCalling  bool `yaml:"function_calling" json:"function_calling"`
+	Streaming        bool `yaml:"streaming" json:"streaming"`
+	StructuredOutput bool `yaml:"structured_output" json:"structured_output"`
+}
+
+type limits struct {
+	ContextWindow    int `yaml:"context_window,omitempty" json:"context_window,omitempty"`
+	MaxOutputTokens  int `yaml:"max_output_tokens,omitempty" json:"max_output_tokens,omitempty"`
+	MaxStopSequences int `yaml:"max_stop_sequences,omitempty" json:"max_stop_sequences,omitempty"`
+}
+
+// pricing lists the base prompt and completion prices as shorthands; rules
+// holds everything else, such as long-context tiers and cached tokens.
+type pricing struct {
+	PromptTokenPrice     price       `yaml:"prompt_token_price,omitempty" json:"prompt_token_price,omitempty"`
+	CompletionTokenPrice price       `yaml:"completion_token_price,omitempty" json:"completion_token_price,omitempty"`
+	Currency             string      `yaml:"currency" json:"currency"`
+	Unit                 string      `yaml:"unit,omitempty" json:"unit,omitempty"`
+	Rules                []priceRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+type priceRule struct {
+	Dimension         string `yaml:"dimension" json:"dimension"`
+	Price             price  `yaml:"price" json:"price"`
+	AbovePromptTokens int    `yaml:"above_prompt_tokens,omitempty" json:"above_prompt_tokens,omitempty"`
+}
+
+// price is a decimal kept as written, so prices round-trip exactly. Files
+// may write it as a number or a string.
+type price string
+
+func (p price) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: string(p)}, nil
+}
+
+func (p price) MarshalJSON() ([]byte, error) {
+	return json.Marshal(json.Number(p))
+}
+
+func (p *price) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*p = price(n)
+	return nil
+}
+
+type templates struct {
+	Request  string `yaml:"request" json:"request"`
+	Response string `yaml:"response" json:"response"`
+}
+
+// Decode parses a YAML or JSON catalog received over the API.
+func Decode(data []byte) (dto.Catalog, error) {
+	f, err := decode(data)
+	if err != nil {
+		return dto.Catalog{}, err
+	}
+
+	for _, p := range f.Providers {
+		if p.Auth.CredentialEnv != "" {
+			return dto.Catalog{}, fmt.Errorf("provider %s: credential_env is only supported in seed files", p.Name)
+		}
+	}
+
+	return f.toDTO(), nil
+}
+
+// Load reads a catalog file, or every .yaml, .yml and .json file in a
+// directory, resolving credential_env with getenv.
+func Load(path string, getenv func(string) string) (dto.Catalog, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return dto.Catalog{}, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return dto.Catalog{}, err
+		}
+
+		paths = paths[:0]
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					paths = append(paths, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+		slices.Sort(paths)
+	}
+
+	var catalog dto.Catalog
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return dto.Catalog{}, err
+		}
+
+		f, err := decode(data)
+		if err != nil {
+			return dto.Catalog{}, fmt.Errorf("%s: %w", p, err)
+		}
+
+		for i, pvd := range f.Providers {
+			if name := pvd.Auth.CredentialEnv; name != "" {
+				if pvd.Auth.Credential != "" {
+					return dto.Catalog{}, fmt.Errorf("%s: provider %s sets both credential and credential_env", p, pvd.Name)
+				}
+
+				f.Providers[i].Auth.Credential = getenv(name)
+				if f.Providers[i].Auth.Credential == "" {
+					return dto.Catalog{}, fmt.Errorf("%s: provider %s: environment variable %s is not set", p, pvd.Name, name)
+				}
+			}
+		}
+
+		catalog.Providers = append(catalog.Providers, f.toDTO().Providers...)
+	}
+
+	return catalog, nil
+}
+
+// Encode writes a catalog in the given format. Credentials are never
+// written.
+func Encode(catalog dto.Catalog, format Format) ([]byte, error) {
+	f := fromDTO(catalog)
+
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(f, "", "  ")
+
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(f); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown catalog format %q", format)
+	}
+}
+
+func decode(data []byte) (file, error) {
+	var f file
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return file{}, fmt.Errorf("failed to parse catalog: %w", err)
+	}
+
+	if f.Version != 0 && f.Version != Version {
+		return file{}, fmt.Errorf("unsupported catalog version %d", f.Version)
+	}
+
+	return f, nil
+}
+
+func (f file) toDTO() dto.Catalog {
+	catalog := dto.Catalog{Providers: make([]dto.CatalogProvider, 0, len(f.Providers))}
+	for _, p := range f.Providers {
+		cp := dto.CatalogProvider{
+			Name:    p.Name,
+			BaseURL: p.BaseURL,
+			Status:  p.Status,
+			Auth: dto.CatalogAuth{
+				Type:       p.Auth.Type,
+				Header:     p.Auth.Header,
+				Prefix:     p.Auth.Prefix,
+				QueryParam: p.Auth.QueryParam,
+				Credential: p.Auth.Credential,
+			},
+			Headers:          p.Headers,
+			RequestTemplate:  p.Templates.Request,
+			ResponseTemplate: p.Templates.Response,
+		}
+
+		if o := p.Auth.OAuth2; o != nil {
+			cp.Auth.OAuth2 = dto.OAuth2Config{TokenURL: o.TokenURL, ClientID: o.ClientID, Scopes: o.Scopes}
+		}
+		if v := p.Auth.SigV4; v != nil {
+			cp.Auth.SigV4 = dto.SigV4Config{AccessKeyID: v.AccessKeyID, Region: v.Region, Service: v.Service}
+		}
+
+		if p.HealthProbe != nil {
+			cp.HealthProbe = dto.HealthProbe{ModelKey: p.HealthProbe.ModelKey, Prompt: p.HealthProbe.Prompt}
+		}
+
+		for _, ep := range p.Endpoints {
+			e := dto.Endpoint{Name: ep.Name, Method: ep.Method, Path: ep.Path, Query: ep.Query, Status: ep.Status}
+			if ep.Stream != nil {
+				e.Stream = &dto.EndpointRoute{Method: ep.Stream.Method, Path: ep.Stream.Path, Query: ep.Stream.Query}
+			}
+			if ep.Probe != nil {
+				e.Probe = &dto.EndpointRoute{Method: ep.Probe.Method, Path: ep.Probe.Path, Query: ep.Probe.Query}
+			}
+			cp.Endpoints = append(cp.Endpoints, e)
+		}
+
+		for _, m := range p.Models {
+			cp.Models = append(cp.Models, dto.Model{
+				Key:         m.Key,
+				Name:        m.Name,
+				Description: m.Description,
+				Capabilities: dto.Capabilities{
+					FunctionCalling:  m.Capabilities.FunctionCalling,
+					Streaming:        m.Capabilities.Streaming,
+					StructuredOutput: m.Capabilities.StructuredOutput,
+				},
+				Limits: dto.Limits{
+					ContextWindow:    m.Limits.ContextWindow,
+					MaxOutputTokens:  m.Limits.MaxOutputTokens,
+					MaxStopSequences: m.Limits.MaxStopSequences,
+				},
+				Pricing: pricingToDTO(m.Pricing),
+			})
+		}
+
+		catalog.Providers = append(catalog.Providers, cp)
+	}
+
+	return catalog
+}
+
+func fromDTO(catalog dto.Catalog) file {
+	f := file{Version: Version, Providers: make([]provider, 0, len(catalog.Providers))}
+	for _, cp := range catalog.Providers {
+		p := provider{
+			Name:    cp.Name,
+			BaseURL: cp.BaseURL,
+			Status:  cp.Status,
+			Auth: auth{
+				Type:       cp.Auth.Type,
+				Header:     cp.Auth.Header,
+				Prefix:     cp.Auth.Prefix,
+				QueryParam: cp.Auth.QueryParam,
+			},
+			Headers:   cp.Headers,
+			Templates: templates{Request: cp.RequestTemplate, Response: cp.ResponseTemplate},
+		}
+
+		if o := cp.Auth.OAuth2; o.TokenURL != "" {
+			p.Auth.OAuth2 = &oauth2Config{TokenURL: o.TokenURL, ClientID: o.ClientID, Scopes: o.Scopes}
+		}
+		if v := cp.Auth.SigV4; v != (dto.SigV4Config{}) {
+			p.Auth.SigV4 = &sigV4Config{AccessKeyID: v.AccessKeyID, Region: v.Region, Service: v.Service}
+		}
+
+		if cp.HealthProbe != (dto.HealthProbe{}) {
+			p.HealthProbe = &healthProbe{ModelKey: cp.HealthProbe.ModelKey, Prompt: cp.HealthProbe.Prompt}
+		}
+
+		for _, ep := range cp.Endpoints {
+			e := endpoint{Name: ep.Name, Method: omitDefaultMethod(ep.Method), Path: ep.Path, Query: ep.Query, Status: ep.Status}
+			if ep.Stream != nil {
+				e.Stream = &endpointRoute{Method: omitDefaultMethod(ep.Stream.Method), Path: ep.Stream.Path, Query: ep.Stream.Query}
+			}
+			if ep.Probe != nil {
+				e.Probe = &endpointRoute{Method: omitDefaultMethod(ep.Probe.Method), Path: ep.Probe.Path, Query: ep.Probe.Query}
+			}
+			p.Endpoints = append(p.Endpoints, e)
+		}
+
+		for _, m := range cp.Models {
+			p.Models = append(p.Models, model{
+				Key:         m.Key,
+				Name:        m.Name,
+				Description: m.Description,
+				Capabilities: capabilities{
+					FunctionCalling:  m.Capabilities.FunctionCalling,
+					Streaming:        m.Capabilities.Streaming,
+					StructuredOutput: m.Capabilities.StructuredOutput,
+				},
+				Limits: limits{
+					ContextWindow:    m.Limits.ContextWindow,
+					MaxOutputTokens:  m.Limits.MaxOutputTokens,
+					MaxStopSequences: m.Limits.MaxStopSequences,
+				},
+				Pricing: pricingFromDTO(m.Pricing),
+			})
+		}
+
+		f.Providers = append(f.Providers, p)
+	}
+
+	return f
+}
+
+func pricingToDTO(p pricing) dto.Pricing {
+	rules := make([]dto.PriceRule, len(p.Rules))
+	for i, r := range p.Rules {
+		rules[i] = dto.PriceRule{Dimension: r.Dimension, Price: string(r.Price), AbovePromptTokens: r.AbovePromptTokens}
+	}
+
+	return dto.Pricing{
+		PromptTokenPrice:     string(p.PromptTokenPrice),
+		CompletionTokenPrice: string(p.CompletionTokenPrice),
+		Currency:             p.Currency,
+		Unit:                 p.Unit,
+		Rules:                rules,
+	}
+}
+
+// pricingFromDTO leaves out the base prompt and completion rules, which the
+// shorthands already carry.
+func pricingFromDTO(p dto.Pricing) pricing {
+	out := pricing{
+		PromptTokenPrice:     price(p.PromptTokenPrice),
+		CompletionTokenPrice: price(p.CompletionTokenPrice),
+		Currency:             p.Currency,
+		Unit:                 p.Unit,
+	}
+
+	for _, r := range p.Rules {
+		if r.AbovePromptTokens == 0 && (r.Dimension == "prompt_tokens" || r.Dimension == "completion_tokens") {
+			continue
+		}
+		out.Rules = append(out.Rules, priceRule{Dimension: r.Dimension, Price: price(r.Price), AbovePromptTokens: r.AbovePromptTokens})
+	}
+
+	return out
+}
+
+// omitDefaultMethod leaves POST, which endpoints use unless told otherwise,
+// out of exported files.
+func omitDefaultMethod(method string) string {
+	if method == http.MethodPost {
+		return ""
+	}
+	return method
+}
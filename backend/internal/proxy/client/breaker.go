@@ -0,0 +1,153 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+)
+
+// ErrCircuitOpen is returned without contacting the upstream while its
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type circuitState string
+
+const (
+	circuitClosed   circuitState = "closed"
+	circuitOpen     circuitState = "open"
+	circuitHalfOpen circuitState = "half-open"
+)
+
+// BreakerConfig configures the per-target circuit breakers.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before a probe is let through.
+	OpenTimeout time.Duration
+}
+
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerOpenTimeout      = 30 * time.Second
+)
+
+type breaker struct {
+	mu          sync.Mutex
+	state       circuitState
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	threshold   int
+	openTimeout time.Duration
+}
+
+// allow reports whether a request may be sent. Once the open timeout has
+// elapsed a single probe is let through in the half-open state; its outcome
+// closes or re-opens the breaker.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if now.Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return true
+
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = circuitClosed
+	b.failures = 0
+	b.probing = false
+	b.openedAt = time.Time{}
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.state = circuitOpen
+		b.openedAt = now
+	}
+}
+
+// release gives up a half-open probe slot without recording an outcome.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) snapshot() service.CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return service.CircuitState{
+		State:               string(b.state),
+		ConsecutiveFailures: b.failures,
+		OpenedAt:            b.openedAt,
+	}
+}
+
+// breakers holds one breaker per upstream target, created on first use.
+type breakers struct {
+	mu     sync.Mutex
+	byKey  map[string]*breaker
+	config BreakerConfig
+}
+
+func newBreakers(cfg BreakerConfig) *breakers {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultBreakerFailureThreshold
+	}
+
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultBreakerOpenTimeout
+	}
+
+	return &breakers{
+		byKey:  make(map[string]*breaker),
+		config: cfg,
+	}
+}
+
+func (bs *breakers) get(target string) *breaker {
+	key := service.CircuitKey(target)
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	b, ok := bs.byKey[key]
+	if !ok {
+		b = &breaker{
+			state:       circuitClosed,
+			threshold:   bs.config.FailureThreshold,
+			openTimeout: bs.config.OpenTimeout,
+		}
+		bs.byKey[key] = b
+	}
+	return b
+}
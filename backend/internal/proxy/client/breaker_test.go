@@ -0,0 +1,55 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreakers(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}).get("https://api.example.com/v1/chat")
+
+	tests := []struct {
+		name      string
+		at        time.Duration
+		apply     func(now time.Time)
+		wantAllow bool
+		wantState circuitState
+	}{
+		{"Closed", 0, nil, true, circuitClosed},
+		{"Below the threshold", 0, b.failure, true, circuitClosed},
+		{"At the threshold", 0, b.failure, false, circuitOpen},
+		{"Before the open timeout", 59 * time.Second, nil, false, circuitOpen},
+		{"Probe after the open timeout", time.Minute, nil, true, circuitHalfOpen},
+		{"Only one probe", time.Minute, nil, false, circuitHalfOpen},
+		{"Probe fails", time.Minute, b.failure, false, circuitOpen},
+		{"Probe after the next timeout", 2 * time.Minute, nil, true, circuitHalfOpen},
+		{"Probe cancelled by the caller", 2 * time.Minute, func(time.Time) { b.release() }, true, circuitHalfOpen},
+		{"Probe succeeds", 2 * time.Minute, func(time.Time) { b.success() }, true, circuitClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start.Add(tt.at)
+			if tt.apply != nil {
+				tt.apply(now)
+			}
+
+			if got := b.allow(now); got != tt.wantAllow {
+				t.Errorf("Expected allow %t, got %t", tt.wantAllow, got)
+			}
+			if got := b.snapshot().State; got != string(tt.wantState) {
+				t.Errorf("Expected state %s, got %s", tt.wantState, got)
+			}
+		})
+	}
+}
+
+func TestBreakersPerTarget(t *testing.T) {
+	bs := newBreakers(BreakerConfig{FailureThreshold: 1})
+	bs.get("https://a.example.com/v1/chat").failure(time.Now())
+
+	if bs.get("https://b.example.com/v1/chat").snapshot().State != string(circuitClosed) {
+		t.Error("Expected a failure on one target to leave other targets closed")
+	}
+}
@@ -3,9 +3,11 @@ package client
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/basetable/basetable/backend/internal/proxy/application/service"
@@ -15,10 +17,38 @@ import (
 type HTTPProxyClient struct {
 	regularClient   *http.Client // For regular API calls with shorter timeout
 	streamingClient *http.Client // For streaming requests with longer timeout
+	retry           RetryPolicy
+	breakers        *breakers
 }
 
-// NewHTTPProxyClient creates a new HTTP proxy client with configurable timeout and connection pooling
-func NewHTTPProxyClient(timeout time.Duration) service.ProxyClient {
+var (
+	_ service.ProxyClient      = (*HTTPProxyClient)(nil)
+	_ service.CircuitInspector = (*HTTPProxyClient)(nil)
+)
+
+type HTTPProxyClientConfig struct {
+	Timeout          time.Duration
+	StreamingTimeout time.Duration
+	Retry            RetryPolicy
+	Breaker          BreakerConfig
+}
+
+const (
+	DefaultTimeout          = 60 * time.Second
+	DefaultStreamingTimeout = 600 * time.Second
+)
+
+// NewHTTPProxyClient creates a new HTTP proxy client with connection pooling,
+// retries and per-target circuit breaking
+func NewHTTPProxyClient(cfg HTTPProxyClientConfig) *HTTPProxyClient {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	if cfg.StreamingTimeout <= 0 {
+		cfg.StreamingTimeout = DefaultStreamingTimeout
+	}
+
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 25,
@@ -30,34 +60,28 @@ func NewHTTPProxyClient(timeout time.Duration) service.ProxyClient {
 
 	return &HTTPProxyClient{
 		regularClient: &http.Client{
-			Timeout:   timeout,
+			Timeout:   cfg.Timeout,
 			Transport: transport,
 		},
 		streamingClient: &http.Client{
-			Timeout:   600 * time.Second, // Longer timeout for streaming
+			Timeout:   cfg.StreamingTimeout,
 			Transport: transport,
 		},
+		retry:    cfg.Retry.withDefaults(),
+		breakers: newBreakers(cfg.Breaker),
 	}
 }
 
-// NewDefaultHTTPProxyClient creates a new HTTP proxy client with default 90 second timeout
-func NewDefaultHTTPProxyClient() service.ProxyClient {
-	return NewHTTPProxyClient(60 * time.Second)
+// NewDefaultHTTPProxyClient creates a new HTTP proxy client with default settings
+func NewDefaultHTTPProxyClient() *HTTPProxyClient {
+	return NewHTTPProxyClient(HTTPProxyClientConfig{})
 }
 
 // ProxyRequest implements the ProxyClient interface
 func (c *HTTPProxyClient) ProxyRequest(ctx context.Context, request service.ProxyRequest) (service.ProxyResponse, error) {
 	startTime := time.Now()
-	req, err := http.NewRequestWithContext(ctx, request.Method, request.Target, bytes.NewReader(request.Body))
-	if err != nil {
-		return service.ProxyResponse{}, err
-	}
-
-	for key, value := range request.Headers {
-		req.Header.Set(key, value)
-	}
 
-	resp, err := c.regularClient.Do(req)
+	resp, err := c.do(ctx, c.regularClient, request)
 	if err != nil {
 		return service.ProxyResponse{}, err
 	}
@@ -69,37 +93,118 @@ func (c *HTTPProxyClient) ProxyRequest(ctx context.Context, request service.Prox
 	}
 
 	latency := time.Since(startTime)
+	retryAfter, _ := parseRetryAfter(resp.Header, time.Now())
 
 	return service.ProxyResponse{
 		StatusCode: resp.StatusCode,
 		Body:       body,
 		Latency:    latency,
+		RetryAfter: retryAfter,
 	}, nil
 }
 
 // ProxyRequestStream implements the ProxyClient interface for streaming requests
 func (c *HTTPProxyClient) ProxyRequestStream(ctx context.Context, request service.ProxyRequest) (io.ReadCloser, error) {
-	req, err := http.NewRequestWithContext(ctx, request.Method, request.Target, bytes.NewReader(request.Body))
-	if err != nil {
-		return nil, err
-	}
-
-	// Set headers
-	for key, value := range request.Headers {
-		req.Header.Set(key, value)
-	}
-
-	resp, err := c.streamingClient.Do(req)
+	resp, err := c.do(ctx, c.streamingClient, request)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode >= 400 {
 		b, _ := io.ReadAll(resp.Body)
-		fmt.Println(string(b))
 		resp.Body.Close()
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+		retryAfter, _ := parseRetryAfter(resp.Header, time.Now())
+		return nil, &service.UpstreamError{StatusCode: resp.StatusCode, RetryAfter: retryAfter, Err: errors.New(string(b))}
 	}
 
 	return resp.Body, nil
 }
+
+// CircuitState implements the CircuitInspector interface
+func (c *HTTPProxyClient) CircuitState(target string) service.CircuitState {
+	return c.breakers.get(target).snapshot()
+}
+
+// do sends the request through the target's circuit breaker, retrying
+// retryable failures with backoff. The returned response may still carry an
+// error status once retries are exhausted.
+func (c *HTTPProxyClient) do(ctx context.Context, httpClient *http.Client, request service.ProxyRequest) (*http.Response, error) {
+	b := c.breakers.get(request.Target)
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, request.Method, request.Target, bytes.NewReader(request.Body))
+		if err != nil {
+			return nil, redactURLError(err)
+		}
+
+		for key, value := range request.Headers {
+			req.Header.Set(key, value)
+		}
+
+		if !b.allow(time.Now()) {
+			return nil, &service.UpstreamError{Err: ErrCircuitOpen}
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			err = redactURLError(err)
+			if ctx.Err() != nil {
+				// The caller gave up; that says nothing about the upstream.
+				b.release()
+				return nil, err
+			}
+
+			b.failure(time.Now())
+			if attempt < c.retry.MaxAttempts && isConnectError(err) {
+				if err := sleep(ctx, c.retry.backoff(attempt)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+
+		if resp.StatusCode >= 500 {
+			b.failure(time.Now())
+		} else {
+			b.success()
+		}
+
+		if attempt < c.retry.MaxAttempts && c.retryStatus(request, resp.StatusCode) {
+			if delay, ok := c.retry.delay(attempt, resp.Header); ok {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				if err := sleep(ctx, delay); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+// retryStatus reports whether a response with the given status is retried.
+// Rate limits are returned to callers that rotate keys on them, so the key
+// cools down instead of being retried.
+func (c *HTTPProxyClient) retryStatus(request service.ProxyRequest, status int) bool {
+	if status == http.StatusTooManyRequests && request.ReturnRateLimits {
+		return false
+	}
+	return c.retry.retryableStatus(status)
+}
+
+// redactURLError hides the query string of the URL a transport error names.
+// Providers that authenticate with a query parameter carry the key there,
+// and errors end up in logs and client responses.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if i := strings.IndexByte(urlErr.URL, '?'); i >= 0 {
+			urlErr.URL = urlErr.URL[:i] + "?redacted"
+		}
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+)
+
+func TestProxyRequestRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		statuses         []int
+		maxAttempts      int
+		returnRateLimits bool
+		wantStatus       int
+		wantAttempts     int32
+	}{
+		{"Recovers after retryable failures", []int{503, 503, 200}, 3, false, http.StatusOK, 3},
+		{"Returns the last failure once attempts run out", []int{503, 503, 200}, 2, false, http.StatusServiceUnavailable, 2},
+		{"Does not retry other failures", []int{400, 200}, 3, false, http.StatusBadRequest, 1},
+		{"Retries rate limits", []int{429, 200}, 3, false, http.StatusOK, 2},
+		{"Returns rate limits when asked", []int{429, 200}, 3, true, http.StatusTooManyRequests, 1},
+		{"Retries other failures when returning rate limits", []int{503, 200}, 3, true, http.StatusOK, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			client := NewHTTPProxyClient(HTTPProxyClientConfig{
+				Retry: RetryPolicy{MaxAttempts: tt.maxAttempts, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+			})
+
+			resp, err := client.ProxyRequest(context.Background(), service.ProxyRequest{
+				Target:           server.URL,
+				Method:           http.MethodPost,
+				ReturnRateLimits: tt.returnRateLimits,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+		})
+	}
+}
+
+func TestProxyRequestCancelReleasesProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewHTTPProxyClient(HTTPProxyClientConfig{Breaker: BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Millisecond}})
+	b := client.breakers.get(server.URL)
+	b.failure(time.Now().Add(-time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The probe is let through and then abandoned by the caller.
+	if _, err := client.ProxyRequest(ctx, service.ProxyRequest{Target: server.URL, Method: http.MethodPost}); err == nil {
+		t.Fatal("Expected the cancelled request to fail")
+	}
+
+	if state := b.snapshot().State; state != string(circuitHalfOpen) {
+		t.Errorf("Expected state %s, got %s", circuitHalfOpen, state)
+	}
+	if !b.allow(time.Now()) {
+		t.Error("Expected the probe slot to be free again")
+	}
+}
+
+func TestTransportErrorsRedactQuery(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	tests := []struct {
+		name   string
+		target string
+		stream bool
+	}{
+		{"Unreachable upstream", server.URL + "/v1/chat?key=sk-secret", false},
+		{"Unreachable upstream when streaming", server.URL + "/v1/chat?alt=sse&key=sk-secret", true},
+		{"Malformed target", "http://api example.com/v1/chat?key=sk-secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewHTTPProxyClient(HTTPProxyClientConfig{Retry: RetryPolicy{MaxAttempts: 1}})
+			request := service.ProxyRequest{Target: tt.target, Method: http.MethodPost}
+
+			var err error
+			if tt.stream {
+				_, err = client.ProxyRequestStream(context.Background(), request)
+			} else {
+				_, err = client.ProxyRequest(context.Background(), request)
+			}
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if strings.Contains(err.Error(), "sk-secret") {
+				t.Errorf("Expected the key to be redacted, got %v", err)
+			}
+		})
+	}
+}
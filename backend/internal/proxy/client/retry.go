@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed upstream calls are retried. Only failures
+// that are safe to repeat are retried: connection errors, where the request
+// never reached the provider, and the statuses in RetryableStatuses.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	// MaxDelay caps the backoff. A Retry-After longer than this is not waited
+	// out; the response is returned instead.
+	MaxDelay          time.Duration
+	RetryableStatuses []int
+}
+
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryBaseDelay   = 200 * time.Millisecond
+	DefaultRetryMaxDelay    = 5 * time.Second
+)
+
+var DefaultRetryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryBaseDelay
+	}
+
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryMaxDelay
+	}
+
+	if p.RetryableStatuses == nil {
+		p.RetryableStatuses = DefaultRetryableStatuses
+	}
+
+	return p
+}
+
+func (p RetryPolicy) retryableStatus(status int) bool {
+	return slices.Contains(p.RetryableStatuses, status)
+}
+
+// backoff returns a fully jittered exponential delay for the given retry
+// (1 for the first retry).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return rand.N(delay) + 1
+}
+
+// delay returns how long to wait before the given retry and whether the
+// retry should happen at all.
+func (p RetryPolicy) delay(retry int, header http.Header) (time.Duration, bool) {
+	backoff := p.backoff(retry)
+
+	retryAfter, ok := parseRetryAfter(header, time.Now())
+	if !ok {
+		return backoff, true
+	}
+	if retryAfter > p.MaxDelay {
+		return 0, false
+	}
+	return max(retryAfter, backoff), true
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// isConnectError reports whether the request failed before any of it was
+// sent, which makes it safe to retry regardless of method.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"Missing", "", 0, false},
+		{"Seconds", "3", 3 * time.Second, true},
+		{"Zero seconds", "0", 0, true},
+		{"Negative seconds", "-1", 0, false},
+		{"HTTP date", now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{"HTTP date in the past", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"Malformed", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.value != "" {
+				header.Set("Retry-After", tt.value)
+			}
+
+			got, ok := parseRetryAfter(header, now)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Expected %v (%t), got %v (%t)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}.withDefaults()
+
+	tests := []struct {
+		name  string
+		retry int
+		limit time.Duration
+	}{
+		{"First retry", 1, 100 * time.Millisecond},
+		{"Second retry", 2, 200 * time.Millisecond},
+		{"Capped", 5, time.Second},
+		{"Shift overflow", 100, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				if got := policy.backoff(tt.retry); got <= 0 || got > tt.limit {
+					t.Fatalf("Expected a delay in (0, %v], got %v", tt.limit, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second}.withDefaults()
+
+	tests := []struct {
+		name        string
+		retryAfter  string
+		wantRetry   bool
+		wantAtLeast time.Duration
+	}{
+		{"No Retry-After", "", true, 0},
+		{"Retry-After within the cap", "2", true, 2 * time.Second},
+		{"Retry-After beyond the cap", "60", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+
+			delay, ok := policy.delay(1, header)
+			if ok != tt.wantRetry {
+				t.Fatalf("Expected retry %t, got %t", tt.wantRetry, ok)
+			}
+			if ok && (delay < tt.wantAtLeast || delay > policy.MaxDelay) {
+				t.Errorf("Expected a delay in [%v, %v], got %v", tt.wantAtLeast, policy.MaxDelay, delay)
+			}
+		})
+	}
+}
@@ -2,13 +2,20 @@ package provider
 
 import (
 	"errors"
+	"net/url"
+	"slices"
 	"strings"
 )
 
 type AuthConfig struct {
-	Type       AuthType
-	Header     string
-	Prefix     string
+	Type   AuthType
+	Header string
+	Prefix string
+	// QueryParam names the query parameter that carries the credential for
+	// AuthTypeQuery.
+	QueryParam string
+	OAuth2     OAuth2Config
+	SigV4      SigV4Config
 	Credential Credential
 }
 
@@ -17,30 +24,124 @@ type AuthType string
 const (
 	AuthTypeBearer AuthType = "bearer"
 	AuthTypeAPIKey AuthType = "apikey"
+	// AuthTypeQuery sends the credential as a query parameter.
+	AuthTypeQuery AuthType = "query"
+	// AuthTypeOAuth2 exchanges the credential, a client secret, for an access
+	// token with the OAuth2 client credentials grant. The token is sent in
+	// Header with Prefix.
+	AuthTypeOAuth2 AuthType = "oauth2_client_credentials"
+	// AuthTypeSigV4 signs requests with AWS Signature Version 4, using the
+	// credential as the secret access key.
+	AuthTypeSigV4 AuthType = "aws_sigv4"
 )
 
+// IsStatic reports whether the credential is sent as it is, rather than
+// exchanged for a token or used to sign each request.
+func (t AuthType) IsStatic() bool {
+	switch t {
+	case AuthTypeBearer, AuthTypeAPIKey, AuthTypeQuery:
+		return true
+
+	default:
+		return false
+	}
+}
+
+type OAuth2Config struct {
+	TokenURL string
+	ClientID string
+	Scopes   []string
+}
+
+type SigV4Config struct {
+	AccessKeyID string
+	Region      string
+	// Service is the signing name, such as "bedrock".
+	Service string
+}
+
+// Credential is a provider secret sealed with envelope encryption: the
+// secret is encrypted with a per-credential data key, which is itself
+// encrypted with the master key identified by KeyID.
 type Credential struct {
 	Encrypted string
+	DataKey   string
+	KeyID     string
+}
+
+// IsSealed reports whether the credential is encrypted. Credentials stored
+// before encryption was introduced hold the plaintext in Encrypted.
+func (c Credential) IsSealed() bool {
+	return c.KeyID != ""
 }
 
 func (cfg AuthConfig) Validate() error {
 	switch cfg.Type {
 	case AuthTypeBearer, AuthTypeAPIKey:
-		// ok
+		if strings.TrimSpace(cfg.Header) == "" {
+			return errors.New("authheader is required")
+		}
+
+	case AuthTypeQuery:
+		if strings.TrimSpace(cfg.QueryParam) == "" {
+			return errors.New("query parameter is required")
+		}
+
+	case AuthTypeOAuth2:
+		if strings.TrimSpace(cfg.Header) == "" {
+			return errors.New("authheader is required")
+		}
+		if u, err := url.Parse(cfg.OAuth2.TokenURL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return errors.New("oauth2 token URL must be an absolute http(s) URL")
+		}
+		if strings.TrimSpace(cfg.OAuth2.ClientID) == "" {
+			return errors.New("oauth2 client ID is required")
+		}
+
+	case AuthTypeSigV4:
+		if strings.TrimSpace(cfg.SigV4.AccessKeyID) == "" {
+			return errors.New("access key ID is required")
+		}
+		if strings.TrimSpace(cfg.SigV4.Region) == "" || strings.TrimSpace(cfg.SigV4.Service) == "" {
+			return errors.New("signing region and service are required")
+		}
 
 	default:
 		return errors.New("invalid auth type")
 	}
 
-	if strings.TrimSpace(cfg.Header) == "" {
-		return errors.New("authheader is required")
+	if strings.TrimSpace(cfg.Credential.Encrypted) == "" {
+		return errors.New("credential is required")
 	}
 
-	if strings.TrimSpace(cfg.Credential.Encrypted) == "" {
+	if !cfg.Credential.IsSealed() {
+		return errors.New("credential must be encrypted")
+	}
+
+	return nil
+}
+
+// Equal reports whether two configs authenticate the same way with the same
+// sealed credential.
+func (cfg AuthConfig) Equal(other AuthConfig) bool {
+	return cfg.Type == other.Type &&
+		cfg.Header == other.Header &&
+		cfg.Prefix == other.Prefix &&
+		cfg.QueryParam == other.QueryParam &&
+		cfg.OAuth2.TokenURL == other.OAuth2.TokenURL &&
+		cfg.OAuth2.ClientID == other.OAuth2.ClientID &&
+		slices.Equal(cfg.OAuth2.Scopes, other.OAuth2.Scopes) &&
+		cfg.SigV4 == other.SigV4 &&
+		cfg.Credential == other.Credential
+}
+
+// ValidateSecret checks a plaintext secret before it is sealed.
+func (t AuthType) ValidateSecret(secret string) error {
+	if strings.TrimSpace(secret) == "" {
 		return errors.New("credential is required")
 	}
 
-	if cfg.Type == AuthTypeBearer && strings.EqualFold(cfg.Credential.Encrypted, "Bearer") {
+	if t == AuthTypeBearer && strings.EqualFold(secret, "Bearer") {
 		return errors.New("apikey is required")
 	}
 
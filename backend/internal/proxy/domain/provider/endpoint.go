@@ -1,15 +1,90 @@
 package provider
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+)
 
 type Endpoint struct {
-	Name            string
-	Path            string
+	Name   string
+	Method string
+	Path   string
+	Query  map[string]string
+	// Stream, when set, is the route taken by streaming requests, for APIs
+	// that stream from a different URL than they answer in one piece.
+	Stream *EndpointRoute
+	// Probe, when set, is the route the health checker requests instead of
+	// sending the probe prompt, for endpoints that do not take chat
+	// requests. Only the status of its response is checked.
+	Probe           *EndpointRoute
 	Status          EndpointStatus
 	Health          EndpointHealth
 	LastHealthCheck time.Time
 }
 
+// DefaultEndpointMethod is used by endpoints that do not name a method.
+const DefaultEndpointMethod = http.MethodPost
+
+// EndpointRoute is how a request reaches the provider. Path and query values
+// are templates rendered against the canonical request, so a route can carry
+// the model, as in "v1beta/models/{{.ModelKey}}:generateContent".
+type EndpointRoute struct {
+	Method string
+	Path   string
+	Query  map[string]string
+}
+
+func (r EndpointRoute) Validate() error {
+	switch r.Method {
+	case "", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fmt.Errorf("unsupported endpoint method %q", r.Method)
+	}
+
+	if r.Path == "" {
+		return errors.New("path is required")
+	}
+
+	if strings.Contains(r.Path, "?") {
+		return errors.New("path cannot contain a query string, use query parameters instead")
+	}
+
+	for key := range r.Query {
+		if key == "" {
+			return errors.New("query parameter name is required")
+		}
+	}
+
+	return nil
+}
+
+// Equal reports whether two routes send requests to the same place.
+func (r EndpointRoute) Equal(other EndpointRoute) bool {
+	return r.method() == other.method() && r.Path == other.Path && maps.Equal(r.Query, other.Query)
+}
+
+func (r EndpointRoute) method() string {
+	if r.Method == "" {
+		return DefaultEndpointMethod
+	}
+	return r.Method
+}
+
+// Route returns the route taken by a request, using the stream variant for
+// streaming requests when the endpoint has one.
+func (e Endpoint) Route(stream bool) EndpointRoute {
+	route := EndpointRoute{Method: e.Method, Path: e.Path, Query: e.Query}
+	if stream && e.Stream != nil {
+		route = *e.Stream
+	}
+	route.Method = route.method()
+	return route
+}
+
 type EndpointStatus string
 
 const (
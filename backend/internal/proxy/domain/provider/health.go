@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"errors"
+	"time"
+)
+
+// HealthProbe configures the request the health checker sends to each of a
+// provider's endpoints. An empty ModelKey means the provider's first model
+// by key.
+type HealthProbe struct {
+	ModelKey string
+	Prompt   string
+}
+
+const DefaultHealthProbePrompt = "ping"
+
+func (p HealthProbe) PromptOrDefault() string {
+	if p.Prompt == "" {
+		return DefaultHealthProbePrompt
+	}
+	return p.Prompt
+}
+
+// HealthSample is the outcome of a single probe.
+type HealthSample struct {
+	Latency time.Duration
+	Failed  bool
+}
+
+// HealthPolicy holds the thresholds used to classify a window of samples.
+// MinSamples is how many samples a window needs before it can be classified
+// unhealthy, so a single failed probe does not take an endpoint out of
+// routing.
+type HealthPolicy struct {
+	DegradedLatency    time.Duration
+	DegradedErrorRate  float64
+	UnhealthyErrorRate float64
+	MinSamples         int
+}
+
+var DefaultHealthPolicy = HealthPolicy{
+	DegradedLatency:    5 * time.Second,
+	DegradedErrorRate:  0.2,
+	UnhealthyErrorRate: 0.5,
+	MinSamples:         3,
+}
+
+// Classify derives an endpoint health from a window of recent samples. Error
+// rate takes precedence over latency, and latency is only averaged over the
+// probes that succeeded. A window shorter than MinSamples is at worst
+// degraded.
+func (p HealthPolicy) Classify(samples []HealthSample) EndpointHealth {
+	if len(samples) == 0 {
+		return EndpointHealthUnknown
+	}
+
+	var (
+		failed  int
+		latency time.Duration
+	)
+	for _, s := range samples {
+		if s.Failed {
+			failed++
+			continue
+		}
+		latency += s.Latency
+	}
+
+	errorRate := float64(failed) / float64(len(samples))
+	switch {
+	case errorRate >= p.UnhealthyErrorRate && len(samples) >= p.MinSamples:
+		return EndpointHealthUnhealthy
+	case errorRate >= p.UnhealthyErrorRate:
+		return EndpointHealthDegraded
+	case errorRate >= p.DegradedErrorRate:
+		return EndpointHealthDegraded
+	}
+
+	if succeeded := len(samples) - failed; succeeded > 0 && latency/time.Duration(succeeded) > p.DegradedLatency {
+		return EndpointHealthDegraded
+	}
+
+	return EndpointHealthHealthy
+}
+
+func (p *Provider) HealthProbe() HealthProbe {
+	return p.healthProbe
+}
+
+func (p *Provider) UpdateHealthProbe(probe HealthProbe) error {
+	if probe.ModelKey != "" && !p.HasModel(probe.ModelKey) {
+		return errors.New("health probe model not found")
+	}
+
+	p.healthProbe = probe
+	p.updatedAt = time.Now()
+	return nil
+}
+
+// RecordEndpointHealth stores the outcome of a health check. Inactive
+// endpoints are not checked and keep their unknown health. Health is not a
+// configuration change, so updatedAt is left alone.
+func (p *Provider) RecordEndpointHealth(endpointName string, health EndpointHealth, checkedAt time.Time) error {
+	for i, ep := range p.endpoints {
+		if ep.Name == endpointName {
+			if ep.Status.IsInactive() {
+				return errors.New("endpoint is inactive")
+			}
+
+			p.endpoints[i] = ep.
+				WithHealth(health).
+				WithLastHealthCheck(checkedAt)
+			return nil
+		}
+	}
+	return errors.New("endpoint not found")
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthPolicyClassify(t *testing.T) {
+	ok := func(latency time.Duration) HealthSample { return HealthSample{Latency: latency} }
+	fail := HealthSample{Failed: true}
+
+	tests := []struct {
+		name     string
+		samples  []HealthSample
+		expected EndpointHealth
+	}{
+		{"No samples", nil, EndpointHealthUnknown},
+		{"All fast", []HealthSample{ok(time.Second), ok(2 * time.Second)}, EndpointHealthHealthy},
+		{"Slow on average", []HealthSample{ok(4 * time.Second), ok(7 * time.Second)}, EndpointHealthDegraded},
+		{"Some failures", []HealthSample{ok(time.Second), ok(time.Second), ok(time.Second), ok(time.Second), fail}, EndpointHealthDegraded},
+		{"Half failing", []HealthSample{ok(time.Second), fail, fail}, EndpointHealthUnhealthy},
+		{"All failing", []HealthSample{fail, fail, fail}, EndpointHealthUnhealthy},
+		{"Too few samples to be unhealthy", []HealthSample{fail, fail}, EndpointHealthDegraded},
+		{"Failures do not count towards latency", []HealthSample{ok(time.Second), ok(time.Second), ok(time.Second), ok(time.Second), ok(time.Second), fail}, EndpointHealthHealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DefaultHealthPolicy.Classify(tt.samples)
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRecordEndpointHealth(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := Hydrate(HydrateData{
+		ID:        NewID(),
+		Name:      "test",
+		Status:    StatusActive,
+		UpdatedAt: updatedAt,
+		Endpoints: []Endpoint{
+			{Name: "chat", Path: "chat/completions", Status: EndpointStatusActive, Health: EndpointHealthUnknown},
+			{Name: "embed", Path: "embeddings", Status: EndpointStatusInactive, Health: EndpointHealthUnknown},
+		},
+	})
+
+	checkedAt := time.Now()
+	if err := p.RecordEndpointHealth("chat", EndpointHealthDegraded, checkedAt); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ep := p.Endpoints()[0]
+	if !ep.Health.IsDegraded() {
+		t.Errorf("Expected health to be degraded, got %s", ep.Health)
+	}
+	if !ep.LastHealthCheck.Equal(checkedAt) {
+		t.Errorf("Expected LastHealthCheck %v, got %v", checkedAt, ep.LastHealthCheck)
+	}
+	if !p.UpdatedAt().Equal(updatedAt) {
+		t.Errorf("Expected UpdatedAt to stay %v, got %v", updatedAt, p.UpdatedAt())
+	}
+
+	if err := p.RecordEndpointHealth("embed", EndpointHealthHealthy, checkedAt); err == nil {
+		t.Error("Expected error when recording health of an inactive endpoint")
+	}
+
+	if err := p.RecordEndpointHealth("missing", EndpointHealthHealthy, checkedAt); err == nil {
+		t.Error("Expected error when recording health of an unknown endpoint")
+	}
+}
+
+func TestUpdateHealthProbe(t *testing.T) {
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+
+	if err := p.UpdateHealthProbe(HealthProbe{ModelKey: "missing"}); err == nil {
+		t.Error("Expected error for a probe model the provider does not serve")
+	}
+
+	if err := p.UpdateHealthProbe(HealthProbe{Prompt: "hello"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if p.HealthProbe().PromptOrDefault() != "hello" {
+		t.Errorf("Expected prompt hello, got %s", p.HealthProbe().PromptOrDefault())
+	}
+}
@@ -0,0 +1,249 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/shared/domain"
+)
+
+type KeyID = domain.ID[Key]
+
+var (
+	NewKeyID     = domain.NewID[Key]
+	HydrateKeyID = domain.HydrateID[Key]
+)
+
+type KeyStatus string
+
+const (
+	KeyStatusActive KeyStatus = "active"
+	// KeyStatusQuarantined keys were rejected by the provider and serve no
+	// requests until they are reinstated.
+	KeyStatusQuarantined KeyStatus = "quarantined"
+	// KeyStatusRetired keys are kept for inspection only and never serve
+	// again.
+	KeyStatusRetired KeyStatus = "retired"
+)
+
+func (s KeyStatus) String() string {
+	return string(s)
+}
+
+// ErrorTypeKeyStatus marks a key status change rejected because the key is
+// not in the status the change starts from, e.g. quarantining a key that is
+// already quarantined or retired.
+const ErrorTypeKeyStatus domain.ErrorType = "KEY_STATUS"
+
+func newKeyStatusError(status KeyStatus) *domain.Error {
+	return &domain.Error{
+		Type:    ErrorTypeKeyStatus,
+		Message: fmt.Sprintf("key is %s", status),
+	}
+}
+
+// IsKeyStatusError reports whether err, or any error it wraps, is a rejected
+// key status change.
+func IsKeyStatusError(err error) bool {
+	var domainErr *domain.Error
+	return errors.As(err, &domainErr) && domainErr.Type == ErrorTypeKeyStatus
+}
+
+// KeyStrategy is how requests are spread across a provider's active keys.
+type KeyStrategy string
+
+const (
+	// KeyStrategyWeightedRoundRobin sends each key a share of requests in
+	// proportion to its weight.
+	KeyStrategyWeightedRoundRobin KeyStrategy = "weighted_round_robin"
+	// KeyStrategyLeastRecentlyLimited prefers the key that has gone longest
+	// without being rate limited.
+	KeyStrategyLeastRecentlyLimited KeyStrategy = "least_recently_limited"
+)
+
+func (s KeyStrategy) Validate() error {
+	switch s {
+	case KeyStrategyWeightedRoundRobin, KeyStrategyLeastRecentlyLimited:
+		return nil
+	default:
+		return errors.New("invalid key strategy")
+	}
+}
+
+// MaxKeyWeight bounds key weights so one key cannot starve the others.
+const MaxKeyWeight = 100
+
+// Key is one credential in a provider's pool. Each key is sealed on its own
+// and sent with the provider's auth settings.
+type Key struct {
+	ID              KeyID
+	Name            string
+	Credential      Credential
+	Weight          int
+	Status          KeyStatus
+	CreatedAt       time.Time
+	StatusChangedAt time.Time
+}
+
+func (k Key) IsActive() bool {
+	return k.Status == KeyStatusActive
+}
+
+func validateKeyWeight(weight int) error {
+	if weight < 1 || weight > MaxKeyWeight {
+		return errors.New("key weight must be between 1 and 100")
+	}
+	return nil
+}
+
+func (p *Provider) Keys() []Key {
+	return p.keys
+}
+
+func (p *Provider) KeyStrategy() KeyStrategy {
+	return p.keyStrategy
+}
+
+// UpdateKeyStrategy changes how requests are spread across the pool.
+func (p *Provider) UpdateKeyStrategy(strategy KeyStrategy) error {
+	if err := strategy.Validate(); err != nil {
+		return err
+	}
+
+	p.keyStrategy = strategy
+	p.updatedAt = time.Now()
+	return nil
+}
+
+// AddKey adds an active key to the pool. Names are unique among the keys
+// that have not been retired. SigV4 providers have no pool, since each
+// secret access key belongs to its own access key ID.
+func (p *Provider) AddKey(name string, credential Credential, weight int) (KeyID, error) {
+	if p.auth.Type == AuthTypeSigV4 {
+		return KeyID{}, errors.New("aws_sigv4 credentials cannot be pooled")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return KeyID{}, errors.New("key name is required")
+	}
+
+	if err := validateKeyWeight(weight); err != nil {
+		return KeyID{}, err
+	}
+
+	if !credential.IsSealed() {
+		return KeyID{}, errors.New("credential must be encrypted")
+	}
+
+	for _, k := range p.keys {
+		if k.Name == name && k.Status != KeyStatusRetired {
+			return KeyID{}, errors.New("key already exists")
+		}
+	}
+
+	now := time.Now()
+	key := Key{
+		ID:              NewKeyID(),
+		Name:            name,
+		Credential:      credential,
+		Weight:          weight,
+		Status:          KeyStatusActive,
+		CreatedAt:       now,
+		StatusChangedAt: now,
+	}
+	p.keys = append(p.keys, key)
+	p.updatedAt = now
+	return key.ID, nil
+}
+
+func (p *Provider) findKey(id KeyID) (*Key, error) {
+	for i := range p.keys {
+		if p.keys[i].ID == id {
+			return &p.keys[i], nil
+		}
+	}
+	return nil, errors.New("key not found")
+}
+
+func (p *Provider) UpdateKeyWeight(id KeyID, weight int) error {
+	if err := validateKeyWeight(weight); err != nil {
+		return err
+	}
+
+	key, err := p.findKey(id)
+	if err != nil {
+		return err
+	}
+
+	if key.Status == KeyStatusRetired {
+		return errors.New("key is retired")
+	}
+
+	key.Weight = weight
+	p.updatedAt = time.Now()
+	return nil
+}
+
+// UpdateKeyCredential replaces a key's sealed credential, e.g. after it has
+// been re-encrypted under a new master key.
+func (p *Provider) UpdateKeyCredential(id KeyID, credential Credential) error {
+	if !credential.IsSealed() {
+		return errors.New("credential must be encrypted")
+	}
+
+	key, err := p.findKey(id)
+	if err != nil {
+		return err
+	}
+
+	key.Credential = credential
+	p.updatedAt = time.Now()
+	return nil
+}
+
+// QuarantineKey takes an active key out of rotation after the provider
+// rejected it.
+func (p *Provider) QuarantineKey(id KeyID) error {
+	return p.changeKeyStatus(id, KeyStatusActive, KeyStatusQuarantined)
+}
+
+// ReinstateKey puts a quarantined key back into rotation.
+func (p *Provider) ReinstateKey(id KeyID) error {
+	return p.changeKeyStatus(id, KeyStatusQuarantined, KeyStatusActive)
+}
+
+// RetireKey takes a key out of rotation for good. Requests already sent with
+// it are unaffected.
+func (p *Provider) RetireKey(id KeyID) error {
+	key, err := p.findKey(id)
+	if err != nil {
+		return err
+	}
+
+	if key.Status == KeyStatusRetired {
+		return errors.New("key is already retired")
+	}
+
+	key.Status = KeyStatusRetired
+	key.StatusChangedAt = time.Now()
+	p.updatedAt = key.StatusChangedAt
+	return nil
+}
+
+func (p *Provider) changeKeyStatus(id KeyID, from, to KeyStatus) error {
+	key, err := p.findKey(id)
+	if err != nil {
+		return err
+	}
+
+	if key.Status != from {
+		return newKeyStatusError(key.Status)
+	}
+
+	key.Status = to
+	key.StatusChangedAt = time.Now()
+	p.updatedAt = key.StatusChangedAt
+	return nil
+}
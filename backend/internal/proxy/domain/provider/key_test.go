@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeyLifecycle(t *testing.T) {
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+	sealed := Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}
+
+	if p.KeyStrategy() != KeyStrategyWeightedRoundRobin {
+		t.Errorf("Expected default strategy %s, got %s", KeyStrategyWeightedRoundRobin, p.KeyStrategy())
+	}
+
+	id, err := p.AddKey("primary", sealed, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		apply   func() error
+		want    KeyStatus
+		wantErr bool
+	}{
+		{"Reinstate active key", func() error { return p.ReinstateKey(id) }, KeyStatusActive, true},
+		{"Quarantine", func() error { return p.QuarantineKey(id) }, KeyStatusQuarantined, false},
+		{"Quarantine twice", func() error { return p.QuarantineKey(id) }, KeyStatusQuarantined, true},
+		{"Reinstate", func() error { return p.ReinstateKey(id) }, KeyStatusActive, false},
+		{"Retire", func() error { return p.RetireKey(id) }, KeyStatusRetired, false},
+		{"Reinstate retired key", func() error { return p.ReinstateKey(id) }, KeyStatusRetired, true},
+		{"Reweight retired key", func() error { return p.UpdateKeyWeight(id, 1) }, KeyStatusRetired, true},
+		{"Unknown key", func() error { return p.RetireKey(NewKeyID()) }, KeyStatusRetired, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.apply()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if p.Keys()[0].Status != tt.want {
+				t.Errorf("Expected status %s, got %s", tt.want, p.Keys()[0].Status)
+			}
+		})
+	}
+
+	// A retired key's name can be reused.
+	if _, err := p.AddKey("primary", sealed, 1); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if _, err := p.AddKey("primary", sealed, 1); err == nil {
+		t.Error("Expected error for duplicate key name")
+	}
+}
+
+func TestAddKeyValidation(t *testing.T) {
+	sealed := Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}
+
+	tests := []struct {
+		name       string
+		keyName    string
+		credential Credential
+		weight     int
+		wantErr    bool
+	}{
+		{"Valid key", "a", sealed, 1, false},
+		{"Missing name", " ", sealed, 1, true},
+		{"Zero weight", "a", sealed, 0, true},
+		{"Weight too high", "a", sealed, MaxKeyWeight + 1, true},
+		{"Unsealed credential", "a", Credential{Encrypted: "plain"}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+			_, err := p.AddKey(tt.keyName, tt.credential, tt.weight)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	sigV4 := Hydrate(HydrateData{ID: NewID(), Name: "bedrock", Status: StatusActive, Auth: AuthConfig{Type: AuthTypeSigV4}})
+	if _, err := sigV4.AddKey("a", sealed, 1); err == nil {
+		t.Error("Expected error for a SigV4 pool")
+	}
+
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+	if err := p.UpdateKeyStrategy("random"); err == nil {
+		t.Error("Expected error for unknown key strategy")
+	}
+	if err := p.UpdateKeyStrategy(KeyStrategyLeastRecentlyLimited); err != nil || p.KeyStrategy() != KeyStrategyLeastRecentlyLimited {
+		t.Errorf("Expected strategy %s, got %s (%v)", KeyStrategyLeastRecentlyLimited, p.KeyStrategy(), err)
+	}
+}
+
+func TestIsKeyStatusError(t *testing.T) {
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+	id, err := p.AddKey("primary", Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := p.RetireKey(id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := p.QuarantineKey(id); !IsKeyStatusError(fmt.Errorf("wrapped: %w", err)) {
+		t.Errorf("Expected a key status error, got %v", err)
+	}
+	if err := p.QuarantineKey(NewKeyID()); err == nil || IsKeyStatusError(err) {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+}
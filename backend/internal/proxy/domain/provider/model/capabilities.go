@@ -3,4 +3,7 @@ package model
 type Capabilities struct {
 	FunctionCalling bool
 	Streaming       bool
+	// StructuredOutput means the model natively supports JSON and JSON
+	// schema response formats.
+	StructuredOutput bool
 }
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimal is an exact decimal number. Prices are parsed from their decimal
+// text and multiplied by whole quantities, so no precision is lost to binary
+// floating point. The zero value is 0.
+type Decimal struct {
+	rat *big.Rat
+}
+
+var (
+	decimalTwo  = big.NewInt(2)
+	decimalFive = big.NewInt(5)
+)
+
+// ParseDecimal parses a decimal such as "0.0025" or "2.5e-6". An empty
+// string is 0.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, nil
+	}
+
+	if strings.ContainsRune(s, '/') {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{rat: r}, nil
+}
+
+// MustParseDecimal is ParseDecimal for constants known to be valid.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func NewDecimalFromInt(n int64) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(n)}
+}
+
+func (d Decimal) value() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return d.rat
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.value(), other.value())}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.value(), other.value())}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.value(), other.value())}
+}
+
+func (d Decimal) MulInt(n int64) Decimal {
+	return d.Mul(NewDecimalFromInt(n))
+}
+
+// DivInt divides by a power of ten or another divisor that keeps the
+// result a terminating decimal.
+func (d Decimal) DivInt(n int64) Decimal {
+	return Decimal{rat: new(big.Rat).Quo(d.value(), new(big.Rat).SetInt64(n))}
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value().Cmp(other.value())
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Ceil returns the smallest integer not less than d.
+func (d Decimal) Ceil() *big.Int {
+	v := d.value()
+	q, m := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if m.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// String formats d exactly, without trailing zeros. Only a quotient that
+// does not terminate, which prices never produce, is rounded.
+func (d Decimal) String() string {
+	v := d.value()
+	if v.IsInt() {
+		return v.Num().String()
+	}
+
+	// A terminating decimal's denominator is 2^a * 5^b and needs max(a, b)
+	// fractional digits.
+	digits := max(factorCount(v.Denom(), decimalTwo), factorCount(v.Denom(), decimalFive))
+	s := v.FloatString(digits)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func factorCount(n, factor *big.Int) int {
+	n = new(big.Int).Set(n)
+	q, m := new(big.Int), new(big.Int)
+	for count := 0; ; count++ {
+		q.QuoRem(n, factor, m)
+		if m.Sign() != 0 {
+			return count
+		}
+		n.Set(q)
+	}
+}
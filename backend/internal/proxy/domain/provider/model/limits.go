@@ -1,6 +1,21 @@
 package model
 
+import "errors"
+
 type Limits struct {
 	ContextWindow   int
 	MaxOutputTokens int
+	// MaxStopSequences is how many stop sequences the model accepts.
+	MaxStopSequences int
+}
+
+// Validate checks the limits. Zero means the limit is not declared.
+func (l Limits) Validate() error {
+	if l.ContextWindow < 0 || l.MaxOutputTokens < 0 || l.MaxStopSequences < 0 {
+		return errors.New("limits cannot be negative")
+	}
+	if l.ContextWindow > 0 && l.MaxOutputTokens > l.ContextWindow {
+		return errors.New("max output tokens cannot exceed the context window")
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/basetable/basetable/backend/internal/shared/domain"
 )
@@ -20,7 +21,7 @@ type Model struct {
 	description  string
 	capabilities Capabilities
 	limits       Limits
-	pricing      TokenPricing
+	pricing      Pricing
 }
 
 func New(
@@ -29,7 +30,7 @@ func New(
 	description string,
 	capabilities Capabilities,
 	limits Limits,
-	pricing TokenPricing,
+	pricing Pricing,
 ) *Model {
 	return &Model{
 		id:           NewID(),
@@ -49,7 +50,7 @@ type HydrateData struct {
 	Description  string
 	Capabilities Capabilities
 	Limits       Limits
-	Pricing      TokenPricing
+	Pricing      Pricing
 }
 
 func Hydrate(data HydrateData) *Model {
@@ -88,8 +89,10 @@ func (m *Model) Limits() Limits {
 	return m.limits
 }
 
-func (m *Model) Pricing() TokenPricing {
-	return m.pricing
+func (m *Model) Pricing() Pricing {
+	pricing := m.pricing
+	pricing.Rules = slices.Clone(pricing.Rules)
+	return pricing
 }
 
 func (m *Model) String() string {
@@ -1,5 +1,11 @@
 package model
 
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
 type PricingUnit string
 
 func (u PricingUnit) String() string {
@@ -7,12 +13,223 @@ func (u PricingUnit) String() string {
 }
 
 const (
-	UnitPer1000Tokens PricingUnit = "per_1000_tokens"
+	UnitPer1000Tokens    PricingUnit = "per_1000_tokens"
+	UnitPerMillionTokens PricingUnit = "per_1m_tokens"
+)
+
+// IsValid reports whether the unit is known. An empty unit is read as
+// UnitPer1000Tokens.
+func (u PricingUnit) IsValid() bool {
+	switch u {
+	case "", UnitPer1000Tokens, UnitPerMillionTokens:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (u PricingUnit) tokens() int64 {
+	if u == UnitPerMillionTokens {
+		return 1_000_000
+	}
+	return 1000
+}
+
+// Dimension is one thing a request is charged for.
+type Dimension string
+
+const (
+	// DimensionPromptTokens prices prompt tokens that were not read from
+	// the provider's prompt cache.
+	DimensionPromptTokens       Dimension = "prompt_tokens"
+	DimensionCachedPromptTokens Dimension = "cached_prompt_tokens"
+	// DimensionCompletionTokens prices completion tokens other than
+	// reasoning tokens.
+	DimensionCompletionTokens Dimension = "completion_tokens"
+	DimensionReasoningTokens  Dimension = "reasoning_tokens"
+	DimensionImages           Dimension = "images"
+	DimensionRequests         Dimension = "requests"
 )
 
-type TokenPricing struct {
-	Unit                 PricingUnit
-	PromptTokenPrice     float64
-	CompletionTokenPrice float64
-	Currency             string
+func (d Dimension) String() string {
+	return string(d)
+}
+
+func (d Dimension) IsValid() bool {
+	switch d {
+	case DimensionPromptTokens, DimensionCachedPromptTokens, DimensionCompletionTokens,
+		DimensionReasoningTokens, DimensionImages, DimensionRequests:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// IsTokens reports whether the dimension is priced per unit of tokens
+// rather than per item.
+func (d Dimension) IsTokens() bool {
+	switch d {
+	case DimensionPromptTokens, DimensionCachedPromptTokens, DimensionCompletionTokens, DimensionReasoningTokens:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// PriceRule prices one dimension. A rule with AbovePromptTokens set is a
+// long-context tier: for requests whose prompt exceeds that many tokens it
+// replaces the dimension's lower tiers for the whole request, the way
+// providers price long prompts.
+type PriceRule struct {
+	Dimension         Dimension
+	Price             Decimal
+	AbovePromptTokens int
+}
+
+// Pricing is a model's price sheet. Dimensions without a rule are free,
+// except that cached prompt tokens fall back to the prompt price and
+// reasoning tokens to the completion price.
+type Pricing struct {
+	Unit     PricingUnit
+	Currency string
+	Rules    []PriceRule
+}
+
+// NewTokenPricing prices prompt and completion tokens only.
+func NewTokenPricing(unit PricingUnit, currency string, prompt, completion Decimal) Pricing {
+	return Pricing{
+		Unit:     unit,
+		Currency: currency,
+		Rules: []PriceRule{
+			{Dimension: DimensionPromptTokens, Price: prompt},
+			{Dimension: DimensionCompletionTokens, Price: completion},
+		},
+	}
+}
+
+func (p Pricing) Validate() error {
+	if !p.Unit.IsValid() {
+		return fmt.Errorf("unknown pricing unit %q", p.Unit)
+	}
+
+	type ruleKey struct {
+		dimension Dimension
+		above     int
+	}
+	seen := make(map[ruleKey]bool, len(p.Rules))
+	for _, r := range p.Rules {
+		if !r.Dimension.IsValid() {
+			return fmt.Errorf("unknown pricing dimension %q", r.Dimension)
+		}
+		if r.Price.Sign() < 0 {
+			return fmt.Errorf("%s price cannot be negative", r.Dimension)
+		}
+		if r.AbovePromptTokens < 0 {
+			return fmt.Errorf("%s tier threshold cannot be negative", r.Dimension)
+		}
+
+		key := ruleKey{r.Dimension, r.AbovePromptTokens}
+		if seen[key] {
+			return fmt.Errorf("%s is priced twice above %d prompt tokens", r.Dimension, r.AbovePromptTokens)
+		}
+		seen[key] = true
+	}
+
+	for key := range seen {
+		if key.above > 0 && !seen[ruleKey{key.dimension, 0}] {
+			return fmt.Errorf("%s has a long-context tier but no base price", key.dimension)
+		}
+	}
+
+	return nil
+}
+
+// Equal reports whether two price sheets charge the same, regardless of
+// rule order.
+func (p Pricing) Equal(other Pricing) bool {
+	if p.unitTokens() != other.unitTokens() || p.Currency != other.Currency || len(p.Rules) != len(other.Rules) {
+		return false
+	}
+
+	a, b := p.sortedRules(), other.sortedRules()
+	for i := range a {
+		if a[i].Dimension != b[i].Dimension || a[i].AbovePromptTokens != b[i].AbovePromptTokens || !a[i].Price.Equal(b[i].Price) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Pricing) unitTokens() int64 {
+	return p.Unit.tokens()
+}
+
+func (p Pricing) sortedRules() []PriceRule {
+	rules := slices.Clone(p.Rules)
+	slices.SortFunc(rules, func(a, b PriceRule) int {
+		return cmp.Or(cmp.Compare(a.Dimension, b.Dimension), cmp.Compare(a.AbovePromptTokens, b.AbovePromptTokens))
+	})
+	return rules
+}
+
+// Price returns the dimension's price for a request with the given prompt
+// size, and whether any rule prices the dimension.
+func (p Pricing) Price(dimension Dimension, promptTokens int) (Decimal, bool) {
+	var (
+		price Decimal
+		above = -1
+	)
+	for _, r := range p.Rules {
+		if r.Dimension != dimension || r.AbovePromptTokens <= above {
+			continue
+		}
+		if r.AbovePromptTokens == 0 || promptTokens > r.AbovePromptTokens {
+			price, above = r.Price, r.AbovePromptTokens
+		}
+	}
+	return price, above >= 0
+}
+
+// Usage is what one request consumed. PromptTokens includes
+// CachedPromptTokens and CompletionTokens includes ReasoningTokens, as
+// providers report them.
+type Usage struct {
+	PromptTokens       int
+	CachedPromptTokens int
+	CompletionTokens   int
+	ReasoningTokens    int
+	Images             int
+}
+
+// Cost prices the usage exactly, in the pricing currency.
+func (p Pricing) Cost(u Usage) Decimal {
+	prompt, _ := p.Price(DimensionPromptTokens, u.PromptTokens)
+	completion, _ := p.Price(DimensionCompletionTokens, u.PromptTokens)
+
+	cachedPrice, ok := p.Price(DimensionCachedPromptTokens, u.PromptTokens)
+	if !ok {
+		cachedPrice = prompt
+	}
+	reasoningPrice, ok := p.Price(DimensionReasoningTokens, u.PromptTokens)
+	if !ok {
+		reasoningPrice = completion
+	}
+
+	cached := int64(min(u.CachedPromptTokens, u.PromptTokens))
+	reasoning := int64(min(u.ReasoningTokens, u.CompletionTokens))
+
+	tokens := prompt.MulInt(int64(u.PromptTokens) - cached).
+		Add(cachedPrice.MulInt(cached)).
+		Add(completion.MulInt(int64(u.CompletionTokens) - reasoning)).
+		Add(reasoningPrice.MulInt(reasoning))
+
+	images, _ := p.Price(DimensionImages, u.PromptTokens)
+	request, _ := p.Price(DimensionRequests, u.PromptTokens)
+
+	return tokens.DivInt(p.unitTokens()).
+		Add(images.MulInt(int64(u.Images))).
+		Add(request)
 }
@@ -0,0 +1,152 @@
+package model
+
+import "testing"
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Integer", "3", "3"},
+		{"Fraction", "0.0025", "0.0025"},
+		{"Trailing zeros", "1.500", "1.5"},
+		{"Exponent", "2.5e-7", "0.00000025"},
+		{"Empty", "", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDecimal(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if d.String() != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, d.String())
+			}
+		})
+	}
+
+	for _, input := range []string{"1/3", "abc", "0.1.2"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+
+	// 0.1 + 0.2 is exact, unlike in float64.
+	sum := MustParseDecimal("0.1").Add(MustParseDecimal("0.2"))
+	if !sum.Equal(MustParseDecimal("0.3")) {
+		t.Errorf("Expected 0.3, got %s", sum)
+	}
+
+	if got := MustParseDecimal("1.01").Ceil().Int64(); got != 2 {
+		t.Errorf("Expected ceil 2, got %d", got)
+	}
+	if got := MustParseDecimal("-1.5").Ceil().Int64(); got != -1 {
+		t.Errorf("Expected ceil -1, got %d", got)
+	}
+}
+
+func TestPricingCost(t *testing.T) {
+	d := MustParseDecimal
+
+	// A long-context price sheet per million tokens: prompt $1.25 and
+	// completion $10, doubling above 200k prompt tokens, cached prompt at
+	// a quarter, reasoning at the completion price.
+	sheet := Pricing{
+		Unit:     UnitPerMillionTokens,
+		Currency: "USD",
+		Rules: []PriceRule{
+			{Dimension: DimensionPromptTokens, Price: d("1.25")},
+			{Dimension: DimensionPromptTokens, Price: d("2.5"), AbovePromptTokens: 200_000},
+			{Dimension: DimensionCachedPromptTokens, Price: d("0.3125")},
+			{Dimension: DimensionCompletionTokens, Price: d("10")},
+			{Dimension: DimensionCompletionTokens, Price: d("15"), AbovePromptTokens: 200_000},
+			{Dimension: DimensionImages, Price: d("0.001")},
+			{Dimension: DimensionRequests, Price: d("0.0001")},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		pricing Pricing
+		usage   Usage
+		want    string
+	}{
+		{
+			name:    "Prompt and completion",
+			pricing: NewTokenPricing(UnitPer1000Tokens, "USD", d("0.003"), d("0.015")),
+			usage:   Usage{PromptTokens: 1000, CompletionTokens: 500},
+			want:    "0.0105",
+		},
+		{
+			name:    "Reasoning falls back to completion price",
+			pricing: NewTokenPricing(UnitPer1000Tokens, "USD", d("0.003"), d("0.015")),
+			usage:   Usage{PromptTokens: 1000, CompletionTokens: 500, ReasoningTokens: 400, CachedPromptTokens: 1000},
+			want:    "0.0105",
+		},
+		{
+			name:    "Cached prompt discount",
+			pricing: sheet,
+			usage:   Usage{PromptTokens: 100_000, CachedPromptTokens: 80_000},
+			want:    "0.0501",
+		},
+		{
+			name:    "Long-context tier",
+			pricing: sheet,
+			usage:   Usage{PromptTokens: 300_000, CompletionTokens: 1000},
+			want:    "0.7651",
+		},
+		{
+			name:    "Images and request fee",
+			pricing: sheet,
+			usage:   Usage{PromptTokens: 1000, Images: 2},
+			want:    "0.00335",
+		},
+		{
+			name:    "Cached count beyond prompt is capped",
+			pricing: sheet,
+			usage:   Usage{PromptTokens: 1000, CachedPromptTokens: 5000},
+			want:    "0.0004125",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.pricing.Validate(); err != nil {
+				t.Fatalf("Expected valid pricing, got %v", err)
+			}
+
+			got := tt.pricing.Cost(tt.usage)
+			if !got.Equal(d(tt.want)) {
+				t.Errorf("Expected cost %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPricingValidate(t *testing.T) {
+	d := MustParseDecimal
+
+	tests := []struct {
+		name    string
+		pricing Pricing
+		wantErr bool
+	}{
+		{"Empty", Pricing{}, false},
+		{"Unknown unit", Pricing{Unit: "per_token"}, true},
+		{"Unknown dimension", Pricing{Rules: []PriceRule{{Dimension: "seconds"}}}, true},
+		{"Negative price", Pricing{Rules: []PriceRule{{Dimension: DimensionPromptTokens, Price: d("-1")}}}, true},
+		{"Duplicate rule", Pricing{Rules: []PriceRule{{Dimension: DimensionPromptTokens}, {Dimension: DimensionPromptTokens}}}, true},
+		{"Tier without base", Pricing{Rules: []PriceRule{{Dimension: DimensionPromptTokens, AbovePromptTokens: 1000}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pricing.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -41,7 +41,14 @@ type Provider struct {
 	models           []*model.Model
 	requestTemplate  Template
 	responseTemplate Template
-	updatedAt        time.Time
+	// templateVersion is the number of the version currently serving, or 0
+	// for providers created before templates were versioned.
+	templateVersion int
+	stagedTemplate  *StagedTemplate
+	healthProbe     HealthProbe
+	keys            []Key
+	keyStrategy     KeyStrategy
+	updatedAt       time.Time
 }
 
 type Config struct {
@@ -51,6 +58,7 @@ type Config struct {
 	Headers      map[string]string
 	RequestTmpl  Template
 	ResponseTmpl Template
+	HealthProbe  HealthProbe
 }
 
 func (cfg Config) Validate() error {
@@ -83,6 +91,8 @@ func New(cfg Config) (*Provider, error) {
 		status:           StatusActive,
 		requestTemplate:  cfg.RequestTmpl,
 		responseTemplate: cfg.ResponseTmpl,
+		healthProbe:      cfg.HealthProbe,
+		keyStrategy:      KeyStrategyWeightedRoundRobin,
 		updatedAt:        time.Now(),
 	}, nil
 
@@ -97,12 +107,22 @@ type HydrateData struct {
 	Status           Status
 	RequestTemplate  Template
 	ResponseTemplate Template
+	TemplateVersion  int
+	StagedTemplate   *StagedTemplate
 	Models           []*model.Model
 	Endpoints        []Endpoint
+	HealthProbe      HealthProbe
+	Keys             []Key
+	KeyStrategy      KeyStrategy
 	UpdatedAt        time.Time
 }
 
 func Hydrate(data HydrateData) *Provider {
+	// Providers stored before key pools existed have no strategy.
+	if data.KeyStrategy == "" {
+		data.KeyStrategy = KeyStrategyWeightedRoundRobin
+	}
+
 	return &Provider{
 		id:               data.ID,
 		name:             data.Name,
@@ -112,8 +132,13 @@ func Hydrate(data HydrateData) *Provider {
 		status:           data.Status,
 		requestTemplate:  data.RequestTemplate,
 		responseTemplate: data.ResponseTemplate,
+		templateVersion:  data.TemplateVersion,
+		stagedTemplate:   data.StagedTemplate,
 		models:           data.Models,
 		endpoints:        data.Endpoints,
+		healthProbe:      data.HealthProbe,
+		keys:             data.Keys,
+		keyStrategy:      data.KeyStrategy,
 		updatedAt:        data.UpdatedAt,
 	}
 }
@@ -134,10 +159,51 @@ func (p *Provider) Auth() AuthConfig {
 	return p.auth
 }
 
+// UpdateCredential replaces the sealed credential, e.g. after it has been
+// re-encrypted under a new master key.
+func (p *Provider) UpdateCredential(credential Credential) error {
+	if !credential.IsSealed() {
+		return errors.New("credential must be encrypted")
+	}
+
+	p.auth.Credential = credential
+	p.updatedAt = time.Now()
+	return nil
+}
+
 func (p *Provider) Headers() map[string]string {
 	return p.headers
 }
 
+// UpdateConnection replaces the base URL and the headers sent with every
+// upstream request.
+func (p *Provider) UpdateConnection(baseURL string, headers map[string]string) error {
+	if baseURL == "" {
+		return errors.New("baseURL is required")
+	}
+
+	p.baseURL = baseURL
+	p.headers = headers
+	p.updatedAt = time.Now()
+	return nil
+}
+
+// UpdateAuth replaces how the provider authenticates. The credential must
+// already be sealed.
+func (p *Provider) UpdateAuth(auth AuthConfig) error {
+	if err := auth.Validate(); err != nil {
+		return err
+	}
+
+	if auth.Type == AuthTypeSigV4 && slices.ContainsFunc(p.keys, func(k Key) bool { return k.Status != KeyStatusRetired }) {
+		return errors.New("retire the provider's pooled keys before switching to aws_sigv4")
+	}
+
+	p.auth = auth
+	p.updatedAt = time.Now()
+	return nil
+}
+
 func (p *Provider) Status() Status {
 	return p.status
 }
@@ -152,7 +218,7 @@ func (p *Provider) AddModel(
 	description string,
 	capabilities model.Capabilities,
 	limits model.Limits,
-	pricing model.TokenPricing,
+	pricing model.Pricing,
 ) (model.ID, error) {
 	for _, model := range p.models {
 		if model.Key() == key {
@@ -160,6 +226,10 @@ func (p *Provider) AddModel(
 		}
 	}
 
+	if err := validateModelSettings(limits, pricing); err != nil {
+		return model.ID{}, err
+	}
+
 	model := model.New(name, key, description, capabilities, limits, pricing)
 	p.models = append(p.models, model)
 	p.updatedAt = time.Now()
@@ -178,20 +248,87 @@ func (p *Provider) RemoveModel(modelID model.ID) error {
 	return errors.New("model not found")
 }
 
+// UpdateModel replaces the settings of the model with the given key,
+// keeping its ID.
+func (p *Provider) UpdateModel(
+	key string,
+	name string,
+	description string,
+	capabilities model.Capabilities,
+	limits model.Limits,
+	pricing model.Pricing,
+) error {
+	if err := validateModelSettings(limits, pricing); err != nil {
+		return err
+	}
+
+	for i, m := range p.models {
+		if m.Key() == key {
+			p.models[i] = model.Hydrate(model.HydrateData{
+				ID:           m.ID(),
+				Name:         name,
+				Key:          key,
+				Description:  description,
+				Capabilities: capabilities,
+				Limits:       limits,
+				Pricing:      pricing,
+			})
+			p.updatedAt = time.Now()
+			return nil
+		}
+	}
+
+	return errors.New("model not found")
+}
+
+func validateModelSettings(limits model.Limits, pricing model.Pricing) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	return pricing.Validate()
+}
+
 func (p *Provider) Models() []*model.Model {
 	return p.models
 }
 
-func (p *Provider) AddEndpoint(name, path string) error {
+func (p *Provider) HasModel(key string) bool {
+	for _, m := range p.models {
+		if m.Key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Provider) HasEndpoint(name string) bool {
+	for _, ep := range p.endpoints {
+		if ep.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddEndpoint adds an active endpoint. stream is the optional route for
+// streaming requests.
+func (p *Provider) AddEndpoint(name string, route EndpointRoute, stream *EndpointRoute) error {
+	if err := validateEndpointRoutes(route, stream); err != nil {
+		return err
+	}
+
 	for _, endpoint := range p.endpoints {
-		if endpoint.Name == name || endpoint.Path == path {
+		if endpoint.Name == name || endpoint.Route(false).Equal(route) {
 			return errors.New("endpoint already exists")
 		}
 	}
 
 	p.endpoints = append(p.endpoints, Endpoint{
 		Name:   name,
-		Path:   path,
+		Method: route.method(),
+		Path:   route.Path,
+		Query:  route.Query,
+		Stream: stream,
 		Status: EndpointStatusActive,
 		Health: EndpointHealthUnknown,
 	})
@@ -199,6 +336,67 @@ func (p *Provider) AddEndpoint(name, path string) error {
 	return nil
 }
 
+// UpdateEndpointRoute moves an endpoint to a new route, keeping its status
+// and health. A nil stream removes the streaming variant.
+func (p *Provider) UpdateEndpointRoute(endpointName string, route EndpointRoute, stream *EndpointRoute) error {
+	if err := validateEndpointRoutes(route, stream); err != nil {
+		return err
+	}
+
+	index := -1
+	for i, ep := range p.endpoints {
+		if ep.Name == endpointName {
+			index = i
+		} else if ep.Route(false).Equal(route) {
+			return errors.New("another endpoint already uses this route")
+		}
+	}
+	if index < 0 {
+		return errors.New("endpoint not found")
+	}
+
+	ep := &p.endpoints[index]
+	ep.Method = route.method()
+	ep.Path = route.Path
+	ep.Query = route.Query
+	ep.Stream = stream
+	p.updatedAt = time.Now()
+	return nil
+}
+
+// UpdateEndpointProbe sets the route the health checker requests for an
+// endpoint. A nil probe goes back to sending the probe prompt.
+func (p *Provider) UpdateEndpointProbe(endpointName string, probe *EndpointRoute) error {
+	if probe != nil {
+		if err := probe.Validate(); err != nil {
+			return fmt.Errorf("probe route: %w", err)
+		}
+	}
+
+	for i, ep := range p.endpoints {
+		if ep.Name == endpointName {
+			p.endpoints[i].Probe = probe
+			p.updatedAt = time.Now()
+			return nil
+		}
+	}
+	return errors.New("endpoint not found")
+}
+
+func validateEndpointRoutes(route EndpointRoute, stream *EndpointRoute) error {
+	if err := route.Validate(); err != nil {
+		return err
+	}
+
+	if stream != nil {
+		if err := stream.Validate(); err != nil {
+			return fmt.Errorf("stream route: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (p *Provider) RemoveEndpoint(endpointName string) error {
 	for i, endpoint := range p.endpoints {
 		if endpoint.Name == endpointName {
@@ -293,14 +491,81 @@ func (p *Provider) Activate() error {
 	return nil
 }
 
-func (p *Provider) UpdateRequestTemplate(tmpl Template) {
-	p.requestTemplate = tmpl
+func (p *Provider) TemplateVersion() int {
+	return p.templateVersion
+}
+
+func (p *Provider) StagedTemplate() (StagedTemplate, bool) {
+	if p.stagedTemplate == nil {
+		return StagedTemplate{}, false
+	}
+	return *p.stagedTemplate, true
+}
+
+// ApplyTemplateVersion makes the version serve all traffic. Any staged
+// version is discarded, since it was staged against the templates being
+// replaced.
+func (p *Provider) ApplyTemplateVersion(v TemplateVersion) error {
+	if v.ProviderID != p.id {
+		return errors.New("template version belongs to another provider")
+	}
+
+	p.requestTemplate = v.RequestTemplate
+	p.responseTemplate = v.ResponseTemplate
+	p.templateVersion = v.Number
+	p.stagedTemplate = nil
 	p.updatedAt = time.Now()
+	return nil
 }
 
-func (p *Provider) UpdateResponseTemplate(tmpl Template) {
-	p.responseTemplate = tmpl
+// StageTemplateVersion serves the version to percent of traffic, replacing
+// any version already staged.
+func (p *Provider) StageTemplateVersion(v TemplateVersion, percent int) error {
+	if v.ProviderID != p.id {
+		return errors.New("template version belongs to another provider")
+	}
+
+	if v.Number == p.templateVersion {
+		return errors.New("template version is already serving")
+	}
+
+	staged := StagedTemplate{
+		Version:          v.Number,
+		RequestTemplate:  v.RequestTemplate,
+		ResponseTemplate: v.ResponseTemplate,
+		Percent:          percent,
+	}
+	if err := staged.Validate(); err != nil {
+		return err
+	}
+
+	p.stagedTemplate = &staged
+	p.updatedAt = time.Now()
+	return nil
+}
+
+// PromoteStagedTemplate makes the staged version serve all traffic.
+func (p *Provider) PromoteStagedTemplate() error {
+	if p.stagedTemplate == nil {
+		return errors.New("no template version is staged")
+	}
+
+	p.requestTemplate = p.stagedTemplate.RequestTemplate
+	p.responseTemplate = p.stagedTemplate.ResponseTemplate
+	p.templateVersion = p.stagedTemplate.Version
+	p.stagedTemplate = nil
 	p.updatedAt = time.Now()
+	return nil
+}
+
+func (p *Provider) DiscardStagedTemplate() error {
+	if p.stagedTemplate == nil {
+		return errors.New("no template version is staged")
+	}
+
+	p.stagedTemplate = nil
+	p.updatedAt = time.Now()
+	return nil
 }
 
 func (p *Provider) String() string {
@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider/model"
+)
+
+func TestUpdateModel(t *testing.T) {
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+	id, err := p.AddModel("GPT-4o", "gpt-4o", "", model.Capabilities{}, model.Limits{ContextWindow: 8000}, tokenPricing("1", "0"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = p.UpdateModel("gpt-4o", "GPT-4o (2024-08)", "updated", model.Capabilities{Streaming: true}, model.Limits{ContextWindow: 128000}, tokenPricing("2", "0"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	m := p.Models()[0]
+	if m.ID() != id {
+		t.Errorf("Expected model ID %s to be kept, got %s", id, m.ID())
+	}
+
+	if m.Name() != "GPT-4o (2024-08)" || m.Limits().ContextWindow != 128000 || !m.Pricing().Equal(tokenPricing("2", "0")) || !m.Capabilities().Streaming {
+		t.Errorf("Expected model settings to be replaced, got %v", m)
+	}
+
+	if err := p.UpdateModel("unknown", "", "", model.Capabilities{}, model.Limits{}, model.Pricing{}); err == nil {
+		t.Error("Expected error for unknown model")
+	}
+}
+
+func TestUpdateConnection(t *testing.T) {
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", BaseURL: "https://a.example.com", Status: StatusActive})
+
+	if err := p.UpdateConnection("", nil); err == nil {
+		t.Error("Expected error for empty base URL")
+	}
+
+	headers := map[string]string{"X-Org": "basetable"}
+	if err := p.UpdateConnection("https://b.example.com", headers); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if p.BaseURL() != "https://b.example.com" || p.Headers()["X-Org"] != "basetable" {
+		t.Errorf("Expected connection to be replaced, got %s %v", p.BaseURL(), p.Headers())
+	}
+}
+
+func TestUpdateAuth(t *testing.T) {
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+
+	tests := []struct {
+		name    string
+		auth    AuthConfig
+		wantErr bool
+	}{
+		{"Sealed credential", AuthConfig{Type: AuthTypeAPIKey, Header: "x-api-key", Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, false},
+		{"Unsealed credential", AuthConfig{Type: AuthTypeAPIKey, Header: "x-api-key", Credential: Credential{Encrypted: "plain"}}, true},
+		{"Missing header", AuthConfig{Type: AuthTypeAPIKey, Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, true},
+		{"Unknown type", AuthConfig{Type: "basic", Header: "Authorization", Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, true},
+		{"Query parameter", AuthConfig{Type: AuthTypeQuery, QueryParam: "key", Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, false},
+		{"Missing query parameter", AuthConfig{Type: AuthTypeQuery, Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, true},
+		{"OAuth2 client credentials", AuthConfig{Type: AuthTypeOAuth2, Header: "Authorization", Prefix: "Bearer", OAuth2: OAuth2Config{TokenURL: "https://auth.example.com/token", ClientID: "client"}, Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, false},
+		{"OAuth2 relative token URL", AuthConfig{Type: AuthTypeOAuth2, Header: "Authorization", OAuth2: OAuth2Config{TokenURL: "/token", ClientID: "client"}, Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, true},
+		{"OAuth2 missing client ID", AuthConfig{Type: AuthTypeOAuth2, Header: "Authorization", OAuth2: OAuth2Config{TokenURL: "https://auth.example.com/token"}, Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, true},
+		{"SigV4", AuthConfig{Type: AuthTypeSigV4, SigV4: SigV4Config{AccessKeyID: "AKID", Region: "us-east-1", Service: "bedrock"}, Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, false},
+		{"SigV4 missing region", AuthConfig{Type: AuthTypeSigV4, SigV4: SigV4Config{AccessKeyID: "AKID", Service: "bedrock"}, Credential: Credential{Encrypted: "c", DataKey: "k", KeyID: "v1"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.UpdateAuth(tt.auth)
+			if tt.wantErr && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestModelSettingsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  model.Limits
+		pricing model.Pricing
+		wantErr bool
+	}{
+		{"Valid", model.Limits{ContextWindow: 8000, MaxOutputTokens: 1000}, tokenPricing("1", "2"), false},
+		{"Undeclared limits", model.Limits{}, model.Pricing{}, false},
+		{"Negative price", model.Limits{}, tokenPricing("0", "-1"), true},
+		{"Negative limit", model.Limits{ContextWindow: -1}, model.Pricing{}, true},
+		{"Output beyond window", model.Limits{ContextWindow: 1000, MaxOutputTokens: 2000}, model.Pricing{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+			_, addErr := p.AddModel("Model", "model", "", model.Capabilities{}, tt.limits, tt.pricing)
+			if (addErr != nil) != tt.wantErr {
+				t.Errorf("Expected AddModel error %v, got %v", tt.wantErr, addErr)
+			}
+
+			p = Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+			if _, err := p.AddModel("Model", "model", "", model.Capabilities{}, model.Limits{}, model.Pricing{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			updateErr := p.UpdateModel("model", "Model", "", model.Capabilities{}, tt.limits, tt.pricing)
+			if (updateErr != nil) != tt.wantErr {
+				t.Errorf("Expected UpdateModel error %v, got %v", tt.wantErr, updateErr)
+			}
+		})
+	}
+}
+
+func TestUpdateEndpointRoute(t *testing.T) {
+	stream := &EndpointRoute{Path: "v1beta/models/{{.ModelKey}}:streamGenerateContent", Query: map[string]string{"alt": "sse"}}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		route    EndpointRoute
+		stream   *EndpointRoute
+		wantErr  bool
+	}{
+		{"New path", "chat", EndpointRoute{Path: "v2/chat/completions"}, nil, false},
+		{"Same path", "chat", EndpointRoute{Path: "v1/chat/completions"}, nil, false},
+		{"Templated path with stream variant", "chat", EndpointRoute{Path: "v1beta/models/{{.ModelKey}}:generateContent"}, stream, false},
+		{"Path of another endpoint with another method", "chat", EndpointRoute{Method: "GET", Path: "v1/embeddings"}, nil, false},
+		{"Empty path", "chat", EndpointRoute{}, nil, true},
+		{"Query in path", "chat", EndpointRoute{Path: "v1/chat?alt=sse"}, nil, true},
+		{"Unknown method", "chat", EndpointRoute{Method: "TRACE", Path: "v1/chat"}, nil, true},
+		{"Invalid stream variant", "chat", EndpointRoute{Path: "v1/chat"}, &EndpointRoute{}, true},
+		{"Path of another endpoint", "chat", EndpointRoute{Path: "v1/embeddings"}, nil, true},
+		{"Unknown endpoint", "images", EndpointRoute{Path: "v1/images"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+			if err := p.AddEndpoint("chat", EndpointRoute{Path: "v1/chat/completions"}, nil); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := p.AddEndpoint("embeddings", EndpointRoute{Path: "v1/embeddings"}, nil); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := p.DeactivateEndpoint("chat"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			err := p.UpdateEndpointRoute(tt.endpoint, tt.route, tt.stream)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			ep := p.Endpoints()[0]
+			if !ep.Route(false).Equal(tt.route) {
+				t.Errorf("Expected route %v, got %v", tt.route, ep.Route(false))
+			}
+			if tt.stream != nil && !ep.Route(true).Equal(*tt.stream) {
+				t.Errorf("Expected stream route %v, got %v", *tt.stream, ep.Route(true))
+			}
+			if !ep.Status.IsInactive() {
+				t.Errorf("Expected endpoint status to be kept, got %s", ep.Status)
+			}
+		})
+	}
+}
+
+func TestEndpointRoute(t *testing.T) {
+	ep := Endpoint{
+		Name:   "chat",
+		Path:   "v1beta/models/{{.ModelKey}}:generateContent",
+		Stream: &EndpointRoute{Path: "v1beta/models/{{.ModelKey}}:streamGenerateContent", Query: map[string]string{"alt": "sse"}},
+	}
+
+	if got := ep.Route(false); got.Method != DefaultEndpointMethod || got.Path != ep.Path || got.Query != nil {
+		t.Errorf("Expected the default route with method %s, got %v", DefaultEndpointMethod, got)
+	}
+
+	if got := ep.Route(true); got.Method != DefaultEndpointMethod || got.Path != ep.Stream.Path || got.Query["alt"] != "sse" {
+		t.Errorf("Expected the stream route, got %v", got)
+	}
+
+	ep.Stream = nil
+	if got := ep.Route(true); got.Path != ep.Path {
+		t.Errorf("Expected streaming requests without a variant to use the default route, got %v", got)
+	}
+}
+
+func TestTemplateVersions(t *testing.T) {
+	p := Hydrate(HydrateData{ID: NewID(), Name: "test", Status: StatusActive})
+
+	v1, err := NewTemplateVersion(p.ID(), 1, Template{Content: "req-1"}, Template{Content: "resp-1"}, "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	v2, err := NewTemplateVersion(p.ID(), 2, Template{Content: "req-2"}, Template{Content: "resp-2"}, "bob")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := p.ApplyTemplateVersion(v1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if p.TemplateVersion() != 1 || p.RequestTemplate().Content != "req-1" {
+		t.Errorf("Expected version 1 to serve, got version %d", p.TemplateVersion())
+	}
+
+	if err := p.StageTemplateVersion(v1, 10); err == nil {
+		t.Error("Expected error staging the serving version")
+	}
+	if err := p.StageTemplateVersion(v2, 100); err == nil {
+		t.Error("Expected error for out of range percentage")
+	}
+	if err := p.StageTemplateVersion(v2, 10); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if staged, ok := p.StagedTemplate(); !ok || staged.Version != 2 || staged.Percent != 10 {
+		t.Errorf("Expected version 2 staged at 10%%, got %+v", staged)
+	}
+
+	if err := p.PromoteStagedTemplate(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if p.TemplateVersion() != 2 || p.ResponseTemplate().Content != "resp-2" {
+		t.Errorf("Expected version 2 to serve, got version %d", p.TemplateVersion())
+	}
+	if _, ok := p.StagedTemplate(); ok {
+		t.Error("Expected nothing staged after promotion")
+	}
+	if err := p.PromoteStagedTemplate(); err == nil {
+		t.Error("Expected error promoting with nothing staged")
+	}
+
+	if err := p.StageTemplateVersion(v1, 50); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := p.ApplyTemplateVersion(v1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := p.StagedTemplate(); ok {
+		t.Error("Expected applying a version to discard the staged one")
+	}
+
+	other, _ := NewTemplateVersion(NewID(), 3, Template{Content: "req"}, Template{Content: "resp"}, "")
+	if err := p.ApplyTemplateVersion(other); err == nil {
+		t.Error("Expected error for another provider's version")
+	}
+}
+
+func tokenPricing(prompt, completion string) model.Pricing {
+	return model.NewTokenPricing(model.UnitPer1000Tokens, "USD", model.MustParseDecimal(prompt), model.MustParseDecimal(completion))
+}
@@ -1,5 +1,61 @@
 package provider
 
+import (
+	"errors"
+	"time"
+)
+
 type Template struct {
 	Content string
 }
+
+// TemplateVersion is an immutable snapshot of a provider's request and
+// response templates. Versions are numbered from 1 per provider.
+type TemplateVersion struct {
+	ProviderID       ID
+	Number           int
+	RequestTemplate  Template
+	ResponseTemplate Template
+	Author           string
+	CreatedAt        time.Time
+}
+
+func NewTemplateVersion(providerID ID, number int, request, response Template, author string) (TemplateVersion, error) {
+	if number < 1 {
+		return TemplateVersion{}, errors.New("template version number must be positive")
+	}
+
+	if request.Content == "" || response.Content == "" {
+		return TemplateVersion{}, errors.New("request/response template is required")
+	}
+
+	return TemplateVersion{
+		ProviderID:       providerID,
+		Number:           number,
+		RequestTemplate:  request,
+		ResponseTemplate: response,
+		Author:           author,
+		CreatedAt:        time.Now(),
+	}, nil
+}
+
+// StagedTemplate is a template version served to Percent of a provider's
+// traffic until it is promoted or discarded.
+type StagedTemplate struct {
+	Version          int
+	RequestTemplate  Template
+	ResponseTemplate Template
+	Percent          int
+}
+
+func (s StagedTemplate) Validate() error {
+	if s.Version < 1 {
+		return errors.New("staged template must reference a version")
+	}
+
+	if s.Percent < 1 || s.Percent > 99 {
+		return errors.New("staged traffic percentage must be between 1 and 99")
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/shared/domain"
+)
+
+type ID = domain.ID[Route]
+
+var (
+	NewID     = domain.NewID[Route]
+	HydrateID = domain.HydrateID[Route]
+)
+
+// Target is one provider/model/endpoint a logical model can be served by.
+type Target struct {
+	ProviderID string
+	ModelKey   string
+	Endpoint   string
+}
+
+func (t Target) Validate() error {
+	if t.ProviderID == "" || t.ModelKey == "" || t.Endpoint == "" {
+		return errors.New("target requires provider, model key and endpoint")
+	}
+	return nil
+}
+
+// Route maps a logical model name to an ordered list of targets. Requests
+// are served by the first target that succeeds.
+type Route struct {
+	id        ID
+	model     string
+	targets   []Target
+	updatedAt time.Time
+}
+
+func New(model string, targets []Target) (*Route, error) {
+	if model == "" {
+		return nil, errors.New("model is required")
+	}
+
+	if err := validateTargets(targets); err != nil {
+		return nil, err
+	}
+
+	return &Route{
+		id:        NewID(),
+		model:     model,
+		targets:   targets,
+		updatedAt: time.Now(),
+	}, nil
+}
+
+type HydrateData struct {
+	ID        ID
+	Model     string
+	Targets   []Target
+	UpdatedAt time.Time
+}
+
+func Hydrate(data HydrateData) *Route {
+	return &Route{
+		id:        data.ID,
+		model:     data.Model,
+		targets:   data.Targets,
+		updatedAt: data.UpdatedAt,
+	}
+}
+
+func (r *Route) ID() ID {
+	return r.id
+}
+
+func (r *Route) Model() string {
+	return r.model
+}
+
+func (r *Route) Targets() []Target {
+	return r.targets
+}
+
+func (r *Route) UpdatedAt() time.Time {
+	return r.updatedAt
+}
+
+func (r *Route) UpdateTargets(targets []Target) error {
+	if err := validateTargets(targets); err != nil {
+		return err
+	}
+
+	r.targets = targets
+	r.updatedAt = time.Now()
+	return nil
+}
+
+func validateTargets(targets []Target) error {
+	if len(targets) == 0 {
+		return errors.New("at least one target is required")
+	}
+
+	seen := make(map[Target]struct{}, len(targets))
+	for _, t := range targets {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+
+		if _, ok := seen[t]; ok {
+			return fmt.Errorf("duplicate target %s/%s/%s", t.ProviderID, t.ModelKey, t.Endpoint)
+		}
+		seen[t] = struct{}{}
+	}
+
+	return nil
+}
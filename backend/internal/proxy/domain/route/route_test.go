@@ -0,0 +1,70 @@
+package route
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	targets := []Target{
+		{ProviderID: "provider-a", ModelKey: "claude-sonnet", Endpoint: "chat"},
+		{ProviderID: "provider-b", ModelKey: "anthropic/claude-sonnet", Endpoint: "chat"},
+	}
+
+	r, err := New("claude-sonnet", targets)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if r.Model() != "claude-sonnet" {
+		t.Errorf("Expected model claude-sonnet, got %s", r.Model())
+	}
+
+	if len(r.Targets()) != 2 || r.Targets()[0] != targets[0] {
+		t.Errorf("Expected targets to keep their order, got %v", r.Targets())
+	}
+
+	if r.ID().String() == "" {
+		t.Error("Expected ID to be generated")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	valid := Target{ProviderID: "provider-a", ModelKey: "gpt-4o", Endpoint: "chat"}
+
+	tests := []struct {
+		name    string
+		model   string
+		targets []Target
+	}{
+		{"Missing model", "", []Target{valid}},
+		{"No targets", "gpt-4o", nil},
+		{"Incomplete target", "gpt-4o", []Target{{ProviderID: "provider-a", ModelKey: "gpt-4o"}}},
+		{"Duplicate target", "gpt-4o", []Target{valid, valid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.model, tt.targets); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestUpdateTargets(t *testing.T) {
+	r, err := New("gpt-4o", []Target{{ProviderID: "provider-a", ModelKey: "gpt-4o", Endpoint: "chat"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := r.UpdateTargets(nil); err == nil {
+		t.Error("Expected error when clearing targets")
+	}
+
+	next := []Target{{ProviderID: "provider-b", ModelKey: "openai/gpt-4o", Endpoint: "chat"}}
+	if err := r.UpdateTargets(next); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if r.Targets()[0] != next[0] {
+		t.Errorf("Expected targets to be replaced, got %v", r.Targets())
+	}
+}
@@ -0,0 +1,606 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrInvalidSchema = errors.New("invalid JSON schema")
+
+// Schema is a compiled JSON Schema. It covers the keywords model providers
+// accept for structured output and tool parameters: types, properties,
+// items, enums, numeric and length bounds, patterns, combinators and local
+// $refs. Annotation keywords such as format and description are kept in the
+// raw document but not enforced.
+type Schema struct {
+	raw  json.RawMessage
+	root *node
+}
+
+type node struct {
+	// always is set for the boolean schemas true and false.
+	always *bool
+
+	types []string
+	enum  []any
+	cnst  *any
+
+	properties           map[string]*node
+	required             []string
+	additionalProperties *node
+
+	items    *node
+	minItems *int
+	maxItems *int
+	unique   bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	ref *node
+}
+
+// Parse compiles a JSON Schema document.
+func Parse(raw []byte) (*Schema, error) {
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	c := &compiler{doc: doc, refs: make(map[string]*node)}
+	root, err := c.compile(doc, "#")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	return &Schema{raw: compact.Bytes(), root: root}, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for schemas
+// defined in code.
+func MustParse(raw string) *Schema {
+	s, err := Parse([]byte(raw))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Raw returns the schema document as given, compacted.
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// MarshalJSON lets templates and payloads embed the schema unchanged.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+	return s.raw, nil
+}
+
+// ValidationError reports the first place an instance breaks the schema.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateJSON validates a JSON document against the schema.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &ValidationError{Path: "$", Message: "not valid JSON: " + err.Error()}
+	}
+	return s.Validate(value)
+}
+
+// Validate validates a value decoded by encoding/json against the schema.
+func (s *Schema) Validate(value any) error {
+	return s.root.validate(value, "$")
+}
+
+type compiler struct {
+	doc  any
+	refs map[string]*node
+}
+
+func (c *compiler) compile(v any, location string) (*node, error) {
+	if b, ok := v.(bool); ok {
+		return &node{always: &b}, nil
+	}
+
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", location)
+	}
+
+	n := &node{}
+	var err error
+
+	if ref, ok := obj["$ref"].(string); ok {
+		if n.ref, err = c.resolve(ref); err != nil {
+			return nil, fmt.Errorf("%s: %w", location, err)
+		}
+	}
+
+	switch t := obj["type"].(type) {
+	case nil:
+	case string:
+		n.types = []string{t}
+	case []any:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/type: must be a string or an array of strings", location)
+			}
+			n.types = append(n.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s/type: must be a string or an array of strings", location)
+	}
+	for _, t := range n.types {
+		if !validType(t) {
+			return nil, fmt.Errorf("%s/type: unknown type %q", location, t)
+		}
+	}
+
+	if enum, ok := obj["enum"]; ok {
+		values, ok := enum.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/enum: must be an array", location)
+		}
+		n.enum = values
+	}
+
+	if cnst, ok := obj["const"]; ok {
+		n.cnst = &cnst
+	}
+
+	if props, ok := obj["properties"]; ok {
+		propsObj, ok := props.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/properties: must be an object", location)
+		}
+		n.properties = make(map[string]*node, len(propsObj))
+		for name, prop := range propsObj {
+			if n.properties[name], err = c.compile(prop, location+"/properties/"+escape(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if required, ok := obj["required"]; ok {
+		names, ok := required.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/required: must be an array of strings", location)
+		}
+		for _, name := range names {
+			s, ok := name.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/required: must be an array of strings", location)
+			}
+			n.required = append(n.required, s)
+		}
+	}
+
+	if additional, ok := obj["additionalProperties"]; ok {
+		if n.additionalProperties, err = c.compile(additional, location+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+
+	if items, ok := obj["items"]; ok {
+		if n.items, err = c.compile(items, location+"/items"); err != nil {
+			return nil, err
+		}
+	}
+
+	if n.unique, err = boolKeyword(obj, "uniqueItems", location); err != nil {
+		return nil, err
+	}
+
+	for keyword, target := range map[string]**int{
+		"minItems":  &n.minItems,
+		"maxItems":  &n.maxItems,
+		"minLength": &n.minLength,
+		"maxLength": &n.maxLength,
+	} {
+		if *target, err = intKeyword(obj, keyword, location); err != nil {
+			return nil, err
+		}
+	}
+
+	for keyword, target := range map[string]**float64{
+		"minimum":          &n.minimum,
+		"maximum":          &n.maximum,
+		"exclusiveMinimum": &n.exclusiveMinimum,
+		"exclusiveMaximum": &n.exclusiveMaximum,
+		"multipleOf":       &n.multipleOf,
+	} {
+		if *target, err = numberKeyword(obj, keyword, location); err != nil {
+			return nil, err
+		}
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return nil, fmt.Errorf("%s/multipleOf: must be greater than 0", location)
+	}
+
+	if pattern, ok := obj["pattern"]; ok {
+		expr, ok := pattern.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/pattern: must be a string", location)
+		}
+		if n.pattern, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("%s/pattern: %w", location, err)
+		}
+	}
+
+	for keyword, target := range map[string]*[]*node{
+		"allOf": &n.allOf,
+		"anyOf": &n.anyOf,
+		"oneOf": &n.oneOf,
+	} {
+		raw, ok := obj[keyword]
+		if !ok {
+			continue
+		}
+		subschemas, ok := raw.([]any)
+		if !ok || len(subschemas) == 0 {
+			return nil, fmt.Errorf("%s/%s: must be a non-empty array", location, keyword)
+		}
+		for i, sub := range subschemas {
+			compiled, err := c.compile(sub, fmt.Sprintf("%s/%s/%d", location, keyword, i))
+			if err != nil {
+				return nil, err
+			}
+			*target = append(*target, compiled)
+		}
+	}
+
+	if not, ok := obj["not"]; ok {
+		if n.not, err = c.compile(not, location+"/not"); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// resolve compiles a local reference such as "#/$defs/address". Each target
+// is compiled once, which also lets recursive schemas refer to themselves.
+func (c *compiler) resolve(ref string) (*node, error) {
+	if n, ok := c.refs[ref]; ok {
+		return n, nil
+	}
+
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local $refs are supported, got %q", ref)
+	}
+
+	target, ok := lookup(c.doc, ref)
+	if !ok {
+		return nil, fmt.Errorf("unresolvable $ref %q", ref)
+	}
+
+	// Register a placeholder first so cycles resolve to the same node.
+	n := &node{}
+	c.refs[ref] = n
+	compiled, err := c.compile(target, ref)
+	if err != nil {
+		return nil, err
+	}
+	*n = *compiled
+
+	return n, nil
+}
+
+func (n *node) validate(value any, path string) error {
+	if n.always != nil {
+		if *n.always {
+			return nil
+		}
+		return &ValidationError{Path: path, Message: "no value is allowed here"}
+	}
+
+	if n.ref != nil {
+		if err := n.ref.validate(value, path); err != nil {
+			return err
+		}
+	}
+
+	if len(n.types) > 0 && !matchesAnyType(value, n.types) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(n.types, " or "), typeOf(value))}
+	}
+
+	if n.enum != nil && !containsValue(n.enum, value) {
+		return &ValidationError{Path: path, Message: "value is not one of the allowed values"}
+	}
+
+	if n.cnst != nil && !equal(*n.cnst, value) {
+		return &ValidationError{Path: path, Message: "value does not match the constant"}
+	}
+
+	var err error
+	switch v := value.(type) {
+	case map[string]any:
+		err = n.validateObject(v, path)
+	case []any:
+		err = n.validateArray(v, path)
+	case string:
+		err = n.validateString(v, path)
+	case float64:
+		err = n.validateNumber(v, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return n.validateCombinators(value, path)
+}
+
+func (n *node) validateObject(obj map[string]any, path string) error {
+	for _, name := range n.required {
+		if _, ok := obj[name]; !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)}
+		}
+	}
+
+	for name, value := range obj {
+		propPath := path + "." + name
+		if prop, ok := n.properties[name]; ok {
+			if err := prop.validate(value, propPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if n.additionalProperties != nil {
+			if n.additionalProperties.always != nil && !*n.additionalProperties.always {
+				return &ValidationError{Path: path, Message: fmt.Sprintf("unexpected property %q", name)}
+			}
+			if err := n.additionalProperties.validate(value, propPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *node) validateArray(items []any, path string) error {
+	if n.minItems != nil && len(items) < *n.minItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d items", *n.minItems)}
+	}
+
+	if n.maxItems != nil && len(items) > *n.maxItems {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d items", *n.maxItems)}
+	}
+
+	for i, item := range items {
+		if n.items != nil {
+			if err := n.items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		if n.unique {
+			for j := 0; j < i; j++ {
+				if equal(items[j], item) {
+					return &ValidationError{Path: path, Message: fmt.Sprintf("items %d and %d are equal", j, i)}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *node) validateString(s, path string) error {
+	length := utf8.RuneCountInString(s)
+	if n.minLength != nil && length < *n.minLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d characters", *n.minLength)}
+	}
+
+	if n.maxLength != nil && length > *n.maxLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d characters", *n.maxLength)}
+	}
+
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("does not match pattern %q", n.pattern.String())}
+	}
+
+	return nil
+}
+
+func (n *node) validateNumber(f float64, path string) error {
+	if n.minimum != nil && f < *n.minimum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %v", *n.minimum)}
+	}
+
+	if n.maximum != nil && f > *n.maximum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %v", *n.maximum)}
+	}
+
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be greater than %v", *n.exclusiveMinimum)}
+	}
+
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be less than %v", *n.exclusiveMaximum)}
+	}
+
+	if n.multipleOf != nil {
+		q := f / *n.multipleOf
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be a multiple of %v", *n.multipleOf)}
+		}
+	}
+
+	return nil
+}
+
+func (n *node) validateCombinators(value any, path string) error {
+	for _, sub := range n.allOf {
+		if err := sub.validate(value, path); err != nil {
+			return err
+		}
+	}
+
+	if len(n.anyOf) > 0 {
+		matched := false
+		for _, sub := range n.anyOf {
+			if sub.validate(value, path) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return &ValidationError{Path: path, Message: "value matches none of anyOf"}
+		}
+	}
+
+	if len(n.oneOf) > 0 {
+		matches := 0
+		for _, sub := range n.oneOf {
+			if sub.validate(value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("value matches %d of oneOf, expected exactly 1", matches)}
+		}
+	}
+
+	if n.not != nil && n.not.validate(value, path) == nil {
+		return &ValidationError{Path: path, Message: "value matches a schema it must not match"}
+	}
+
+	return nil
+}
+
+func validType(t string) bool {
+	switch t {
+	case "object", "array", "string", "number", "integer", "boolean", "null":
+		return true
+
+	default:
+		return false
+	}
+}
+
+func matchesAnyType(value any, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if f, ok := value.(float64); ok && f == math.Trunc(f) {
+				return true
+			}
+		default:
+			if typeOf(value) == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func boolKeyword(obj map[string]any, keyword, location string) (bool, error) {
+	raw, ok := obj[keyword]
+	if !ok {
+		return false, nil
+	}
+	b, ok := raw.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s/%s: must be a boolean", location, keyword)
+	}
+	return b, nil
+}
+
+func intKeyword(obj map[string]any, keyword, location string) (*int, error) {
+	raw, ok := obj[keyword]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := raw.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s/%s: must be a non-negative integer", location, keyword)
+	}
+	i := int(f)
+	return &i, nil
+}
+
+func numberKeyword(obj map[string]any, keyword, location string) (*float64, error) {
+	raw, ok := obj[keyword]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := raw.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s/%s: must be a number", location, keyword)
+	}
+	return &f, nil
+}
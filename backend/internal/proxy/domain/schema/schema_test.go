@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+)
+
+const weatherSchema = `{
+	"type": "object",
+	"properties": {
+		"city": {"type": "string", "minLength": 1},
+		"unit": {"type": "string", "enum": ["celsius", "fahrenheit"]},
+		"days": {"type": "integer", "minimum": 1, "maximum": 7},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2, "uniqueItems": true},
+		"location": {"$ref": "#/$defs/location"}
+	},
+	"required": ["city"],
+	"additionalProperties": false,
+	"$defs": {
+		"location": {
+			"type": "object",
+			"properties": {
+				"lat": {"type": "number"},
+				"lon": {"type": "number"}
+			},
+			"required": ["lat", "lon"]
+		}
+	}
+}`
+
+func TestValidateJSON(t *testing.T) {
+	s := MustParse(weatherSchema)
+
+	tests := []struct {
+		name     string
+		instance string
+		valid    bool
+	}{
+		{"Minimal", `{"city":"Paris"}`, true},
+		{"All properties", `{"city":"Paris","unit":"celsius","days":3,"tags":["a","b"],"location":{"lat":48.8,"lon":2.3}}`, true},
+		{"Missing required", `{"unit":"celsius"}`, false},
+		{"Wrong type", `{"city":42}`, false},
+		{"Empty string", `{"city":""}`, false},
+		{"Not in enum", `{"city":"Paris","unit":"kelvin"}`, false},
+		{"Not an integer", `{"city":"Paris","days":1.5}`, false},
+		{"Above maximum", `{"city":"Paris","days":8}`, false},
+		{"Too many items", `{"city":"Paris","tags":["a","b","c"]}`, false},
+		{"Duplicate items", `{"city":"Paris","tags":["a","a"]}`, false},
+		{"Additional property", `{"city":"Paris","extra":true}`, false},
+		{"Invalid ref target", `{"city":"Paris","location":{"lat":48.8}}`, false},
+		{"Not an object", `["Paris"]`, false},
+		{"Not JSON", `{"city":`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ValidateJSON([]byte(tt.instance))
+			if tt.valid && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{"anyOf match", `{"anyOf":[{"type":"string"},{"type":"number"}]}`, `1`, true},
+		{"anyOf no match", `{"anyOf":[{"type":"string"},{"type":"number"}]}`, `true`, false},
+		{"oneOf single match", `{"oneOf":[{"type":"integer"},{"type":"string"}]}`, `1`, true},
+		{"oneOf double match", `{"oneOf":[{"type":"integer"},{"type":"number"}]}`, `1`, false},
+		{"allOf", `{"allOf":[{"minimum":1},{"maximum":3}]}`, `4`, false},
+		{"not", `{"not":{"type":"null"}}`, `null`, false},
+		{"const", `{"const":"fixed"}`, `"fixed"`, true},
+		{"nullable type", `{"type":["string","null"]}`, `null`, true},
+		{"pattern", `{"type":"string","pattern":"^[a-z]+$"}`, `"ABC"`, false},
+		{"recursive ref", `{"type":"object","properties":{"child":{"$ref":"#"}},"additionalProperties":false}`, `{"child":{"child":{}}}`, true},
+		{"recursive ref invalid", `{"type":"object","properties":{"child":{"$ref":"#"}},"additionalProperties":false}`, `{"child":{"other":1}}`, false},
+		{"false schema", `false`, `{}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			err = s.ValidateJSON([]byte(tt.instance))
+			if tt.valid && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"Not JSON", `{`},
+		{"Not an object", `"string"`},
+		{"Unknown type", `{"type":"text"}`},
+		{"Bad required", `{"required":"city"}`},
+		{"Bad pattern", `{"pattern":"("}`},
+		{"Remote ref", `{"$ref":"https://example.com/schema.json"}`},
+		{"Unresolvable ref", `{"$ref":"#/$defs/missing"}`},
+		{"Empty anyOf", `{"anyOf":[]}`},
+		{"Negative minLength", `{"minLength":-1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.schema))
+			if !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("Expected ErrInvalidSchema, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidationErrorPath(t *testing.T) {
+	s := MustParse(weatherSchema)
+
+	err := s.ValidateJSON([]byte(`{"city":"Paris","location":{"lat":"north","lon":2.3}}`))
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	if validationErr.Path != "$.location.lat" {
+		t.Errorf("Expected path $.location.lat, got %s", validationErr.Path)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	s := MustParse(`{ "type": "object" }`)
+
+	if string(s.Raw()) != `{"type":"object"}` {
+		t.Errorf("Expected compacted schema, got %s", s.Raw())
+	}
+}
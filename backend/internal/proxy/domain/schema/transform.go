@@ -0,0 +1,285 @@
+package schema
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Keywords whose values are themselves schemas. Transforms only descend
+// through these so property names are never mistaken for keywords.
+var (
+	schemaKeywords      = []string{"items", "additionalProperties", "not"}
+	schemaListKeywords  = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+	schemaMapKeywords   = []string{"properties", "$defs", "definitions"}
+	definitionsKeywords = []string{"$defs", "definitions"}
+)
+
+// openAPIKeywords are the keywords accepted by OpenAPI 3.0 style schemas,
+// as used by Gemini function declarations.
+var openAPIKeywords = []string{
+	"type", "format", "title", "description", "nullable", "enum", "default",
+	"properties", "required", "minProperties", "maxProperties",
+	"items", "minItems", "maxItems",
+	"minLength", "maxLength", "pattern",
+	"minimum", "maximum", "anyOf",
+}
+
+// Document returns a copy of the schema as generic JSON values.
+func (s *Schema) Document() any {
+	var doc any
+	_ = json.Unmarshal(s.raw, &doc)
+	return doc
+}
+
+// Inline returns the schema with every local $ref replaced by its target and
+// the definitions removed. A recursive reference cannot be inlined and is
+// replaced by an unconstrained schema.
+func (s *Schema) Inline() any {
+	doc := s.Document()
+	return inline(doc, doc, map[string]bool{})
+}
+
+// Strict returns the schema in the shape OpenAI's strict mode requires:
+// objects are closed and list every property as required, with properties
+// that were optional made nullable instead.
+func (s *Schema) Strict() any {
+	return strict(s.Document())
+}
+
+// OpenAPI returns the schema reduced to the OpenAPI 3.0 subset Gemini
+// accepts: references are inlined, ["T", "null"] becomes nullable and
+// unsupported keywords are dropped.
+func (s *Schema) OpenAPI() any {
+	return openAPI(s.Inline())
+}
+
+func inline(v, root any, resolving map[string]bool) any {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+
+	out := make(map[string]any, len(obj))
+	if ref, ok := obj["$ref"].(string); ok {
+		target, found := lookup(root, ref)
+		if found && !resolving[ref] {
+			resolving[ref] = true
+			if resolved, ok := inline(target, root, resolving).(map[string]any); ok {
+				for key, value := range resolved {
+					out[key] = value
+				}
+			}
+			delete(resolving, ref)
+		}
+	}
+
+	for key, value := range obj {
+		if key == "$ref" || slices.Contains(definitionsKeywords, key) {
+			continue
+		}
+		out[key] = value
+	}
+
+	return mapSubschemas(out, func(sub any) any { return inline(sub, root, resolving) })
+}
+
+func strict(v any) any {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	obj = mapSubschemas(obj, strict)
+
+	props, ok := obj["properties"].(map[string]any)
+	if !ok && !hasType(obj, "object") {
+		return obj
+	}
+
+	required := map[string]bool{}
+	if names, ok := obj["required"].([]any); ok {
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]any, 0, len(props))
+	for _, name := range sortedKeys(props) {
+		if !required[name] {
+			props[name] = nullable(props[name])
+		}
+		names = append(names, name)
+	}
+
+	if props == nil {
+		props = map[string]any{}
+	}
+	obj["properties"] = props
+	obj["required"] = names
+	obj["additionalProperties"] = false
+
+	return obj
+}
+
+func openAPI(v any) any {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+
+	out := make(map[string]any, len(obj))
+	for key, value := range obj {
+		if slices.Contains(openAPIKeywords, key) {
+			out[key] = value
+		}
+	}
+
+	if cnst, ok := obj["const"]; ok {
+		out["enum"] = []any{cnst}
+	}
+
+	// oneOf has no OpenAPI 3.0 equivalent Gemini accepts; anyOf is the
+	// closest.
+	if oneOf, ok := obj["oneOf"]; ok {
+		if _, exists := out["anyOf"]; !exists {
+			out["anyOf"] = oneOf
+		}
+	}
+
+	if types, ok := obj["type"].([]any); ok {
+		var nonNull []any
+		for _, t := range types {
+			if t == "null" {
+				out["nullable"] = true
+				continue
+			}
+			nonNull = append(nonNull, t)
+		}
+		if len(nonNull) == 1 {
+			out["type"] = nonNull[0]
+		} else {
+			delete(out, "type")
+		}
+	}
+
+	return mapSubschemas(out, openAPI)
+}
+
+// nullable widens a schema to also accept null.
+func nullable(v any) any {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+
+	if enum, ok := obj["enum"].([]any); ok && !slices.Contains(enum, nil) {
+		obj["enum"] = append(enum, nil)
+	}
+
+	switch t := obj["type"].(type) {
+	case string:
+		if t != "null" {
+			obj["type"] = []any{t, "null"}
+		}
+		return obj
+
+	case []any:
+		if !slices.Contains(t, any("null")) {
+			obj["type"] = append(t, "null")
+		}
+		return obj
+
+	default:
+		return map[string]any{"anyOf": []any{obj, map[string]any{"type": "null"}}}
+	}
+}
+
+// mapSubschemas applies fn to every direct subschema of obj, in place.
+func mapSubschemas(obj map[string]any, fn func(any) any) map[string]any {
+	for _, key := range schemaKeywords {
+		if sub, ok := obj[key]; ok {
+			obj[key] = fn(sub)
+		}
+	}
+
+	for _, key := range schemaListKeywords {
+		if list, ok := obj[key].([]any); ok {
+			mapped := make([]any, len(list))
+			for i, sub := range list {
+				mapped[i] = fn(sub)
+			}
+			obj[key] = mapped
+		}
+	}
+
+	for _, key := range schemaMapKeywords {
+		if subs, ok := obj[key].(map[string]any); ok {
+			mapped := make(map[string]any, len(subs))
+			for name, sub := range subs {
+				mapped[name] = fn(sub)
+			}
+			obj[key] = mapped
+		}
+	}
+
+	return obj
+}
+
+// lookup resolves a local JSON pointer reference such as "#/$defs/address".
+func lookup(doc any, ref string) (any, bool) {
+	if ref == "#" {
+		return doc, true
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+
+	target := doc
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch t := target.(type) {
+		case map[string]any:
+			next, ok := t[token]
+			if !ok {
+				return nil, false
+			}
+			target = next
+
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, false
+			}
+			target = t[i]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return target, true
+}
+
+func hasType(obj map[string]any, name string) bool {
+	switch t := obj["type"].(type) {
+	case string:
+		return t == name
+	case []any:
+		return slices.Contains(t, any(name))
+	default:
+		return false
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name      string
+		schema    string
+		transform func(*Schema) any
+		expected  string
+	}{
+		{
+			"Inline refs",
+			`{"type":"object","properties":{"home":{"$ref":"#/$defs/address"}},"$defs":{"address":{"type":"object","properties":{"city":{"type":"string"}}}}}`,
+			(*Schema).Inline,
+			`{"properties":{"home":{"properties":{"city":{"type":"string"}},"type":"object"}},"type":"object"}`,
+		},
+		{
+			"Inline recursive ref",
+			`{"type":"object","properties":{"child":{"$ref":"#"}}}`,
+			(*Schema).Inline,
+			`{"properties":{"child":{"properties":{"child":{}},"type":"object"}},"type":"object"}`,
+		},
+		{
+			"Inline keeps property named like a keyword",
+			`{"type":"object","properties":{"$defs":{"type":"string"}}}`,
+			(*Schema).Inline,
+			`{"properties":{"$defs":{"type":"string"}},"type":"object"}`,
+		},
+		{
+			"Strict closes objects and requires every property",
+			`{"type":"object","properties":{"city":{"type":"string"},"unit":{"type":"string"},"days":{"$ref":"#/$defs/days"}},"required":["city"],"$defs":{"days":{"type":"integer"}}}`,
+			(*Schema).Strict,
+			`{"$defs":{"days":{"type":"integer"}},"additionalProperties":false,"properties":{"city":{"type":"string"},"days":{"anyOf":[{"$ref":"#/$defs/days"},{"type":"null"}]},"unit":{"type":["string","null"]}},"required":["city","days","unit"],"type":"object"}`,
+		},
+		{
+			"Strict nested objects",
+			`{"type":"object","properties":{"items":{"type":"array","items":{"type":"object","properties":{"id":{"type":"string"}}}}},"required":["items"]}`,
+			(*Schema).Strict,
+			`{"additionalProperties":false,"properties":{"items":{"items":{"additionalProperties":false,"properties":{"id":{"type":["string","null"]}},"required":["id"],"type":"object"},"type":"array"}},"required":["items"],"type":"object"}`,
+		},
+		{
+			"OpenAPI subset",
+			`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"name":{"type":["string","null"]},"kind":{"const":"user"},"value":{"oneOf":[{"type":"string"},{"type":"number"}]}},"additionalProperties":false}`,
+			(*Schema).OpenAPI,
+			`{"properties":{"kind":{"enum":["user"]},"name":{"nullable":true,"type":"string"},"value":{"anyOf":[{"type":"string"},{"type":"number"}]}},"type":"object"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := json.Marshal(tt.transform(MustParse(tt.schema)))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if string(out) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestStrictOutputIsValidSchema(t *testing.T) {
+	strictSchema, err := json.Marshal(MustParse(weatherSchema).Strict())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	s, err := Parse(strictSchema)
+	if err != nil {
+		t.Fatalf("Expected strict schema to parse, got %v", err)
+	}
+
+	if err := s.ValidateJSON([]byte(`{"city":"Paris","unit":null,"days":null,"tags":null,"location":null}`)); err != nil {
+		t.Errorf("Expected nulls for optional properties to be accepted, got %v", err)
+	}
+
+	if err := s.ValidateJSON([]byte(`{"city":"Paris"}`)); err == nil {
+		t.Error("Expected omitted properties to be rejected")
+	}
+}
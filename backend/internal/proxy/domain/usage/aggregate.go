@@ -0,0 +1,124 @@
+package usage
+
+import (
+	"errors"
+	"time"
+)
+
+// Period is the width of the time buckets usage is aggregated into.
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+func (p Period) String() string {
+	return string(p)
+}
+
+func (p Period) IsValid() bool {
+	switch p {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Start returns the start of the bucket t falls in, in UTC. Weeks start on
+// Monday.
+func (p Period) Start(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	switch p {
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// GroupBy is the dimension usage is broken down by within each bucket.
+type GroupBy string
+
+const (
+	GroupByModel    GroupBy = "model"
+	GroupByProvider GroupBy = "provider"
+	GroupByEndpoint GroupBy = "endpoint"
+)
+
+func (g GroupBy) String() string {
+	return string(g)
+}
+
+func (g GroupBy) IsValid() bool {
+	switch g {
+	case GroupByModel, GroupByProvider, GroupByEndpoint:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// MaxQueryRange bounds how much history a single query may cover.
+const MaxQueryRange = 366 * 24 * time.Hour
+
+// Query selects aggregated usage between From (inclusive) and To
+// (exclusive). An empty AccountID covers every account.
+type Query struct {
+	AccountID string
+	From      time.Time
+	To        time.Time
+	Period    Period
+	GroupBy   GroupBy
+}
+
+func (q Query) Validate() error {
+	if !q.Period.IsValid() {
+		return errors.New("period must be day, week or month")
+	}
+
+	if !q.GroupBy.IsValid() {
+		return errors.New("group_by must be model, provider or endpoint")
+	}
+
+	if !q.From.Before(q.To) {
+		return errors.New("from must be before to")
+	}
+
+	if q.To.Sub(q.From) > MaxQueryRange {
+		return errors.New("date range cannot exceed 366 days")
+	}
+
+	return nil
+}
+
+// Aggregate is the usage of one group within one time bucket. Cost is in
+// micro-credits.
+type Aggregate struct {
+	BucketStart      time.Time
+	Key              string
+	Requests         int64
+	FailedRequests   int64
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             int64
+}
+
+func (a Aggregate) TotalTokens() int64 {
+	return a.PromptTokens + a.CompletionTokens
+}
+
+// ErrorRate is the share of requests that failed, between 0 and 1.
+func (a Aggregate) ErrorRate() float64 {
+	if a.Requests == 0 {
+		return 0
+	}
+	return float64(a.FailedRequests) / float64(a.Requests)
+}
@@ -0,0 +1,89 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// Thursday
+	ts := time.Date(2026, 10, 15, 17, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period   Period
+		expected time.Time
+	}{
+		{PeriodDay, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{PeriodWeek, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonth, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period.String(), func(t *testing.T) {
+			if got := tt.period.Start(ts); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPeriodStartOnSunday(t *testing.T) {
+	sunday := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	expected := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+
+	if got := PeriodWeek.Start(sunday); !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestQueryValidate(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	valid := Query{From: from, To: from.AddDate(0, 0, 30), Period: PeriodDay, GroupBy: GroupByModel}
+
+	tests := []struct {
+		name    string
+		modify  func(q *Query)
+		wantErr bool
+	}{
+		{"Valid", func(q *Query) {}, false},
+		{"Unknown period", func(q *Query) { q.Period = "year" }, true},
+		{"Unknown group", func(q *Query) { q.GroupBy = "account" }, true},
+		{"Empty range", func(q *Query) { q.To = q.From }, true},
+		{"Range too long", func(q *Query) { q.To = q.From.AddDate(2, 0, 0) }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := valid
+			tt.modify(&q)
+
+			err := q.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAggregateErrorRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		aggregate Aggregate
+		expected  float64
+	}{
+		{"No requests", Aggregate{}, 0},
+		{"No failures", Aggregate{Requests: 10}, 0},
+		{"Some failures", Aggregate{Requests: 8, FailedRequests: 2}, 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.aggregate.ErrorRate(); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
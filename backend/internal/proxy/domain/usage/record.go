@@ -0,0 +1,210 @@
+package usage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/shared/domain"
+)
+
+type ID = domain.ID[Record]
+
+var (
+	NewID     = domain.NewID[Record]
+	HydrateID = domain.HydrateID[Record]
+)
+
+// ErrorCode classifies why a proxied call failed, independently of the
+// provider that served it. Successful calls have no code.
+type ErrorCode string
+
+const (
+	ErrorCodeNone                   ErrorCode = ""
+	ErrorCodeInvalidRequest         ErrorCode = "invalid_request"
+	ErrorCodeModelNotFound          ErrorCode = "model_not_found"
+	ErrorCodeContextWindowExceeded  ErrorCode = "context_window_exceeded"
+	ErrorCodeTargetUnavailable      ErrorCode = "target_unavailable"
+	ErrorCodeBillingFailed          ErrorCode = "billing_failed"
+	ErrorCodeUpstreamUnreachable    ErrorCode = "upstream_unreachable"
+	ErrorCodeUpstreamRateLimited    ErrorCode = "upstream_rate_limited"
+	ErrorCodeUpstreamRejected       ErrorCode = "upstream_rejected"
+	ErrorCodeUpstreamError          ErrorCode = "upstream_error"
+	ErrorCodeResponseFormatMismatch ErrorCode = "response_format_mismatch"
+	ErrorCodeCanceled               ErrorCode = "canceled"
+	ErrorCodeInternal               ErrorCode = "internal"
+)
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+func (c ErrorCode) IsValid() bool {
+	switch c {
+	case ErrorCodeNone, ErrorCodeInvalidRequest, ErrorCodeModelNotFound, ErrorCodeContextWindowExceeded,
+		ErrorCodeTargetUnavailable, ErrorCodeBillingFailed, ErrorCodeUpstreamUnreachable,
+		ErrorCodeUpstreamRateLimited, ErrorCodeUpstreamRejected, ErrorCodeUpstreamError,
+		ErrorCodeResponseFormatMismatch, ErrorCodeCanceled, ErrorCodeInternal:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Record is the audit entry for one attempt to serve a proxied request. A
+// request that fails over has one record per target tried, sharing the
+// request ID.
+type Record struct {
+	id               ID
+	requestID        string
+	accountID        string
+	providerID       string
+	modelKey         string
+	endpoint         string
+	stream           bool
+	promptTokens     int
+	completionTokens int
+	cost             int64
+	latency          time.Duration
+	upstreamStatus   int
+	finishReason     string
+	errorCode        ErrorCode
+	createdAt        time.Time
+}
+
+// Attempt describes what happened during one attempt. Cost is in
+// micro-credits.
+type Attempt struct {
+	RequestID        string
+	AccountID        string
+	ProviderID       string
+	ModelKey         string
+	Endpoint         string
+	Stream           bool
+	PromptTokens     int
+	CompletionTokens int
+	Cost             int64
+	Latency          time.Duration
+	UpstreamStatus   int // 0 when the provider was never reached
+	FinishReason     string
+	ErrorCode        ErrorCode
+}
+
+func New(a Attempt) (*Record, error) {
+	if a.RequestID == "" {
+		return nil, errors.New("request ID is required")
+	}
+
+	if a.PromptTokens < 0 || a.CompletionTokens < 0 {
+		return nil, errors.New("token counts cannot be negative")
+	}
+
+	if a.Cost < 0 {
+		return nil, errors.New("cost cannot be negative")
+	}
+
+	if a.Latency < 0 {
+		return nil, errors.New("latency cannot be negative")
+	}
+
+	if !a.ErrorCode.IsValid() {
+		return nil, errors.New("invalid error code")
+	}
+
+	return hydrate(NewID(), a, time.Now()), nil
+}
+
+type HydrateData struct {
+	ID        ID
+	Attempt   Attempt
+	CreatedAt time.Time
+}
+
+func Hydrate(data HydrateData) *Record {
+	return hydrate(data.ID, data.Attempt, data.CreatedAt)
+}
+
+func hydrate(id ID, a Attempt, createdAt time.Time) *Record {
+	return &Record{
+		id:               id,
+		requestID:        a.RequestID,
+		accountID:        a.AccountID,
+		providerID:       a.ProviderID,
+		modelKey:         a.ModelKey,
+		endpoint:         a.Endpoint,
+		stream:           a.Stream,
+		promptTokens:     a.PromptTokens,
+		completionTokens: a.CompletionTokens,
+		cost:             a.Cost,
+		latency:          a.Latency,
+		upstreamStatus:   a.UpstreamStatus,
+		finishReason:     a.FinishReason,
+		errorCode:        a.ErrorCode,
+		createdAt:        createdAt,
+	}
+}
+
+func (r *Record) ID() ID {
+	return r.id
+}
+
+func (r *Record) RequestID() string {
+	return r.requestID
+}
+
+func (r *Record) AccountID() string {
+	return r.accountID
+}
+
+func (r *Record) ProviderID() string {
+	return r.providerID
+}
+
+func (r *Record) ModelKey() string {
+	return r.modelKey
+}
+
+func (r *Record) Endpoint() string {
+	return r.endpoint
+}
+
+func (r *Record) Stream() bool {
+	return r.stream
+}
+
+func (r *Record) PromptTokens() int {
+	return r.promptTokens
+}
+
+func (r *Record) CompletionTokens() int {
+	return r.completionTokens
+}
+
+func (r *Record) Cost() int64 {
+	return r.cost
+}
+
+func (r *Record) Latency() time.Duration {
+	return r.latency
+}
+
+func (r *Record) UpstreamStatus() int {
+	return r.upstreamStatus
+}
+
+func (r *Record) FinishReason() string {
+	return r.finishReason
+}
+
+func (r *Record) ErrorCode() ErrorCode {
+	return r.errorCode
+}
+
+// Succeeded reports whether the attempt produced a response.
+func (r *Record) Succeeded() bool {
+	return r.errorCode == ErrorCodeNone
+}
+
+func (r *Record) CreatedAt() time.Time {
+	return r.createdAt
+}
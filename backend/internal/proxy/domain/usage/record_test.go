@@ -0,0 +1,78 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	r, err := New(Attempt{
+		RequestID:        "req-1",
+		AccountID:        "account-1",
+		ProviderID:       "provider-a",
+		ModelKey:         "gpt-4o",
+		Endpoint:         "chat",
+		PromptTokens:     120,
+		CompletionTokens: 30,
+		Cost:             2,
+		Latency:          800 * time.Millisecond,
+		UpstreamStatus:   200,
+		FinishReason:     "stop",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if r.ID().String() == "" {
+		t.Error("Expected ID to be generated")
+	}
+
+	if !r.Succeeded() {
+		t.Error("Expected attempt without error code to have succeeded")
+	}
+
+	if r.PromptTokens() != 120 || r.CompletionTokens() != 30 {
+		t.Errorf("Expected 120/30 tokens, got %d/%d", r.PromptTokens(), r.CompletionTokens())
+	}
+
+	if r.CreatedAt().IsZero() {
+		t.Error("Expected creation time to be set")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt Attempt
+	}{
+		{"Missing request ID", Attempt{}},
+		{"Negative prompt tokens", Attempt{RequestID: "req-1", PromptTokens: -1}},
+		{"Negative completion tokens", Attempt{RequestID: "req-1", CompletionTokens: -1}},
+		{"Negative cost", Attempt{RequestID: "req-1", Cost: -1}},
+		{"Negative latency", Attempt{RequestID: "req-1", Latency: -time.Second}},
+		{"Unknown error code", Attempt{RequestID: "req-1", ErrorCode: "boom"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.attempt); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestFailedAttempt(t *testing.T) {
+	r, err := New(Attempt{RequestID: "req-1", UpstreamStatus: 429, ErrorCode: ErrorCodeUpstreamRateLimited})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if r.Succeeded() {
+		t.Error("Expected attempt with error code to have failed")
+	}
+
+	if r.ErrorCode() != ErrorCodeUpstreamRateLimited {
+		t.Errorf("Expected %s, got %s", ErrorCodeUpstreamRateLimited, r.ErrorCode())
+	}
+}
@@ -0,0 +1,48 @@
+package billing
+
+import (
+	"context"
+
+	billingdto "github.com/basetable/basetable/backend/internal/billing/application/dto"
+	billingservice "github.com/basetable/basetable/backend/internal/billing/application/service"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+)
+
+// Gateway adapts the billing context's application service to the proxy's
+// BillingGateway port.
+type Gateway struct {
+	billingService billingservice.BillingService
+}
+
+var _ service.BillingGateway = (*Gateway)(nil)
+
+func NewGateway(billingService billingservice.BillingService) *Gateway {
+	return &Gateway{billingService: billingService}
+}
+
+func (gw *Gateway) ReserveCredits(ctx context.Context, accountID string, amount int64) (string, error) {
+	resp, err := gw.billingService.ReserveCredits(ctx, billingdto.ReserveCreditRequest{
+		AccountID: accountID,
+		Amount:    amount,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.ReservationID, nil
+}
+
+func (gw *Gateway) CommitReservation(ctx context.Context, reservationID string, amount int64) error {
+	_, err := gw.billingService.CommitReservation(ctx, billingdto.CommitReservationRequest{
+		ReservationID: reservationID,
+		ActualAmount:  amount,
+	})
+	return err
+}
+
+func (gw *Gateway) ReleaseReservation(ctx context.Context, reservationID string) error {
+	_, err := gw.billingService.ReleaseReservation(ctx, billingdto.ReleaseReservationRequest{
+		ReservationID: reservationID,
+	})
+	return err
+}
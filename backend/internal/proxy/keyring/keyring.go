@@ -0,0 +1,213 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+const dataKeySize = 32 // AES-256
+
+// KeyRing implements envelope encryption with locally held master keys. Each
+// credential gets its own data key, which is stored wrapped by the master key
+// named in the credential's KeyID. Old master keys are kept so credentials
+// sealed under them can still be opened until they are rewrapped.
+type KeyRing struct {
+	keys      map[string][]byte
+	currentID string
+}
+
+var _ service.CredentialVault = (*KeyRing)(nil)
+
+type Config struct {
+	// MasterKeys maps key IDs to 32-byte AES-256 keys.
+	MasterKeys map[string][]byte
+	// CurrentKeyID names the key new credentials are sealed with.
+	CurrentKeyID string
+}
+
+func NewKeyRing(cfg Config) (*KeyRing, error) {
+	if _, ok := cfg.MasterKeys[cfg.CurrentKeyID]; !ok {
+		return nil, fmt.Errorf("current master key %q is not in the key ring", cfg.CurrentKeyID)
+	}
+
+	for id, key := range cfg.MasterKeys {
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes", id, dataKeySize)
+		}
+	}
+
+	return &KeyRing{
+		keys:      cfg.MasterKeys,
+		currentID: cfg.CurrentKeyID,
+	}, nil
+}
+
+// ParseMasterKeys parses a comma separated list of "<key id>:<base64 key>".
+func ParseMasterKeys(value string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid master key entry %q", entry)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no master keys configured")
+	}
+
+	return keys, nil
+}
+
+func (k *KeyRing) CurrentKeyID() string {
+	return k.currentID
+}
+
+func (k *KeyRing) Seal(plaintext string) (provider.Credential, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return provider.Credential{}, err
+	}
+
+	ciphertext, err := encrypt(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		return provider.Credential{}, err
+	}
+
+	return k.wrap(ciphertext, dataKey)
+}
+
+func (k *KeyRing) Open(credential provider.Credential) (string, error) {
+	if !credential.IsSealed() {
+		return credential.Encrypted, nil
+	}
+
+	dataKey, err := k.unwrap(credential)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(credential.Encrypted)
+	if err != nil {
+		return "", fmt.Errorf("invalid credential ciphertext: %w", err)
+	}
+
+	plaintext, err := decrypt(dataKey, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credential: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+func (k *KeyRing) Rewrap(credential provider.Credential) (provider.Credential, error) {
+	if !credential.IsSealed() {
+		return k.Seal(credential.Encrypted)
+	}
+
+	if credential.KeyID == k.currentID {
+		return credential, nil
+	}
+
+	dataKey, err := k.unwrap(credential)
+	if err != nil {
+		return provider.Credential{}, err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(credential.Encrypted)
+	if err != nil {
+		return provider.Credential{}, fmt.Errorf("invalid credential ciphertext: %w", err)
+	}
+
+	return k.wrap(ciphertext, dataKey)
+}
+
+// wrap encrypts the data key under the current master key. The key ID is
+// bound in as additional data so a wrapped key cannot be relabelled.
+func (k *KeyRing) wrap(ciphertext, dataKey []byte) (provider.Credential, error) {
+	wrapped, err := encrypt(k.keys[k.currentID], dataKey, []byte(k.currentID))
+	if err != nil {
+		return provider.Credential{}, err
+	}
+
+	return provider.Credential{
+		Encrypted: base64.StdEncoding.EncodeToString(ciphertext),
+		DataKey:   base64.StdEncoding.EncodeToString(wrapped),
+		KeyID:     k.currentID,
+	}, nil
+}
+
+func (k *KeyRing) unwrap(credential provider.Credential) ([]byte, error) {
+	masterKey, ok := k.keys[credential.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", credential.KeyID)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(credential.DataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+
+	dataKey, err := decrypt(masterKey, wrapped, []byte(credential.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return dataKey, nil
+}
+
+// encrypt seals plaintext with AES-GCM, prefixing the random nonce.
+func encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,126 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+func masterKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, dataKeySize)
+}
+
+func newTestKeyRing(t *testing.T, current string, keys map[string][]byte) *KeyRing {
+	t.Helper()
+
+	ring, err := NewKeyRing(Config{MasterKeys: keys, CurrentKeyID: current})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return ring
+}
+
+func TestSealOpen(t *testing.T) {
+	ring := newTestKeyRing(t, "v1", map[string][]byte{"v1": masterKey(1)})
+
+	credential, err := ring.Seal("sk-secret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !credential.IsSealed() || credential.KeyID != "v1" {
+		t.Errorf("Expected a credential sealed under v1, got %+v", credential)
+	}
+	if credential.Encrypted == "sk-secret" {
+		t.Error("Expected the credential to be encrypted")
+	}
+
+	plaintext, err := ring.Open(credential)
+	if err != nil || plaintext != "sk-secret" {
+		t.Errorf("Expected sk-secret, got %q (%v)", plaintext, err)
+	}
+
+	// Credentials stored before encryption hold their plaintext.
+	plaintext, err = ring.Open(provider.Credential{Encrypted: "sk-legacy"})
+	if err != nil || plaintext != "sk-legacy" {
+		t.Errorf("Expected sk-legacy, got %q (%v)", plaintext, err)
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	old := newTestKeyRing(t, "v1", map[string][]byte{"v1": masterKey(1)})
+	sealed, err := old.Seal("sk-secret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ring := newTestKeyRing(t, "v2", map[string][]byte{"v1": masterKey(1), "v2": masterKey(2)})
+
+	tests := []struct {
+		name       string
+		credential provider.Credential
+	}{
+		{"Sealed under an old key", sealed},
+		{"Legacy plaintext", provider.Credential{Encrypted: "sk-secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewrapped, err := ring.Rewrap(tt.credential)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if rewrapped.KeyID != "v2" {
+				t.Errorf("Expected key ID v2, got %s", rewrapped.KeyID)
+			}
+
+			plaintext, err := ring.Open(rewrapped)
+			if err != nil || plaintext != "sk-secret" {
+				t.Errorf("Expected sk-secret, got %q (%v)", plaintext, err)
+			}
+		})
+	}
+
+	// Credentials not yet rewrapped still open while the old key is loaded.
+	if plaintext, err := ring.Open(sealed); err != nil || plaintext != "sk-secret" {
+		t.Errorf("Expected sk-secret, got %q (%v)", plaintext, err)
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	// Both IDs name the same key, so only the additional data tells them apart.
+	ring := newTestKeyRing(t, "v1", map[string][]byte{"v1": masterKey(1), "v2": masterKey(1)})
+	sealed, err := ring.Seal("sk-secret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ciphertext, _ := base64.StdEncoding.DecodeString(sealed.Encrypted)
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	tampered := sealed
+	tampered.Encrypted = base64.StdEncoding.EncodeToString(ciphertext)
+
+	unknown := sealed
+	unknown.KeyID = "v9"
+
+	relabelled := sealed
+	relabelled.KeyID = "v2"
+
+	tests := []struct {
+		name       string
+		credential provider.Credential
+	}{
+		{"Unknown key ID", unknown},
+		{"Tampered ciphertext", tampered},
+		{"Relabelled data key", relabelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plaintext, err := ring.Open(tt.credential); err == nil {
+				t.Errorf("Expected error, got %q", plaintext)
+			}
+		})
+	}
+}
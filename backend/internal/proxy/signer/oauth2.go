@@ -0,0 +1,128 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenExpiryMargin refreshes tokens this long before they expire, so a
+	// token never lapses between signing and the provider checking it.
+	tokenExpiryMargin = time.Minute
+	// defaultTokenLifetime is assumed when the token response has no
+	// expires_in.
+	defaultTokenLifetime = 5 * time.Minute
+)
+
+// tokenSource fetches and caches access tokens with the OAuth2 client
+// credentials grant (RFC 6749 section 4.4).
+type tokenSource struct {
+	httpClient   *http.Client
+	now          func() time.Time
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	// mu is held while fetching, so concurrent requests wait for one fetch
+	// instead of each starting their own.
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (s *tokenSource) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && s.now().Before(s.expiresAt.Add(-tokenExpiryMargin)) {
+		return s.accessToken, nil
+	}
+
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch oauth2 token: %w", err)
+	}
+
+	lifetime := defaultTokenLifetime
+	if token.ExpiresIn > 0 {
+		lifetime = time.Duration(token.ExpiresIn) * time.Second
+	}
+
+	s.accessToken = token.AccessToken
+	s.expiresAt = s.now().Add(lifetime)
+	return s.accessToken, nil
+}
+
+// invalidate drops the cached token if it is still the given one. A token
+// another request has already replaced is left alone.
+func (s *tokenSource) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token != "" && s.accessToken == token {
+		s.accessToken = ""
+		s.expiresAt = time.Time{}
+	}
+}
+
+func (s *tokenSource) fetch(ctx context.Context) (tokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.scopes) > 0 {
+		form.Set("scope", strings.Join(s.scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// RFC 6749 section 2.3.1 form-encodes the client credentials before
+	// using them for basic authentication.
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return tokenResponse{}, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, body)
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return tokenResponse{}, fmt.Errorf("invalid token response: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return tokenResponse{}, errors.New("token response has no access_token")
+	}
+
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return tokenResponse{}, fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	return token, nil
+}
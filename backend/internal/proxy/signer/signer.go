@@ -0,0 +1,221 @@
+package signer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+// Factory builds the signer for each provider auth scheme. OAuth2 tokens are
+// cached per client, so every request for a provider shares one token until
+// it nears expiry, the provider rejects it or the provider is forgotten.
+type Factory struct {
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*tokenSource
+	// users holds the token keys each provider signs with, so tokens can be
+	// dropped once no provider uses them.
+	users map[string]map[string]bool
+}
+
+var _ service.SignerFactory = (*Factory)(nil)
+
+type Config struct {
+	// HTTPClient fetches OAuth2 tokens.
+	HTTPClient *http.Client
+	// Now is the clock used for token expiry and signing dates.
+	Now func() time.Time
+}
+
+const DefaultTokenTimeout = 10 * time.Second
+
+func NewFactory(cfg Config) *Factory {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: DefaultTokenTimeout}
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &Factory{
+		httpClient: cfg.HTTPClient,
+		now:        cfg.Now,
+		tokens:     make(map[string]*tokenSource),
+		users:      make(map[string]map[string]bool),
+	}
+}
+
+func (f *Factory) Signer(providerID string, auth dto.AuthConfig, credential string) (service.Signer, error) {
+	switch provider.AuthType(auth.Type) {
+	case provider.AuthTypeBearer, provider.AuthTypeAPIKey:
+		return headerSigner{header: auth.Header, value: withPrefix(auth.Prefix, credential)}, nil
+
+	case provider.AuthTypeQuery:
+		return querySigner{param: auth.QueryParam, value: credential}, nil
+
+	case provider.AuthTypeOAuth2:
+		return &oauth2Signer{
+			header: auth.Header,
+			prefix: auth.Prefix,
+			tokens: f.tokenSource(providerID, auth.OAuth2, credential),
+		}, nil
+
+	case provider.AuthTypeSigV4:
+		return &sigV4Signer{
+			accessKeyID:     auth.SigV4.AccessKeyID,
+			secretAccessKey: credential,
+			region:          auth.SigV4.Region,
+			service:         auth.SigV4.Service,
+			now:             f.now,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported auth type %q", auth.Type)
+	}
+}
+
+// Forget drops the tokens a provider signs with, unless another provider
+// shares the same client.
+func (f *Factory) Forget(providerID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := f.users[providerID]
+	delete(f.users, providerID)
+	for key := range keys {
+		if !f.inUse(key) {
+			delete(f.tokens, key)
+		}
+	}
+}
+
+func (f *Factory) inUse(key string) bool {
+	for _, keys := range f.users {
+		if keys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenSource returns the shared token source for an OAuth2 client. The
+// secret is part of the key, so a rotated secret fetches a new token.
+func (f *Factory) tokenSource(providerID string, cfg dto.OAuth2Config, secret string) *tokenSource {
+	sum := sha256.Sum256([]byte(secret))
+	key := strings.Join([]string{cfg.TokenURL, cfg.ClientID, strings.Join(cfg.Scopes, " "), hex.EncodeToString(sum[:])}, "\x00")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source, ok := f.tokens[key]
+	if !ok {
+		source = &tokenSource{
+			httpClient:   f.httpClient,
+			now:          f.now,
+			tokenURL:     cfg.TokenURL,
+			clientID:     cfg.ClientID,
+			clientSecret: secret,
+			scopes:       cfg.Scopes,
+		}
+		f.tokens[key] = source
+	}
+
+	if f.users[providerID] == nil {
+		f.users[providerID] = make(map[string]bool)
+	}
+	f.users[providerID][key] = true
+	return source
+}
+
+func withPrefix(prefix, value string) string {
+	if prefix == "" {
+		return value
+	}
+	return prefix + " " + value
+}
+
+// headerSigner sends the credential in a header, optionally prefixed.
+type headerSigner struct {
+	header string
+	value  string
+}
+
+func (s headerSigner) Sign(_ context.Context, request *service.ProxyRequest) error {
+	setHeader(request, s.header, s.value)
+	return nil
+}
+
+// querySigner sends the credential as a query parameter.
+type querySigner struct {
+	param string
+	value string
+}
+
+func (s querySigner) Sign(_ context.Context, request *service.ProxyRequest) error {
+	target, err := url.Parse(request.Target)
+	if err != nil {
+		return fmt.Errorf("invalid target: %w", err)
+	}
+
+	query := target.Query()
+	query.Set(s.param, s.value)
+	target.RawQuery = query.Encode()
+	request.Target = target.String()
+	return nil
+}
+
+// oauth2Signer sends an access token from the client credentials grant.
+type oauth2Signer struct {
+	header string
+	prefix string
+	tokens *tokenSource
+
+	mu     sync.Mutex
+	signed string
+}
+
+var _ service.RefreshableSigner = (*oauth2Signer)(nil)
+
+func (s *oauth2Signer) Sign(ctx context.Context, request *service.ProxyRequest) error {
+	token, err := s.tokens.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.signed = token
+	s.mu.Unlock()
+
+	setHeader(request, s.header, withPrefix(s.prefix, token))
+	return nil
+}
+
+// Invalidate drops the token last signed with, so the next request fetches
+// a new one.
+func (s *oauth2Signer) Invalidate() {
+	s.mu.Lock()
+	token := s.signed
+	s.mu.Unlock()
+
+	s.tokens.invalidate(token)
+}
+
+func setHeader(request *service.ProxyRequest, name, value string) {
+	if request.Headers == nil {
+		request.Headers = make(map[string]string)
+	}
+	request.Headers[name] = value
+}
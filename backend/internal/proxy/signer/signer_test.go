@@ -0,0 +1,300 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/dto"
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+)
+
+func TestStaticSigners(t *testing.T) {
+	tests := []struct {
+		name       string
+		auth       dto.AuthConfig
+		wantTarget string
+		wantHeader string
+		wantValue  string
+	}{
+		{"Bearer", dto.AuthConfig{Type: "bearer", Header: "Authorization", Prefix: "Bearer"}, "https://api.example.com/v1/chat?alt=sse", "Authorization", "Bearer secret"},
+		{"API key", dto.AuthConfig{Type: "apikey", Header: "x-api-key"}, "https://api.example.com/v1/chat?alt=sse", "x-api-key", "secret"},
+		{"Query parameter", dto.AuthConfig{Type: "query", QueryParam: "key"}, "https://api.example.com/v1/chat?alt=sse&key=secret", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewFactory(Config{}).Signer("p", tt.auth, "secret")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			request := service.ProxyRequest{Target: "https://api.example.com/v1/chat?alt=sse", Method: "POST"}
+			if err := signer.Sign(context.Background(), &request); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if request.Target != tt.wantTarget {
+				t.Errorf("Expected target %s, got %s", tt.wantTarget, request.Target)
+			}
+			if tt.wantHeader != "" && request.Headers[tt.wantHeader] != tt.wantValue {
+				t.Errorf("Expected %s header %q, got %q", tt.wantHeader, tt.wantValue, request.Headers[tt.wantHeader])
+			}
+		})
+	}
+
+	if _, err := NewFactory(Config{}).Signer("p", dto.AuthConfig{Type: "basic"}, "secret"); err == nil {
+		t.Error("Expected error for unknown auth type")
+	}
+}
+
+// tokenServer is a stand-in OAuth2 token endpoint for the client
+// credentials grant.
+func tokenServer(t *testing.T, fetches *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error":"invalid_client"}`)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "chat models.read" {
+			t.Errorf("Expected a client credentials grant with scopes, got %v", r.PostForm)
+		}
+
+		n := fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+}
+
+func TestOAuth2Signer(t *testing.T) {
+	var fetches atomic.Int32
+	server := tokenServer(t, &fetches)
+	defer server.Close()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	factory := NewFactory(Config{HTTPClient: server.Client(), Now: func() time.Time { return now }})
+	auth := dto.AuthConfig{
+		Type:   "oauth2_client_credentials",
+		Header: "Authorization",
+		Prefix: "Bearer",
+		OAuth2: dto.OAuth2Config{TokenURL: server.URL, ClientID: "client", Scopes: []string{"chat", "models.read"}},
+	}
+
+	sign := func(credential string) (string, error) {
+		signer, err := factory.Signer("p", auth, credential)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		request := service.ProxyRequest{Target: "https://gateway.example.com/v1/chat", Method: "POST"}
+		err = signer.Sign(context.Background(), &request)
+		return request.Headers["Authorization"], err
+	}
+
+	for range 3 {
+		header, err := sign("s3cret")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if header != "Bearer token-1" {
+			t.Errorf("Expected the cached token, got %q", header)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("Expected 1 token fetch, got %d", fetches.Load())
+	}
+
+	// The token is refreshed ahead of its expiry.
+	now = now.Add(time.Hour - tokenExpiryMargin)
+	if header, err := sign("s3cret"); err != nil || header != "Bearer token-2" {
+		t.Errorf("Expected a refreshed token, got %q (%v)", header, err)
+	}
+
+	if _, err := sign("wrong"); err == nil {
+		t.Error("Expected error when the token endpoint rejects the client")
+	}
+}
+
+func TestOAuth2SignerInvalidate(t *testing.T) {
+	var fetches atomic.Int32
+	server := tokenServer(t, &fetches)
+	defer server.Close()
+
+	factory := NewFactory(Config{HTTPClient: server.Client()})
+	auth := dto.AuthConfig{
+		Type:   "oauth2_client_credentials",
+		Header: "Authorization",
+		Prefix: "Bearer",
+		OAuth2: dto.OAuth2Config{TokenURL: server.URL, ClientID: "client", Scopes: []string{"chat", "models.read"}},
+	}
+
+	signer := func(providerID string) service.Signer {
+		signer, err := factory.Signer(providerID, auth, "s3cret")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return signer
+	}
+	sign := func(signer service.Signer) string {
+		request := service.ProxyRequest{Target: "https://gateway.example.com/v1/chat", Method: "POST"}
+		if err := signer.Sign(context.Background(), &request); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return request.Headers["Authorization"]
+	}
+
+	first, second := signer("p"), signer("p")
+	sign(first)
+	sign(second)
+
+	// A rejected token is refetched once, however many requests saw it
+	// rejected.
+	first.(service.RefreshableSigner).Invalidate()
+	if header := sign(first); header != "Bearer token-2" {
+		t.Errorf("Expected a refetched token, got %q", header)
+	}
+	second.(service.RefreshableSigner).Invalidate()
+	if header := sign(second); header != "Bearer token-2" {
+		t.Errorf("Expected the refetched token to be kept, got %q", header)
+	}
+
+	// A provider sharing the client keeps the token while another provider
+	// is forgotten.
+	shared := signer("q")
+	factory.Forget("p")
+	if header := sign(shared); header != "Bearer token-2" {
+		t.Errorf("Expected the shared token to be kept, got %q", header)
+	}
+
+	factory.Forget("q")
+	if header := sign(signer("p")); header != "Bearer token-3" {
+		t.Errorf("Expected a forgotten provider to fetch a new token, got %q", header)
+	}
+	if fetches.Load() != 3 {
+		t.Errorf("Expected 3 token fetches, got %d", fetches.Load())
+	}
+}
+
+func TestSigV4Signer(t *testing.T) {
+	// The example request from the AWS Signature Version 4 documentation.
+	factory := NewFactory(Config{Now: func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }})
+	signer, err := factory.Signer("p", dto.AuthConfig{
+		Type:  "aws_sigv4",
+		SigV4: dto.SigV4Config{AccessKeyID: "AKIDEXAMPLE", Region: "us-east-1", Service: "iam"},
+	}, "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	request := service.ProxyRequest{
+		Target:  "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+		Method:  "GET",
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"},
+	}
+	if err := signer.Sign(context.Background(), &request); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if request.Headers["Authorization"] != want {
+		t.Errorf("Expected %s, got %s", want, request.Headers["Authorization"])
+	}
+	if request.Headers["X-Amz-Date"] != "20150830T123600Z" {
+		t.Errorf("Expected X-Amz-Date 20150830T123600Z, got %s", request.Headers["X-Amz-Date"])
+	}
+}
+
+// TestSigV4OverTheWire sends a signed request to a stand-in verifier that
+// re-signs what it received, so the path and headers must arrive exactly as
+// they were signed.
+func TestSigV4OverTheWire(t *testing.T) {
+	now := func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+	auth := dto.AuthConfig{
+		Type:  "aws_sigv4",
+		SigV4: dto.SigV4Config{AccessKeyID: "AKID", Region: "us-east-1", Service: "bedrock"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		_, signedHeaders, ok := strings.Cut(authorization, "SignedHeaders=")
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		signedHeaders, _, _ = strings.Cut(signedHeaders, ",")
+
+		body, _ := io.ReadAll(r.Body)
+		received := service.ProxyRequest{
+			Target:  "http://" + r.Host + r.URL.RequestURI(),
+			Method:  r.Method,
+			Headers: map[string]string{},
+			Body:    body,
+		}
+		for _, name := range strings.Split(signedHeaders, ";") {
+			if name != "host" {
+				received.Headers[name] = r.Header.Get(name)
+			}
+		}
+
+		verifier, _ := NewFactory(Config{Now: now}).Signer("p", auth, "secret")
+		if err := verifier.Sign(context.Background(), &received); err != nil || received.Headers["Authorization"] != authorization {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		secret string
+		want   int
+	}{
+		{"Valid signature", "secret", http.StatusOK},
+		{"Wrong secret", "other", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewFactory(Config{Now: now}).Signer("p", auth, tt.secret)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			request := service.ProxyRequest{
+				Target:  server.URL + "/model/anthropic.claude-v2:1/invoke",
+				Method:  "POST",
+				Headers: map[string]string{"Content-Type": "application/json", "Accept": "application/json"},
+				Body:    []byte(`{"prompt":"hi"}`),
+			}
+			if err := signer.Sign(context.Background(), &request); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			req, _ := http.NewRequest(request.Method, request.Target, bytes.NewReader(request.Body))
+			for k, v := range request.Headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := server.Client().Do(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package signer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4DateFormat = "20060102T150405Z"
+)
+
+// sigV4Signer signs requests with AWS Signature Version 4. Every header on
+// the request is signed, along with the host and the body.
+type sigV4Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+	service         string
+	now             func() time.Time
+}
+
+func (s *sigV4Signer) Sign(_ context.Context, request *service.ProxyRequest) error {
+	target, err := url.Parse(request.Target)
+	if err != nil {
+		return fmt.Errorf("invalid target: %w", err)
+	}
+
+	// Send the path escaped the way AWS escapes it, so model IDs such as
+	// "anthropic.claude-v2:1" reach the service as they were signed.
+	escapedPath := awsEscapePath(target.Path)
+	target.RawPath = escapedPath
+	request.Target = target.String()
+
+	now := s.now().UTC()
+	amzDate := now.Format(sigV4DateFormat)
+	setHeader(request, "X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": target.Host}
+	for name, value := range request.Headers {
+		name = strings.ToLower(name)
+		if name == "authorization" {
+			continue
+		}
+		headers[name] = strings.Join(strings.Fields(value), " ")
+	}
+
+	names := slices.Sorted(maps.Keys(headers))
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	payloadHash := sha256.Sum256(request.Body)
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		// Services other than S3 expect the path escaped a second time.
+		awsEscapePath(escapedPath),
+		canonicalQuery(target.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	date := now.Format("20060102")
+	scope := strings.Join([]string{date, s.region, s.service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	setHeader(request, "Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsEscapePath escapes each segment of a path, keeping the slashes.
+func awsEscapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsEscape percent-encodes everything but the RFC 3986 unreserved
+// characters, as SigV4 requires.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package model
+
+import "gorm.io/gorm"
+
+// microcreditsPerCredit matches the billing context's unit.
+const microcreditsPerCredit = 1_000_000
+
+// MigrateMicrocreditCosts converts the whole-credit costs of older usage
+// records and rollups to micro-credit columns. It runs after AutoMigrate has
+// added the new columns and only touches rows whose new column is still
+// NULL, so it is safe to run on every start.
+func MigrateMicrocreditCosts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&UsageRecordModel{}, &UsageRollupModel{}} {
+			if !tx.Migrator().HasColumn(model, "cost") {
+				continue
+			}
+
+			if err := tx.Model(model).
+				Where("cost_microcredits IS NULL").
+				Update("cost_microcredits", gorm.Expr("COALESCE(cost, 0) * ?", microcreditsPerCredit)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
@@ -25,7 +27,7 @@ func (h *HeadersJSON) Scan(value interface{}) error {
 		*h = make(HeadersJSON)
 		return nil
 	}
-	
+
 	switch v := value.(type) {
 	case []byte:
 		return json.Unmarshal(v, h)
@@ -36,25 +38,103 @@ func (h *HeadersJSON) Scan(value interface{}) error {
 	}
 }
 
+// PriceRuleJSON is the persisted form of a model price rule
+type PriceRuleJSON struct {
+	Dimension         string `json:"dimension"`
+	Price             string `json:"price"`
+	AbovePromptTokens int    `json:"above_prompt_tokens,omitempty"`
+}
+
+// PriceRulesJSON handles JSON serialization for model price rules. Rows
+// written before price rules existed hold NULL.
+type PriceRulesJSON []PriceRuleJSON
+
+func (r PriceRulesJSON) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *PriceRulesJSON) Scan(value interface{}) error {
+	if value == nil {
+		*r = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return nil
+	}
+}
+
+// EndpointRouteJSON handles JSON serialization for an endpoint's streaming
+// and probe routes. Endpoints without one hold NULL.
+type EndpointRouteJSON struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query,omitempty"`
+}
+
+func (r *EndpointRouteJSON) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *EndpointRouteJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return nil
+	}
+}
+
 // ProviderModel represents the GORM model for providers
 type ProviderModel struct {
-	ID               string      `gorm:"primaryKey;column:id"`
-	Name             string      `gorm:"column:name;uniqueIndex"`
-	BaseURL          string      `gorm:"column:base_url"`
-	AuthType         string      `gorm:"column:auth_type"`
-	AuthHeader       string      `gorm:"column:auth_header"`
-	AuthPrefix       string      `gorm:"column:auth_prefix"`
-	AuthCredential   string      `gorm:"column:auth_credential"`
-	Headers          HeadersJSON `gorm:"column:headers;type:json"`
-	Status           string      `gorm:"column:status"`
-	RequestTemplate  string      `gorm:"column:request_template;type:text"`
-	ResponseTemplate string      `gorm:"column:response_template;type:text"`
-	UpdatedAt        time.Time   `gorm:"column:updated_at"`
-	CreatedAt        time.Time   `gorm:"column:created_at"`
+	ID                     string      `gorm:"primaryKey;column:id"`
+	Name                   string      `gorm:"column:name;uniqueIndex"`
+	BaseURL                string      `gorm:"column:base_url"`
+	AuthType               string      `gorm:"column:auth_type"`
+	AuthHeader             string      `gorm:"column:auth_header"`
+	AuthPrefix             string      `gorm:"column:auth_prefix"`
+	AuthQueryParam         string      `gorm:"column:auth_query_param"`
+	AuthTokenURL           string      `gorm:"column:auth_token_url"`
+	AuthClientID           string      `gorm:"column:auth_client_id"`
+	AuthScopes             string      `gorm:"column:auth_scopes"` // space-separated, as OAuth2 sends them
+	AuthAccessKeyID        string      `gorm:"column:auth_access_key_id"`
+	AuthRegion             string      `gorm:"column:auth_signing_region"`
+	AuthService            string      `gorm:"column:auth_signing_service"`
+	AuthCredential         string      `gorm:"column:auth_credential"`
+	AuthDataKey            string      `gorm:"column:auth_data_key"`
+	AuthKeyID              string      `gorm:"column:auth_key_id"`
+	Headers                HeadersJSON `gorm:"column:headers;type:json"`
+	Status                 string      `gorm:"column:status"`
+	RequestTemplate        string      `gorm:"column:request_template;type:text"`
+	ResponseTemplate       string      `gorm:"column:response_template;type:text"`
+	TemplateVersion        int         `gorm:"column:template_version"`
+	StagedTemplateVersion  int         `gorm:"column:staged_template_version"`
+	StagedRequestTemplate  string      `gorm:"column:staged_request_template;type:text"`
+	StagedResponseTemplate string      `gorm:"column:staged_response_template;type:text"`
+	StagedTemplatePercent  int         `gorm:"column:staged_template_percent"`
+	HealthProbeModel       string      `gorm:"column:health_probe_model"`
+	HealthProbePrompt      string      `gorm:"column:health_probe_prompt"`
+	KeyStrategy            string      `gorm:"column:key_strategy"`
+	UpdatedAt              time.Time   `gorm:"column:updated_at"`
+	CreatedAt              time.Time   `gorm:"column:created_at"`
 
 	// Relations
 	Models    []ModelModel    `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE"`
 	Endpoints []EndpointModel `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE"`
+	Keys      []KeyModel      `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE"`
 }
 
 func (m *ProviderModel) TableName() string {
@@ -63,19 +143,22 @@ func (m *ProviderModel) TableName() string {
 
 // ModelModel represents the GORM model for provider models
 type ModelModel struct {
-	ID                   string  `gorm:"primaryKey;column:id"`
-	ProviderID           string  `gorm:"column:provider_id;index"`
-	Name                 string  `gorm:"column:name"`
-	Key                  string  `gorm:"column:key"`
-	Description          string  `gorm:"column:description"`
-	FunctionCalling      bool    `gorm:"column:function_calling"`
-	Streaming            bool    `gorm:"column:streaming"`
-	ContextWindow        int     `gorm:"column:context_window"`
-	MaxOutputTokens      int     `gorm:"column:max_output_tokens"`
-	PromptTokenPrice     float64 `gorm:"column:prompt_token_price"`
-	CompletionTokenPrice float64 `gorm:"column:completion_token_price"`
-	Currency             string  `gorm:"column:currency"`
-	PricingUnit          string  `gorm:"column:pricing_unit"`
+	ID                   string         `gorm:"primaryKey;column:id"`
+	ProviderID           string         `gorm:"column:provider_id;index"`
+	Name                 string         `gorm:"column:name"`
+	Key                  string         `gorm:"column:key"`
+	Description          string         `gorm:"column:description"`
+	FunctionCalling      bool           `gorm:"column:function_calling"`
+	Streaming            bool           `gorm:"column:streaming"`
+	StructuredOutput     bool           `gorm:"column:structured_output"`
+	ContextWindow        int            `gorm:"column:context_window"`
+	MaxOutputTokens      int            `gorm:"column:max_output_tokens"`
+	MaxStopSequences     int            `gorm:"column:max_stop_sequences"`
+	PromptTokenPrice     float64        `gorm:"column:prompt_token_price"`
+	CompletionTokenPrice float64        `gorm:"column:completion_token_price"`
+	Currency             string         `gorm:"column:currency"`
+	PricingUnit          string         `gorm:"column:pricing_unit"`
+	PricingRules         PriceRulesJSON `gorm:"column:pricing_rules;type:json"`
 }
 
 func (m *ModelModel) TableName() string {
@@ -84,17 +167,39 @@ func (m *ModelModel) TableName() string {
 
 // EndpointModel represents the GORM model for provider endpoints
 type EndpointModel struct {
+	ID              string             `gorm:"primaryKey;column:id"`
+	ProviderID      string             `gorm:"column:provider_id;index"`
+	Name            string             `gorm:"column:name"`
+	Method          string             `gorm:"column:method;default:POST"`
+	Path            string             `gorm:"column:path"`
+	Query           HeadersJSON        `gorm:"column:query;type:json"`
+	StreamRoute     *EndpointRouteJSON `gorm:"column:stream_route;type:json"`
+	ProbeRoute      *EndpointRouteJSON `gorm:"column:probe_route;type:json"`
+	Status          string             `gorm:"column:status"`
+	Health          string             `gorm:"column:health"`
+	LastHealthCheck time.Time          `gorm:"column:last_health_check"`
+}
+
+func (m *EndpointModel) TableName() string {
+	return "provider_endpoints"
+}
+
+// KeyModel represents the GORM model for the keys in a provider's pool
+type KeyModel struct {
 	ID              string    `gorm:"primaryKey;column:id"`
 	ProviderID      string    `gorm:"column:provider_id;index"`
 	Name            string    `gorm:"column:name"`
-	Path            string    `gorm:"column:path"`
+	Credential      string    `gorm:"column:credential"`
+	DataKey         string    `gorm:"column:data_key"`
+	KeyID           string    `gorm:"column:key_id"`
+	Weight          int       `gorm:"column:weight"`
 	Status          string    `gorm:"column:status"`
-	Health          string    `gorm:"column:health"`
-	LastHealthCheck time.Time `gorm:"column:last_health_check"`
+	CreatedAt       time.Time `gorm:"column:created_at"`
+	StatusChangedAt time.Time `gorm:"column:status_changed_at"`
 }
 
-func (m *EndpointModel) TableName() string {
-	return "provider_endpoints"
+func (m *KeyModel) TableName() string {
+	return "provider_keys"
 }
 
 // MapToDomain converts the GORM model to domain entity
@@ -115,17 +220,47 @@ func (m *ProviderModel) MapToDomain() (*provider.Provider, error) {
 		domainEndpoints[i] = endpointModel.MapToDomain()
 	}
 
+	// Convert keys
+	domainKeys := make([]provider.Key, len(m.Keys))
+	for i, keyModel := range m.Keys {
+		domainKeys[i] = keyModel.MapToDomain()
+	}
+
+	// The staged template columns are zero when no version is staged
+	var staged *provider.StagedTemplate
+	if m.StagedTemplateVersion > 0 {
+		staged = &provider.StagedTemplate{
+			Version:          m.StagedTemplateVersion,
+			RequestTemplate:  provider.Template{Content: m.StagedRequestTemplate},
+			ResponseTemplate: provider.Template{Content: m.StagedResponseTemplate},
+			Percent:          m.StagedTemplatePercent,
+		}
+	}
+
 	// Hydrate provider from persistence
 	return provider.Hydrate(provider.HydrateData{
 		ID:      provider.HydrateID(m.ID),
 		Name:    m.Name,
 		BaseURL: m.BaseURL,
 		Auth: provider.AuthConfig{
-			Type:   provider.AuthType(m.AuthType),
-			Header: m.AuthHeader,
-			Prefix: m.AuthPrefix,
+			Type:       provider.AuthType(m.AuthType),
+			Header:     m.AuthHeader,
+			Prefix:     m.AuthPrefix,
+			QueryParam: m.AuthQueryParam,
+			OAuth2: provider.OAuth2Config{
+				TokenURL: m.AuthTokenURL,
+				ClientID: m.AuthClientID,
+				Scopes:   strings.Fields(m.AuthScopes),
+			},
+			SigV4: provider.SigV4Config{
+				AccessKeyID: m.AuthAccessKeyID,
+				Region:      m.AuthRegion,
+				Service:     m.AuthService,
+			},
 			Credential: provider.Credential{
 				Encrypted: m.AuthCredential,
+				DataKey:   m.AuthDataKey,
+				KeyID:     m.AuthKeyID,
 			},
 		},
 		Headers: map[string]string(m.Headers),
@@ -136,63 +271,151 @@ func (m *ProviderModel) MapToDomain() (*provider.Provider, error) {
 		ResponseTemplate: provider.Template{
 			Content: m.ResponseTemplate,
 		},
-		Models:    domainModels,
-		Endpoints: domainEndpoints,
-		UpdatedAt: m.UpdatedAt,
+		TemplateVersion: m.TemplateVersion,
+		StagedTemplate:  staged,
+		Models:          domainModels,
+		Endpoints:       domainEndpoints,
+		HealthProbe: provider.HealthProbe{
+			ModelKey: m.HealthProbeModel,
+			Prompt:   m.HealthProbePrompt,
+		},
+		Keys:        domainKeys,
+		KeyStrategy: provider.KeyStrategy(m.KeyStrategy),
+		UpdatedAt:   m.UpdatedAt,
 	}), nil
 }
 
 // MapToDomain converts the model GORM model to domain entity
 func (m *ModelModel) MapToDomain() (*model.Model, error) {
+	pricing, err := m.pricing()
+	if err != nil {
+		return nil, err
+	}
+
 	return model.Hydrate(model.HydrateData{
 		ID:          model.HydrateID(m.ID),
 		Name:        m.Name,
 		Key:         m.Key,
 		Description: m.Description,
 		Capabilities: model.Capabilities{
-			FunctionCalling: m.FunctionCalling,
-			Streaming:       m.Streaming,
+			FunctionCalling:  m.FunctionCalling,
+			Streaming:        m.Streaming,
+			StructuredOutput: m.StructuredOutput,
 		},
 		Limits: model.Limits{
-			ContextWindow:   m.ContextWindow,
-			MaxOutputTokens: m.MaxOutputTokens,
-		},
-		Pricing: model.TokenPricing{
-			Unit:                 model.PricingUnit(m.PricingUnit),
-			PromptTokenPrice:     m.PromptTokenPrice,
-			CompletionTokenPrice: m.CompletionTokenPrice,
-			Currency:             m.Currency,
+			ContextWindow:    m.ContextWindow,
+			MaxOutputTokens:  m.MaxOutputTokens,
+			MaxStopSequences: m.MaxStopSequences,
 		},
+		Pricing: pricing,
 	}), nil
 }
 
+// pricing reads the price rules, or for rows without any the legacy prompt
+// and completion price columns.
+func (m *ModelModel) pricing() (model.Pricing, error) {
+	pricing := model.Pricing{
+		Unit:     model.PricingUnit(m.PricingUnit),
+		Currency: m.Currency,
+	}
+
+	if m.PricingRules == nil {
+		pricing.Rules = []model.PriceRule{
+			{Dimension: model.DimensionPromptTokens, Price: legacyPrice(m.PromptTokenPrice)},
+			{Dimension: model.DimensionCompletionTokens, Price: legacyPrice(m.CompletionTokenPrice)},
+		}
+		return pricing, nil
+	}
+
+	pricing.Rules = make([]model.PriceRule, len(m.PricingRules))
+	for i, r := range m.PricingRules {
+		price, err := model.ParseDecimal(r.Price)
+		if err != nil {
+			return model.Pricing{}, err
+		}
+		pricing.Rules[i] = model.PriceRule{
+			Dimension:         model.Dimension(r.Dimension),
+			Price:             price,
+			AbovePromptTokens: r.AbovePromptTokens,
+		}
+	}
+	return pricing, nil
+}
+
+// legacyPrice reads a float price column by its shortest decimal form, so
+// 0.003 is 0.003 rather than its binary approximation.
+func legacyPrice(f float64) model.Decimal {
+	return model.MustParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
 // MapToDomain converts the endpoint GORM model to domain entity
 func (m *EndpointModel) MapToDomain() provider.Endpoint {
 	return provider.Endpoint{
 		Name:            m.Name,
+		Method:          m.Method,
 		Path:            m.Path,
+		Query:           m.Query,
+		Stream:          m.StreamRoute.mapToDomain(),
+		Probe:           m.ProbeRoute.mapToDomain(),
 		Status:          provider.EndpointStatus(m.Status),
 		Health:          provider.EndpointHealth(m.Health),
 		LastHealthCheck: m.LastHealthCheck,
 	}
 }
 
+// MapToDomain converts the key GORM model to domain entity
+func (m *KeyModel) MapToDomain() provider.Key {
+	return provider.Key{
+		ID:   provider.HydrateKeyID(m.ID),
+		Name: m.Name,
+		Credential: provider.Credential{
+			Encrypted: m.Credential,
+			DataKey:   m.DataKey,
+			KeyID:     m.KeyID,
+		},
+		Weight:          m.Weight,
+		Status:          provider.KeyStatus(m.Status),
+		CreatedAt:       m.CreatedAt,
+		StatusChangedAt: m.StatusChangedAt,
+	}
+}
+
 // MapDomainToModel converts domain provider to GORM model
 func MapDomainToModel(p *provider.Provider) *ProviderModel {
 	model := &ProviderModel{
-		ID:               p.ID().String(),
-		Name:             p.Name(),
-		BaseURL:          p.BaseURL(),
-		AuthType:         string(p.Auth().Type),
-		AuthHeader:       p.Auth().Header,
-		AuthPrefix:       p.Auth().Prefix,
-		AuthCredential:   p.Auth().Credential.Encrypted,
-		Headers:          HeadersJSON(p.Headers()),
-		Status:           string(p.Status()),
-		RequestTemplate:  p.RequestTemplate().Content,
-		ResponseTemplate: p.ResponseTemplate().Content,
-		UpdatedAt:        p.UpdatedAt(),
-		CreatedAt:        time.Now(), // This will be set by GORM hooks if needed
+		ID:                p.ID().String(),
+		Name:              p.Name(),
+		BaseURL:           p.BaseURL(),
+		AuthType:          string(p.Auth().Type),
+		AuthHeader:        p.Auth().Header,
+		AuthPrefix:        p.Auth().Prefix,
+		AuthQueryParam:    p.Auth().QueryParam,
+		AuthTokenURL:      p.Auth().OAuth2.TokenURL,
+		AuthClientID:      p.Auth().OAuth2.ClientID,
+		AuthScopes:        strings.Join(p.Auth().OAuth2.Scopes, " "),
+		AuthAccessKeyID:   p.Auth().SigV4.AccessKeyID,
+		AuthRegion:        p.Auth().SigV4.Region,
+		AuthService:       p.Auth().SigV4.Service,
+		AuthCredential:    p.Auth().Credential.Encrypted,
+		AuthDataKey:       p.Auth().Credential.DataKey,
+		AuthKeyID:         p.Auth().Credential.KeyID,
+		Headers:           HeadersJSON(p.Headers()),
+		Status:            string(p.Status()),
+		RequestTemplate:   p.RequestTemplate().Content,
+		ResponseTemplate:  p.ResponseTemplate().Content,
+		TemplateVersion:   p.TemplateVersion(),
+		HealthProbeModel:  p.HealthProbe().ModelKey,
+		HealthProbePrompt: p.HealthProbe().Prompt,
+		KeyStrategy:       string(p.KeyStrategy()),
+		UpdatedAt:         p.UpdatedAt(),
+		CreatedAt:         time.Now(), // This will be set by GORM hooks if needed
+	}
+
+	if staged, ok := p.StagedTemplate(); ok {
+		model.StagedTemplateVersion = staged.Version
+		model.StagedRequestTemplate = staged.RequestTemplate.Content
+		model.StagedResponseTemplate = staged.ResponseTemplate.Content
+		model.StagedTemplatePercent = staged.Percent
 	}
 
 	// Convert models
@@ -207,26 +430,53 @@ func MapDomainToModel(p *provider.Provider) *ProviderModel {
 		model.Endpoints[i] = MapDomainEndpointToModel(p.ID().String(), endpoint)
 	}
 
+	// Convert keys
+	model.Keys = make([]KeyModel, len(p.Keys()))
+	for i, key := range p.Keys() {
+		model.Keys[i] = MapDomainKeyToModel(p.ID().String(), key)
+	}
+
 	return model
 }
 
 // MapDomainModelToModel converts domain model to GORM model
 func MapDomainModelToModel(providerID string, m *model.Model) ModelModel {
-	return ModelModel{
-		ID:                   m.ID().String(),
-		ProviderID:           providerID,
-		Name:                 m.Name(),
-		Key:                  m.Key(),
-		Description:          m.Description(),
-		FunctionCalling:      m.Capabilities().FunctionCalling,
-		Streaming:            m.Capabilities().Streaming,
-		ContextWindow:        m.Limits().ContextWindow,
-		MaxOutputTokens:      m.Limits().MaxOutputTokens,
-		PromptTokenPrice:     m.Pricing().PromptTokenPrice,
-		CompletionTokenPrice: m.Pricing().CompletionTokenPrice,
-		Currency:             m.Pricing().Currency,
-		PricingUnit:          string(m.Pricing().Unit),
+	pricing := m.Pricing()
+	rules := make(PriceRulesJSON, len(pricing.Rules))
+	for i, r := range pricing.Rules {
+		rules[i] = PriceRuleJSON{
+			Dimension:         r.Dimension.String(),
+			Price:             r.Price.String(),
+			AbovePromptTokens: r.AbovePromptTokens,
+		}
+	}
+
+	mm := ModelModel{
+		ID:               m.ID().String(),
+		ProviderID:       providerID,
+		Name:             m.Name(),
+		Key:              m.Key(),
+		Description:      m.Description(),
+		FunctionCalling:  m.Capabilities().FunctionCalling,
+		Streaming:        m.Capabilities().Streaming,
+		StructuredOutput: m.Capabilities().StructuredOutput,
+		ContextWindow:    m.Limits().ContextWindow,
+		MaxOutputTokens:  m.Limits().MaxOutputTokens,
+		MaxStopSequences: m.Limits().MaxStopSequences,
+		Currency:         pricing.Currency,
+		PricingUnit:      string(pricing.Unit),
+		PricingRules:     rules,
+	}
+
+	// The legacy columns keep the base prices for older readers.
+	if price, ok := pricing.Price(model.DimensionPromptTokens, 0); ok {
+		mm.PromptTokenPrice, _ = strconv.ParseFloat(price.String(), 64)
 	}
+	if price, ok := pricing.Price(model.DimensionCompletionTokens, 0); ok {
+		mm.CompletionTokenPrice, _ = strconv.ParseFloat(price.String(), 64)
+	}
+
+	return mm
 }
 
 // MapDomainEndpointToModel converts domain endpoint to GORM model
@@ -235,9 +485,43 @@ func MapDomainEndpointToModel(providerID string, e provider.Endpoint) EndpointMo
 		ID:              uuid.New().String(), // Generate unique persistence ID (not domain-relevant)
 		ProviderID:      providerID,
 		Name:            e.Name,
+		Method:          e.Method,
 		Path:            e.Path,
+		Query:           e.Query,
+		StreamRoute:     mapDomainEndpointRoute(e.Stream),
+		ProbeRoute:      mapDomainEndpointRoute(e.Probe),
 		Status:          string(e.Status),
 		Health:          string(e.Health),
 		LastHealthCheck: e.LastHealthCheck,
 	}
 }
+
+func (r *EndpointRouteJSON) mapToDomain() *provider.EndpointRoute {
+	if r == nil {
+		return nil
+	}
+	return &provider.EndpointRoute{Method: r.Method, Path: r.Path, Query: r.Query}
+}
+
+func mapDomainEndpointRoute(r *provider.EndpointRoute) *EndpointRouteJSON {
+	if r == nil {
+		return nil
+	}
+	return &EndpointRouteJSON{Method: r.Method, Path: r.Path, Query: r.Query}
+}
+
+// MapDomainKeyToModel converts domain key to GORM model
+func MapDomainKeyToModel(providerID string, k provider.Key) KeyModel {
+	return KeyModel{
+		ID:              k.ID.String(),
+		ProviderID:      providerID,
+		Name:            k.Name,
+		Credential:      k.Credential.Encrypted,
+		DataKey:         k.Credential.DataKey,
+		KeyID:           k.Credential.KeyID,
+		Weight:          k.Weight,
+		Status:          string(k.Status),
+		CreatedAt:       k.CreatedAt,
+		StatusChangedAt: k.StatusChangedAt,
+	}
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/route"
+)
+
+// TargetJSON is the persisted form of a route target
+type TargetJSON struct {
+	ProviderID string `json:"provider_id"`
+	ModelKey   string `json:"model_key"`
+	Endpoint   string `json:"endpoint"`
+}
+
+// TargetsJSON handles JSON serialization for the ordered route targets
+type TargetsJSON []TargetJSON
+
+func (t TargetsJSON) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *TargetsJSON) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return nil
+	}
+}
+
+// RouteModel represents the GORM model for model routes
+type RouteModel struct {
+	ID        string      `gorm:"primaryKey;column:id"`
+	Model     string      `gorm:"column:model;uniqueIndex"`
+	Targets   TargetsJSON `gorm:"column:targets;type:json"`
+	UpdatedAt time.Time   `gorm:"column:updated_at"`
+	CreatedAt time.Time   `gorm:"column:created_at"`
+}
+
+func (m *RouteModel) TableName() string {
+	return "model_routes"
+}
+
+// MapToDomain converts the GORM model to domain entity
+func (m *RouteModel) MapToDomain() *route.Route {
+	targets := make([]route.Target, len(m.Targets))
+	for i, t := range m.Targets {
+		targets[i] = route.Target{
+			ProviderID: t.ProviderID,
+			ModelKey:   t.ModelKey,
+			Endpoint:   t.Endpoint,
+		}
+	}
+
+	return route.Hydrate(route.HydrateData{
+		ID:        route.HydrateID(m.ID),
+		Model:     m.Model,
+		Targets:   targets,
+		UpdatedAt: m.UpdatedAt,
+	})
+}
+
+// MapDomainRouteToModel converts domain route to GORM model
+func MapDomainRouteToModel(r *route.Route) *RouteModel {
+	targets := make(TargetsJSON, len(r.Targets()))
+	for i, t := range r.Targets() {
+		targets[i] = TargetJSON{
+			ProviderID: t.ProviderID,
+			ModelKey:   t.ModelKey,
+			Endpoint:   t.Endpoint,
+		}
+	}
+
+	return &RouteModel{
+		ID:        r.ID().String(),
+		Model:     r.Model(),
+		Targets:   targets,
+		UpdatedAt: r.UpdatedAt(),
+	}
+}
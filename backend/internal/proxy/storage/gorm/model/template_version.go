@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+)
+
+// TemplateVersionModel represents the GORM model for provider template versions
+type TemplateVersionModel struct {
+	ID               string    `gorm:"primaryKey;column:id"`
+	ProviderID       string    `gorm:"column:provider_id;uniqueIndex:idx_template_versions_provider_number"`
+	Number           int       `gorm:"column:number;uniqueIndex:idx_template_versions_provider_number"`
+	RequestTemplate  string    `gorm:"column:request_template;type:text"`
+	ResponseTemplate string    `gorm:"column:response_template;type:text"`
+	Author           string    `gorm:"column:author"`
+	CreatedAt        time.Time `gorm:"column:created_at"`
+}
+
+func (m *TemplateVersionModel) TableName() string {
+	return "provider_template_versions"
+}
+
+// MapToDomain converts the GORM model to domain entity
+func (m *TemplateVersionModel) MapToDomain() provider.TemplateVersion {
+	return provider.TemplateVersion{
+		ProviderID:       provider.HydrateID(m.ProviderID),
+		Number:           m.Number,
+		RequestTemplate:  provider.Template{Content: m.RequestTemplate},
+		ResponseTemplate: provider.Template{Content: m.ResponseTemplate},
+		Author:           m.Author,
+		CreatedAt:        m.CreatedAt,
+	}
+}
+
+// MapDomainTemplateVersionToModel converts domain template version to GORM model
+func MapDomainTemplateVersionToModel(v provider.TemplateVersion) *TemplateVersionModel {
+	return &TemplateVersionModel{
+		ID:               uuid.New().String(), // versions are addressed by provider and number
+		ProviderID:       v.ProviderID.String(),
+		Number:           v.Number,
+		RequestTemplate:  v.RequestTemplate.Content,
+		ResponseTemplate: v.ResponseTemplate.Content,
+		Author:           v.Author,
+		CreatedAt:        v.CreatedAt,
+	}
+}
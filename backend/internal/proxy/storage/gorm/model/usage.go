@@ -0,0 +1,140 @@
+package model
+
+import (
+	"time"
+
+	"github.com/basetable/basetable/backend/internal/proxy/domain/usage"
+)
+
+// UsageRecordModel represents the GORM model for usage records
+type UsageRecordModel struct {
+	ID               string    `gorm:"primaryKey;column:id"`
+	RequestID        string    `gorm:"column:request_id;index"`
+	AccountID        string    `gorm:"column:account_id;index:idx_usage_records_account_created"`
+	ProviderID       string    `gorm:"column:provider_id"`
+	ModelKey         string    `gorm:"column:model_key"`
+	Endpoint         string    `gorm:"column:endpoint"`
+	Stream           bool      `gorm:"column:stream"`
+	PromptTokens     int       `gorm:"column:prompt_tokens"`
+	CompletionTokens int       `gorm:"column:completion_tokens"`
+	Cost             int64     `gorm:"column:cost_microcredits"`
+	LatencyMs        int64     `gorm:"column:latency_ms"`
+	UpstreamStatus   int       `gorm:"column:upstream_status"`
+	FinishReason     string    `gorm:"column:finish_reason"`
+	ErrorCode        string    `gorm:"column:error_code"`
+	CreatedAt        time.Time `gorm:"column:created_at;index:idx_usage_records_account_created"`
+}
+
+func (m *UsageRecordModel) TableName() string {
+	return "usage_records"
+}
+
+// MapToDomain converts the GORM model to domain entity
+func (m *UsageRecordModel) MapToDomain() *usage.Record {
+	return usage.Hydrate(usage.HydrateData{
+		ID: usage.HydrateID(m.ID),
+		Attempt: usage.Attempt{
+			RequestID:        m.RequestID,
+			AccountID:        m.AccountID,
+			ProviderID:       m.ProviderID,
+			ModelKey:         m.ModelKey,
+			Endpoint:         m.Endpoint,
+			Stream:           m.Stream,
+			PromptTokens:     m.PromptTokens,
+			CompletionTokens: m.CompletionTokens,
+			Cost:             m.Cost,
+			Latency:          time.Duration(m.LatencyMs) * time.Millisecond,
+			UpstreamStatus:   m.UpstreamStatus,
+			FinishReason:     m.FinishReason,
+			ErrorCode:        usage.ErrorCode(m.ErrorCode),
+		},
+		CreatedAt: m.CreatedAt,
+	})
+}
+
+// MapDomainUsageRecordToModel converts domain usage record to GORM model
+func MapDomainUsageRecordToModel(r *usage.Record) *UsageRecordModel {
+	return &UsageRecordModel{
+		ID:               r.ID().String(),
+		RequestID:        r.RequestID(),
+		AccountID:        r.AccountID(),
+		ProviderID:       r.ProviderID(),
+		ModelKey:         r.ModelKey(),
+		Endpoint:         r.Endpoint(),
+		Stream:           r.Stream(),
+		PromptTokens:     r.PromptTokens(),
+		CompletionTokens: r.CompletionTokens(),
+		Cost:             r.Cost(),
+		LatencyMs:        r.Latency().Milliseconds(),
+		UpstreamStatus:   r.UpstreamStatus(),
+		FinishReason:     r.FinishReason(),
+		ErrorCode:        r.ErrorCode().String(),
+		CreatedAt:        r.CreatedAt(),
+	}
+}
+
+// UsageRollupModel holds one account's usage of one provider, model and
+// endpoint on one UTC day. Rollups are maintained as records are written so
+// aggregate queries never scan usage_records.
+type UsageRollupModel struct {
+	AccountID        string    `gorm:"primaryKey;column:account_id"`
+	Day              time.Time `gorm:"primaryKey;column:day;type:date;index"`
+	ProviderID       string    `gorm:"primaryKey;column:provider_id"`
+	ModelKey         string    `gorm:"primaryKey;column:model_key"`
+	Endpoint         string    `gorm:"primaryKey;column:endpoint"`
+	Requests         int64     `gorm:"column:requests"`
+	FailedRequests   int64     `gorm:"column:failed_requests"`
+	PromptTokens     int64     `gorm:"column:prompt_tokens"`
+	CompletionTokens int64     `gorm:"column:completion_tokens"`
+	Cost             int64     `gorm:"column:cost_microcredits"`
+}
+
+func (m *UsageRollupModel) TableName() string {
+	return "usage_daily_rollups"
+}
+
+// MapDomainUsageRecordToRollup converts a usage record to its contribution
+// to the daily rollup
+func MapDomainUsageRecordToRollup(r *usage.Record) *UsageRollupModel {
+	var failed int64
+	if !r.Succeeded() {
+		failed = 1
+	}
+
+	return &UsageRollupModel{
+		AccountID:        r.AccountID(),
+		Day:              usage.PeriodDay.Start(r.CreatedAt()),
+		ProviderID:       r.ProviderID(),
+		ModelKey:         r.ModelKey(),
+		Endpoint:         r.Endpoint(),
+		Requests:         1,
+		FailedRequests:   failed,
+		PromptTokens:     int64(r.PromptTokens()),
+		CompletionTokens: int64(r.CompletionTokens()),
+		Cost:             r.Cost(),
+	}
+}
+
+// UsageAggregateRow is the result row of an aggregate query over rollups
+type UsageAggregateRow struct {
+	Bucket           time.Time
+	Key              string
+	Requests         int64
+	FailedRequests   int64
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             int64
+}
+
+// MapToDomain converts the result row to domain aggregate
+func (m *UsageAggregateRow) MapToDomain() usage.Aggregate {
+	return usage.Aggregate{
+		BucketStart:      m.Bucket.UTC(),
+		Key:              m.Key,
+		Requests:         m.Requests,
+		FailedRequests:   m.FailedRequests,
+		PromptTokens:     m.PromptTokens,
+		CompletionTokens: m.CompletionTokens,
+		Cost:             m.Cost,
+	}
+}
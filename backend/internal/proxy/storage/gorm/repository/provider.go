@@ -37,6 +37,9 @@ func (r *ProviderRepository) Save(ctx context.Context, p *provider.Provider) err
 		if err := tx.Where("provider_id = ?", providerModel.ID).Delete(&model.EndpointModel{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("provider_id = ?", providerModel.ID).Delete(&model.KeyModel{}).Error; err != nil {
+			return err
+		}
 
 		// Create new related records
 		if len(providerModel.Models) > 0 {
@@ -49,17 +52,40 @@ func (r *ProviderRepository) Save(ctx context.Context, p *provider.Provider) err
 				return err
 			}
 		}
+		if len(providerModel.Keys) > 0 {
+			if err := tx.Create(&providerModel.Keys).Error; err != nil {
+				return err
+			}
+		}
 
 		return nil
 	})
 }
 
+func (r *ProviderRepository) SaveEndpointHealth(ctx context.Context, p *provider.Provider) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, ep := range p.Endpoints() {
+			err := tx.Model(&model.EndpointModel{}).
+				Where("provider_id = ? AND name = ?", p.ID().String(), ep.Name).
+				UpdateColumns(map[string]any{
+					"health":            string(ep.Health),
+					"last_health_check": ep.LastHealthCheck,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (r *ProviderRepository) GetByID(ctx context.Context, id string) (*provider.Provider, error) {
 	var providerModel model.ProviderModel
 
 	err := r.db.WithContext(ctx).
 		Preload("Models").
 		Preload("Endpoints").
+		Preload("Keys").
 		Where("id = ?", id).
 		First(&providerModel).Error
 
@@ -77,6 +103,7 @@ func (r *ProviderRepository) GetByIDForUpdate(ctx context.Context, id string) (*
 		Clauses(clause.Locking{Strength: "UPDATE"}).
 		Preload("Models").
 		Preload("Endpoints").
+		Preload("Keys").
 		Where("id = ?", id).
 		First(&providerModel).Error
 
@@ -93,6 +120,7 @@ func (r *ProviderRepository) GetAll(ctx context.Context) ([]*provider.Provider,
 	err := r.db.WithContext(ctx).
 		Preload("Models").
 		Preload("Endpoints").
+		Preload("Keys").
 		Find(&providerModels).Error
 
 	if err != nil {
@@ -113,13 +141,19 @@ func (r *ProviderRepository) GetAll(ctx context.Context) ([]*provider.Provider,
 
 func (r *ProviderRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Delete related models and endpoints first (should cascade but being explicit)
+		// Delete related models, endpoints, keys and template versions first (should cascade but being explicit)
 		if err := tx.Where("provider_id = ?", id).Delete(&model.ModelModel{}).Error; err != nil {
 			return err
 		}
 		if err := tx.Where("provider_id = ?", id).Delete(&model.EndpointModel{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("provider_id = ?", id).Delete(&model.KeyModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("provider_id = ?", id).Delete(&model.TemplateVersionModel{}).Error; err != nil {
+			return err
+		}
 
 		// Delete the provider
 		return tx.Where("id = ?", id).Delete(&model.ProviderModel{}).Error
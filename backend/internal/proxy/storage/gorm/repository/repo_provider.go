@@ -15,4 +15,13 @@ func NewRepositoryProvider(tx *gorm.DB) repository.RepositoryProvider {
 
 func (p *RepositoryProvider) ProviderRepository() repository.ProviderRepository {
 	return NewProviderRepository(p.tx)
-}
\ No newline at end of file
+}
+func (p *RepositoryProvider) RouteRepository() repository.RouteRepository {
+	return NewRouteRepository(p.tx)
+}
+func (p *RepositoryProvider) UsageRepository() repository.UsageRepository {
+	return NewUsageRepository(p.tx)
+}
+func (p *RepositoryProvider) TemplateVersionRepository() repository.TemplateVersionRepository {
+	return NewTemplateVersionRepository(p.tx)
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/route"
+	"github.com/basetable/basetable/backend/internal/proxy/storage/gorm/model"
+)
+
+type RouteRepository struct {
+	db *gorm.DB
+}
+
+var _ repository.RouteRepository = (*RouteRepository)(nil)
+
+func NewRouteRepository(db *gorm.DB) *RouteRepository {
+	return &RouteRepository{db: db}
+}
+
+func (r *RouteRepository) Save(ctx context.Context, rt *route.Route) error {
+	return r.db.WithContext(ctx).Save(model.MapDomainRouteToModel(rt)).Error
+}
+
+func (r *RouteRepository) GetByID(ctx context.Context, id string) (*route.Route, error) {
+	var routeModel model.RouteModel
+
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&routeModel).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return routeModel.MapToDomain(), nil
+}
+
+func (r *RouteRepository) GetByIDForUpdate(ctx context.Context, id string) (*route.Route, error) {
+	var routeModel model.RouteModel
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&routeModel).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return routeModel.MapToDomain(), nil
+}
+
+func (r *RouteRepository) GetByModel(ctx context.Context, modelName string) (*route.Route, error) {
+	var routeModel model.RouteModel
+
+	err := r.db.WithContext(ctx).
+		Where("model = ?", modelName).
+		First(&routeModel).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return routeModel.MapToDomain(), nil
+}
+
+func (r *RouteRepository) GetAll(ctx context.Context) ([]*route.Route, error) {
+	var routeModels []model.RouteModel
+
+	if err := r.db.WithContext(ctx).Find(&routeModels).Error; err != nil {
+		return nil, err
+	}
+
+	routes := make([]*route.Route, len(routeModels))
+	for i := range routeModels {
+		routes[i] = routeModels[i].MapToDomain()
+	}
+
+	return routes, nil
+}
+
+func (r *RouteRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.RouteModel{}).Error
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/provider"
+	"github.com/basetable/basetable/backend/internal/proxy/storage/gorm/model"
+)
+
+type TemplateVersionRepository struct {
+	db *gorm.DB
+}
+
+var _ repository.TemplateVersionRepository = (*TemplateVersionRepository)(nil)
+
+func NewTemplateVersionRepository(db *gorm.DB) *TemplateVersionRepository {
+	return &TemplateVersionRepository{db: db}
+}
+
+func (r *TemplateVersionRepository) Save(ctx context.Context, version provider.TemplateVersion) error {
+	return r.db.WithContext(ctx).Create(model.MapDomainTemplateVersionToModel(version)).Error
+}
+
+func (r *TemplateVersionRepository) GetByNumber(ctx context.Context, providerID string, number int) (provider.TemplateVersion, error) {
+	var versionModel model.TemplateVersionModel
+
+	err := r.db.WithContext(ctx).
+		Where("provider_id = ? AND number = ?", providerID, number).
+		First(&versionModel).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return provider.TemplateVersion{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return provider.TemplateVersion{}, err
+	}
+
+	return versionModel.MapToDomain(), nil
+}
+
+func (r *TemplateVersionRepository) ListByProvider(ctx context.Context, providerID string) ([]provider.TemplateVersion, error) {
+	var versionModels []model.TemplateVersionModel
+
+	err := r.db.WithContext(ctx).
+		Where("provider_id = ?", providerID).
+		Order("number DESC").
+		Find(&versionModels).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]provider.TemplateVersion, len(versionModels))
+	for i := range versionModels {
+		versions[i] = versionModels[i].MapToDomain()
+	}
+
+	return versions, nil
+}
+
+func (r *TemplateVersionRepository) LatestNumber(ctx context.Context, providerID string) (int, error) {
+	var number int
+
+	err := r.db.WithContext(ctx).
+		Model(&model.TemplateVersionModel{}).
+		Where("provider_id = ?", providerID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&number).Error
+
+	return number, err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/repository"
+	"github.com/basetable/basetable/backend/internal/proxy/domain/usage"
+	"github.com/basetable/basetable/backend/internal/proxy/storage/gorm/model"
+)
+
+type UsageRepository struct {
+	db *gorm.DB
+}
+
+var _ repository.UsageRepository = (*UsageRepository)(nil)
+
+func NewUsageRepository(db *gorm.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// groupColumns maps each grouping onto its rollup column. Only these values
+// are ever interpolated into the query.
+var groupColumns = map[usage.GroupBy]string{
+	usage.GroupByModel:    "model_key",
+	usage.GroupByProvider: "provider_id",
+	usage.GroupByEndpoint: "endpoint",
+}
+
+// Save writes the record and adds it to its daily rollup in one transaction.
+func (r *UsageRepository) Save(ctx context.Context, record *usage.Record) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model.MapDomainUsageRecordToModel(record)).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "account_id"}, {Name: "day"}, {Name: "provider_id"}, {Name: "model_key"}, {Name: "endpoint"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "requests"}, Value: gorm.Expr("usage_daily_rollups.requests + excluded.requests")},
+				{Column: clause.Column{Name: "failed_requests"}, Value: gorm.Expr("usage_daily_rollups.failed_requests + excluded.failed_requests")},
+				{Column: clause.Column{Name: "prompt_tokens"}, Value: gorm.Expr("usage_daily_rollups.prompt_tokens + excluded.prompt_tokens")},
+				{Column: clause.Column{Name: "completion_tokens"}, Value: gorm.Expr("usage_daily_rollups.completion_tokens + excluded.completion_tokens")},
+				{Column: clause.Column{Name: "cost_microcredits"}, Value: gorm.Expr("usage_daily_rollups.cost_microcredits + excluded.cost_microcredits")},
+			},
+		}).Create(model.MapDomainUsageRecordToRollup(record)).Error
+	})
+}
+
+func (r *UsageRepository) Aggregate(ctx context.Context, query usage.Query) ([]usage.Aggregate, error) {
+	column, ok := groupColumns[query.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported grouping %q", query.GroupBy)
+	}
+
+	db := r.db.WithContext(ctx).
+		Model(&model.UsageRollupModel{}).
+		Select("date_trunc(?, day) AS bucket, "+column+" AS key, "+
+			"SUM(requests) AS requests, SUM(failed_requests) AS failed_requests, "+
+			"SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, "+
+			"SUM(cost_microcredits) AS cost", query.Period.String()).
+		Where("day >= ? AND day < ?", query.From, query.To)
+
+	if query.AccountID != "" {
+		db = db.Where("account_id = ?", query.AccountID)
+	}
+
+	var rows []model.UsageAggregateRow
+	if err := db.Group("bucket, key").Order("bucket, key").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	aggregates := make([]usage.Aggregate, len(rows))
+	for i := range rows {
+		aggregates[i] = rows[i].MapToDomain()
+	}
+
+	return aggregates, nil
+}
+
+func (r *UsageRepository) GetByRequestID(ctx context.Context, requestID string) ([]*usage.Record, error) {
+	var recordModels []model.UsageRecordModel
+
+	err := r.db.WithContext(ctx).
+		Where("request_id = ?", requestID).
+		Order("created_at").
+		Find(&recordModels).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*usage.Record, len(recordModels))
+	for i := range recordModels {
+		records[i] = recordModels[i].MapToDomain()
+	}
+
+	return records, nil
+}
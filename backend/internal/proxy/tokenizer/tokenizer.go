@@ -0,0 +1,131 @@
+package tokenizer
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/basetable/basetable/backend/internal/proxy/application/service"
+)
+
+// Approximate estimates token counts from the shape of the text instead of
+// a vocabulary. Words cost one token per WordChars characters, punctuation
+// and symbols one token each, and ideographic scripts one token per
+// character. Scale corrects for how efficient the family's real vocabulary
+// is on ordinary prose.
+type Approximate struct {
+	name      string
+	wordChars int
+	scale     float64
+}
+
+var _ service.Tokenizer = (*Approximate)(nil)
+
+func NewApproximate(name string, wordChars int, scale float64) *Approximate {
+	return &Approximate{name: name, wordChars: wordChars, scale: scale}
+}
+
+func (a *Approximate) Name() string {
+	return a.name
+}
+
+func (a *Approximate) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	tokens, word := 0, 0
+	flush := func() {
+		if word > 0 {
+			tokens += (word + a.wordChars - 1) / a.wordChars
+			word = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word++
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return int(math.Ceil(float64(tokens) * a.scale))
+}
+
+// Built-in approximations per model family.
+var (
+	Generic   = NewApproximate("approximate", 5, 1.15)
+	OpenAI    = NewApproximate("openai", 6, 1.1)
+	Anthropic = NewApproximate("anthropic", 5, 1.2)
+	Gemini    = NewApproximate("gemini", 6, 1.1)
+	Llama     = NewApproximate("llama", 5, 1.15)
+)
+
+// Registry picks a tokenizer by model key prefix, falling back to Generic.
+type Registry struct {
+	mu       sync.RWMutex
+	prefixes map[string]service.Tokenizer
+}
+
+var _ service.Tokenizers = (*Registry)(nil)
+
+// NewRegistry returns a registry with the built-in model families.
+func NewRegistry() *Registry {
+	r := &Registry{prefixes: make(map[string]service.Tokenizer)}
+
+	for _, prefix := range []string{"gpt-", "chatgpt", "o1", "o3", "o4"} {
+		r.Register(prefix, OpenAI)
+	}
+	r.Register("claude", Anthropic)
+	for _, prefix := range []string{"gemini", "gemma"} {
+		r.Register(prefix, Gemini)
+	}
+	for _, prefix := range []string{"llama", "mistral", "mixtral", "codestral"} {
+		r.Register(prefix, Llama)
+	}
+
+	return r
+}
+
+// Register maps model keys starting with prefix to the tokenizer, replacing
+// any tokenizer registered for the same prefix.
+func (r *Registry) Register(prefix string, t service.Tokenizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prefixes[strings.ToLower(prefix)] = t
+}
+
+// ForModel returns the tokenizer of the longest matching prefix. Vendor
+// namespaces such as "anthropic/claude-sonnet" are ignored.
+func (r *Registry) ForModel(modelKey string) service.Tokenizer {
+	key := strings.ToLower(modelKey)
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		key = key[i+1:]
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		best      service.Tokenizer = Generic
+		bestMatch int
+	)
+	for prefix, t := range r.prefixes {
+		if len(prefix) > bestMatch && strings.HasPrefix(key, prefix) {
+			best, bestMatch = t, len(prefix)
+		}
+	}
+
+	return best
+}
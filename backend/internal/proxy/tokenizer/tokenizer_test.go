@@ -0,0 +1,63 @@
+package tokenizer
+
+import "testing"
+
+func TestApproximateCountTokens(t *testing.T) {
+	tokenizer := NewApproximate("test", 5, 1)
+
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"Empty", "", 0},
+		{"Short word", "hello", 1},
+		{"Long word", "internationalization", 4},
+		{"Words", "hello there world", 3},
+		{"Punctuation", "hi, you!", 4},
+		{"Chinese", "你好世界", 4},
+		{"Japanese", "こんにちは", 5},
+		{"Korean", "안녕하세요", 5},
+		{"Mixed scripts", "hello 世界", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenizer.CountTokens(tt.text); got != tt.want {
+				t.Errorf("Expected %d tokens, got %d", tt.want, got)
+			}
+		})
+	}
+
+	if got := NewApproximate("scaled", 5, 1.5).CountTokens("hello"); got != 2 {
+		t.Errorf("Expected scaled count to round up to 2, got %d", got)
+	}
+}
+
+func TestRegistryForModel(t *testing.T) {
+	custom := NewApproximate("custom", 4, 1)
+	registry := NewRegistry()
+	registry.Register("GPT-4o-mini", custom)
+
+	tests := []struct {
+		name     string
+		modelKey string
+		want     string
+	}{
+		{"Family prefix", "gpt-4o", OpenAI.Name()},
+		{"Longest prefix", "gpt-4o-mini-2024-07-18", custom.Name()},
+		{"Case insensitive", "Claude-Sonnet-4", Anthropic.Name()},
+		{"Vendor namespace", "anthropic/claude-sonnet-4", Anthropic.Name()},
+		{"Nested namespace", "openrouter/meta-llama/llama-3.1-70b", Llama.Name()},
+		{"Namespace alone does not match", "google/unknown-model", Generic.Name()},
+		{"Unknown model", "some-model", Generic.Name()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.ForModel(tt.modelKey).Name(); got != tt.want {
+				t.Errorf("Expected tokenizer %s, got %s", tt.want, got)
+			}
+		})
+	}
+}